@@ -0,0 +1,77 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/panjjo/gosip/m"
+	sipapi "github.com/panjjo/gosip/sip"
+)
+
+// @Summary     语音广播
+// @Description 向一个或多个语音输出通道发送广播通知，设备收到通知后发起INVITE，服务端应答媒体服务器中的音频源
+// @Description 音频源需先推送到媒体服务器 broadcast 应用下，每个通道独立返回广播结果
+// @Tags        broadcast
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       channelids formData string true "语音输出通道id，多个使用英文逗号分隔"
+// @Param       stream     formData string true "音频源stream，媒体服务器中 broadcast 应用下的流id"
+// @Success     0          {object} []sipapi.Broadcast
+// @Failure     1000       {object} string
+// @Failure     1001       {object} string
+// @Failure     1002       {object} string
+// @Failure     1003       {object} string
+// @Router      /broadcasts [post]
+func BroadcastsCreate(c *gin.Context) {
+	stream := c.PostForm("stream")
+	if stream == "" {
+		m.JsonResponse(c, m.StatusParamsERR, "缺少音频源stream")
+		return
+	}
+	channelIDs := []string{}
+	for _, id := range strings.Split(c.PostForm("channelids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			channelIDs = append(channelIDs, id)
+		}
+	}
+	if len(channelIDs) == 0 {
+		m.JsonResponse(c, m.StatusParamsERR, "缺少通道id")
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, sipapi.SipBroadcast(channelIDs, stream))
+}
+
+// @Summary     广播列表
+// @Description 查询当前正在进行的语音广播
+// @Tags        broadcast
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Success     0    {object} []sipapi.Broadcast
+// @Failure     1000 {object} string
+// @Failure     1001 {object} string
+// @Failure     1002 {object} string
+// @Failure     1003 {object} string
+// @Router      /broadcasts [get]
+func BroadcastsList(c *gin.Context) {
+	m.JsonResponse(c, m.StatusSucc, sipapi.GetBroadcasts())
+}
+
+// @Summary     停止广播
+// @Description 结束通道的语音广播，向设备发送BYE并停止媒体服务器发送音频
+// @Tags        broadcast
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id   path     string true "语音输出通道id"
+// @Success     0    {object} string
+// @Failure     1000 {object} string
+// @Failure     1001 {object} string
+// @Failure     1002 {object} string
+// @Failure     1003 {object} string
+// @Router      /broadcasts/{id} [delete]
+func BroadcastsStop(c *gin.Context) {
+	if err := sipapi.SipStopBroadcast(c.Param("id")); err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, "")
+}
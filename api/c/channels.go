@@ -1,7 +1,9 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/panjjo/gorm"
@@ -65,7 +67,12 @@ func DeviceChannelsSync(c *gin.Context) {
 // @Param       id         path     string true  "设备id"
 // @Param       memo       formData string false "通道备注"
 // @Param       streamtype formData string false "播放类型，pull 媒体服务器拉流，push 摄像头推流,默认push"
-// @Param       url        formData string false "静态拉流地址，streamtype=pull 时生效。"
+// @Param       url        formData string false "静态拉流地址，streamtype=pull 时生效，支持 rtsp/rtmp/http-flv"
+// @Param       username   formData string false "拉流账号，streamtype=pull 时生效"
+// @Param       password   formData string false "拉流密码，streamtype=pull 时生效"
+// @Param       rtptype    formData int    false "rtsp拉流方式 0 tcp 1 udp 2 组播，默认tcp"
+// @Param       retry      formData int    false "拉流失败重试次数，0或-1为无限重试"
+// @Param       transport  formData string false "媒体流传输方式 udp/tcp_passive/tcp_active，通道未设置时使用默认配置"
 // @Success     0          {object} sipapi.Channels
 // @Failure     1000    {object} string
 // @Failure     1001    {object} string
@@ -98,6 +105,10 @@ func ChannelCreate(c *gin.Context) {
 	if streamtype == m.StreamTypePull {
 		channel.StreamType = m.StreamTypePull
 		channel.URL = c.PostForm("url")
+		if err := channelPullParams(c, &channel); err != nil {
+			m.JsonResponse(c, m.StatusParamsERR, err.Error())
+			return
+		}
 	} else {
 		channel.StreamType = m.StreamTypePush
 	}
@@ -129,7 +140,13 @@ func ChannelCreate(c *gin.Context) {
 // @Param       id         path     string true  "通道id"
 // @Param       memo       formData string false "通道备注"
 // @Param       streamtype formData string false "播放类型，pull 媒体服务器拉流，push 摄像头推流,默认push"
-// @Param       url        formData string false "静态拉流地址，streamtype=pull 时生效。"
+// @Param       url        formData string false "静态拉流地址，streamtype=pull 时生效，支持 rtsp/rtmp/http-flv"
+// @Param       username   formData string false "拉流账号，streamtype=pull 时生效"
+// @Param       password   formData string false "拉流密码，streamtype=pull 时生效"
+// @Param       rtptype    formData int    false "rtsp拉流方式 0 tcp 1 udp 2 组播，默认tcp"
+// @Param       retry      formData int    false "拉流失败重试次数，0或-1为无限重试"
+// @Param       transport  formData string false "媒体流传输方式 udp/tcp_passive/tcp_active，通道未设置时使用默认配置"
+// @Param       zone       formData string false "媒体服务器节点分区，点播时优先使用同分区节点"
 // @Success     0          {object} sipapi.Channels
 // @Failure     1000       {object} string
 // @Failure     1001       {object} string
@@ -162,10 +179,25 @@ func ChannelsUpdate(c *gin.Context) {
 			channel.URL = ""
 		}
 	}
-	url := c.PostForm("url")
-	if streamtype != "" && channel.StreamType == m.StreamTypePull {
+	if url, ok := c.GetPostForm("url"); ok && channel.StreamType == m.StreamTypePull {
 		channel.URL = url
 	}
+	if channel.StreamType == m.StreamTypePull {
+		if err := channelPullParams(c, channel); err != nil {
+			m.JsonResponse(c, m.StatusParamsERR, err.Error())
+			return
+		}
+	}
+	if transport := c.PostForm("transport"); transport != "" {
+		if !m.CheckTransport(transport) {
+			m.JsonResponse(c, m.StatusParamsERR, "传输方式错误")
+			return
+		}
+		channel.Transport = transport
+	}
+	if zone, ok := c.GetPostForm("zone"); ok {
+		channel.Zone = zone
+	}
 
 	if err := db.Save(db.DBClient, channel); err != nil {
 		m.JsonResponse(c, m.StatusDBERR, err)
@@ -174,6 +206,31 @@ func ChannelsUpdate(c *gin.Context) {
 	m.JsonResponse(c, m.StatusSucc, channel)
 }
 
+// 读取拉流通道的账号、拉流方式、重试次数，并检查拉流地址
+func channelPullParams(c *gin.Context, channel *sipapi.Channels) error {
+	if username, ok := c.GetPostForm("username"); ok {
+		channel.PullUsername = username
+	}
+	if password, ok := c.GetPostForm("password"); ok {
+		channel.PullPassword = password
+	}
+	if v := c.PostForm("rtptype"); v != "" {
+		rtpType, err := strconv.Atoi(v)
+		if err != nil || !sipapi.CheckPullRtpType(rtpType) {
+			return errors.New("rtsp拉流方式错误")
+		}
+		channel.PullRtpType = rtpType
+	}
+	if v := c.PostForm("retry"); v != "" {
+		retry, err := strconv.Atoi(v)
+		if err != nil || retry < -1 {
+			return errors.New("拉流重试次数错误")
+		}
+		channel.PullRetry = retry
+	}
+	return sipapi.CheckPullURL(channel.URL)
+}
+
 type ChannelsListResponse struct {
 	Total int64
 	List  []sipapi.Channels
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/panjjo/gosip/api/middleware"
+	"github.com/panjjo/gosip/db"
+	"github.com/panjjo/gosip/m"
+	sipapi "github.com/panjjo/gosip/sip"
+)
+
+// 发送设备控制指令并返回设备应答
+func deviceControl(c *gin.Context, ctl sipapi.DeviceControl) {
+	ctl.Operator = middleware.Principal(c)
+	ctl.IP = c.ClientIP()
+	res, err := sipapi.SipDeviceControl(ctl)
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, res)
+}
+
+// @Summary     设备远程启动
+// @Description 向设备发送TeleBoot远程启动指令，设备重启不返回应答，指令发送成功即返回
+// @Tags        control
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id       path     string true  "设备id"
+// @Success     0        {object} sipapi.MessageResponse
+// @Failure     1000     {object} string
+// @Failure     1001     {object} string
+// @Failure     1002     {object} string
+// @Failure     1003     {object} string
+// @Router      /devices/{id}/teleboot [post]
+func DevicesTeleBoot(c *gin.Context) {
+	deviceControl(c, sipapi.DeviceControl{DeviceID: c.Param("id"), Cmd: sipapi.ControlTeleBoot})
+}
+
+// @Summary     布防/撤防
+// @Description 向设备或报警通道发送GuardCmd布防/撤防指令
+// @Tags        control
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id        path     string true  "设备id"
+// @Param       cmd       formData string true  "SetGuard 布防 ResetGuard 撤防"
+// @Param       channelid formData string false "报警通道id，为空时控制设备本身"
+// @Success     0         {object} sipapi.MessageResponse
+// @Failure     1000      {object} string
+// @Failure     1001      {object} string
+// @Failure     1002      {object} string
+// @Failure     1003      {object} string
+// @Router      /devices/{id}/guard [post]
+func DevicesGuard(c *gin.Context) {
+	deviceControl(c, sipapi.DeviceControl{
+		DeviceID:  c.Param("id"),
+		ChannelID: c.PostForm("channelid"),
+		Cmd:       sipapi.ControlGuard,
+		Value:     c.PostForm("cmd"),
+	})
+}
+
+// @Summary     报警复位
+// @Description 向设备或报警通道发送AlarmCmd报警复位指令，不传报警方式和报警类型时复位全部报警
+// @Tags        control
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id          path     string true  "设备id"
+// @Param       channelid   formData string false "报警通道id，为空时控制设备本身"
+// @Param       alarmmethod formData string false "报警方式 1电话 2设备 3短信 4GPS 5视频 6设备故障 7其他"
+// @Param       alarmtype   formData string false "报警类型，1-99，含义与报警方式相关"
+// @Success     0           {object} sipapi.MessageResponse
+// @Failure     1000        {object} string
+// @Failure     1001        {object} string
+// @Failure     1002        {object} string
+// @Failure     1003        {object} string
+// @Router      /devices/{id}/alarm_reset [post]
+func DevicesAlarmReset(c *gin.Context) {
+	deviceControl(c, sipapi.DeviceControl{
+		DeviceID:    c.Param("id"),
+		ChannelID:   c.PostForm("channelid"),
+		Cmd:         sipapi.ControlAlarm,
+		AlarmMethod: c.PostForm("alarmmethod"),
+		AlarmType:   c.PostForm("alarmtype"),
+	})
+}
+
+// @Summary     设备录像控制
+// @Description 向通道发送RecordCmd指令，开始或停止设备端录像
+// @Tags        control
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id       path     string true  "通道id"
+// @Param       cmd      formData string true  "Record 开始录像 StopRecord 停止录像"
+// @Success     0        {object} sipapi.MessageResponse
+// @Failure     1000     {object} string
+// @Failure     1001     {object} string
+// @Failure     1002     {object} string
+// @Failure     1003     {object} string
+// @Router      /channels/{id}/record [post]
+func ChannelsRecord(c *gin.Context) {
+	deviceControl(c, sipapi.DeviceControl{ChannelID: c.Param("id"), Cmd: sipapi.ControlRecord, Value: c.PostForm("cmd")})
+}
+
+// @Summary     强制关键帧
+// @Description 向通道发送IFameCmd指令，要求设备立即发送关键帧，设备不返回应答
+// @Tags        control
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id       path     string true  "通道id"
+// @Success     0        {object} sipapi.MessageResponse
+// @Failure     1000     {object} string
+// @Failure     1001     {object} string
+// @Failure     1002     {object} string
+// @Failure     1003     {object} string
+// @Router      /channels/{id}/iframe [post]
+func ChannelsIFrame(c *gin.Context) {
+	deviceControl(c, sipapi.DeviceControl{ChannelID: c.Param("id"), Cmd: sipapi.ControlIFame})
+}
+
+type ControlLogsListResponse struct {
+	Total int64
+	List  []sipapi.ControlLogs
+}
+
+// @Summary     设备控制日志
+// @Description 查询设备控制审计日志
+// @Tags        control
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       limit   query    integer false "条数(0-100) 默认20"
+// @Param       skip    query    integer false "间隔 默认0"
+// @Param       sort    query    string  false "排序,例:-key,根据key倒序,key,根据key正序"
+// @Param       filters query    string  false "查询条件,使用规则详情请看帮助"
+// @Success     0       {object} ControlLogsListResponse
+// @Failure     1000    {object} string
+// @Failure     1001    {object} string
+// @Failure     1002    {object} string
+// @Failure     1003    {object} string
+// @Router      /control_logs [get]
+func ControlLogsList(c *gin.Context) {
+	limit := m.GetLimit(c)
+	skip := m.GetSkip(c)
+	sort := m.GetSort(c)
+	logs := []sipapi.ControlLogs{}
+	total, err := db.FindWithJson(db.DBClient, new(sipapi.ControlLogs), &logs, c.Query("filters"), sort, skip, limit, true)
+	if err != nil {
+		m.JsonResponse(c, m.StatusDBERR, err)
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, ControlLogsListResponse{
+		Total: total,
+		List:  logs,
+	})
+}
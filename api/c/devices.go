@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -197,6 +198,32 @@ func DevicesDelete(c *gin.Context) {
 	m.JsonResponse(c, m.StatusSucc, "")
 }
 
+// @Summary     设备状态查询
+// @Description 向设备发送DeviceStatus查询，返回设备在线、工作、编码、录像状态以及报警通道布防状态，同时根据返回结果校正通道状态
+// @Tags        devices
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id   path     string true "设备id"
+// @Success     0    {object} sipapi.DeviceStatus
+// @Failure     1000 {object} string
+// @Failure     1001 {object} string
+// @Failure     1002 {object} string
+// @Failure     1003 {object} string
+// @Router      /devices/{id}/status [get]
+func DevicesStatus(c *gin.Context) {
+	activeDevice, ok := sipapi.GetActiveDevice(c.Param("id"))
+	if !ok {
+		m.JsonResponse(c, m.StatusParamsERR, "设备未注册或离线")
+		return
+	}
+	res, err := sipapi.SipDeviceStatus(activeDevice)
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err)
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, res)
+}
+
 // 定义PTZ控制类型为字符串类型枚举
 type PTZControlType string
 
@@ -285,6 +312,11 @@ func ParsePTZCmd(ptzType PTZControlType, paramValue int) string {
 		// 未知类型，保持默认值
 	}
 
+	return ptzCmdString(ptzCmdStr)
+}
+
+// ptzCmdString 计算校验码并将PTZ指令转换为十六进制字符串
+func ptzCmdString(ptzCmdStr [8]byte) string {
 	// 计算校验和（第8个字节为前7个字节的和）
 	for i := 0; i < 7; i++ {
 		ptzCmdStr[7] += ptzCmdStr[i]
@@ -301,33 +333,49 @@ func ParsePTZCmd(ptzType PTZControlType, paramValue int) string {
 }
 
 // @Summary     设备PTZ云台控制
-// @Description 通过此接口控制设备云台方向和速度
+// @Description 通过此接口控制设备云台方向和速度，传入channel_id时控制指定通道，否则控制设备本身（单通道IPC），被其他同等及以上优先级的请求方控制时返回错误
 // @Tags        devices
 // @Accept      x-www-form-urlencoded
 // @Produce     json
 // @Param       device_id  formData string true  "设备ID"
-// @Param       ptz_type formData string true  "方向(halt,right,rightup,up,leftup,left,leftdown,down,rightdown,zoom,iris,focus)"
-// @Param       speed     formData int    false  "速度(1-255)"
+// @Param       channel_id formData string false "通道ID，多通道NVR下需指定"
+// @Param       ptz_type   formData string true  "方向(halt,right,rightup,up,leftup,left,leftdown,down,rightdown,zoom,iris,focus)"
+// @Param       speed      formData int    false "速度(1-255)"
 // @Success     0    {object} string
 // @Failure     1000 {object} string
 // @Router      /devices/ptz [post]
 func DevicesPTZControl(c *gin.Context) {
 	deviceId := c.PostForm("device_id")
+	channelId := c.PostForm("channel_id")
 	ptzType := c.PostForm("ptz_type")
 	speedStr := c.PostForm("speed")
 	ptzSpeed := 100
 	if speedStr != "" {
 		ptzSpeed, _ = strconv.Atoi(speedStr)
 	}
+	ptzCmd := ParsePTZCmd(PTZControlType(ptzType), ptzSpeed)
+	if channelId != "" {
+		// 按通道控制
+		channel := &sipapi.Channels{ChannelID: channelId}
+		if err := db.Get(db.DBClient, channel); err != nil || channel.DeviceID != deviceId {
+			m.JsonResponse(c, m.StatusParamsERR, "通道不存在")
+			return
+		}
+		if err := sipapi.SipChannelPTZControl(channelId, ptzOperator(c), ptzCmd); err != nil {
+			m.JsonResponse(c, m.StatusParamsERR, err.Error())
+			return
+		}
+		m.JsonResponse(c, m.StatusSucc, "云台控制指令已发送")
+		return
+	}
 	activeDevice, ok := sipapi.GetActiveDevice(deviceId)
 	if !ok {
 		m.JsonResponse(c, m.StatusParamsERR, "设备未注册或离线")
 		return
 	}
-	ptzCmd := ParsePTZCmd(PTZControlType(ptzType), ptzSpeed)
-	err := sipapi.SipPTZControl(activeDevice, ptzCmd)
+	err := sipapi.SipDevicePTZControl(activeDevice, ptzOperator(c), ptzCmd)
 	if err != nil {
-		m.JsonResponse(c, m.StatusDBERR, err.Error())
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
 		return
 	}
 	m.JsonResponse(c, m.StatusSucc, "云台控制指令已发送")
@@ -452,3 +500,98 @@ func DevicesPTZControl(c *gin.Context) {
 // 	router.POST("/index/hook/:method", apiWebHooks)
 // 	logrus.Fatal(http.ListenAndServe(config.API, router))
 // }
+
+// @Summary     设备配置查询
+// @Description 向设备发送ConfigDownload查询，返回设备基本参数、视频参数范围、SVAC编解码配置、OSD配置等
+// @Tags        devices
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id        path     string true  "设备id"
+// @Param       type      query    string true  "配置类型，BasicParam/VideoParamOpt/SVACEncodeConfig/SVACDecodeConfig/OSDConfig，多个类型使用/分隔"
+// @Param       channelid query    string false "通道id，查询设备下指定通道的配置"
+// @Success     0         {object} sipapi.ConfigDownload
+// @Failure     1000      {object} string
+// @Failure     1001      {object} string
+// @Failure     1002      {object} string
+// @Failure     1003      {object} string
+// @Router      /devices/{id}/config [get]
+func DevicesConfigQuery(c *gin.Context) {
+	configType := c.Query("type")
+	if !sipapi.CheckConfigType(configType) {
+		m.JsonResponse(c, m.StatusParamsERR, "配置类型错误")
+		return
+	}
+	activeDevice, ok := sipapi.GetActiveDevice(c.Param("id"))
+	if !ok {
+		m.JsonResponse(c, m.StatusParamsERR, "设备未注册或离线")
+		return
+	}
+	channel, err := deviceConfigChannel(activeDevice.DeviceID, c.Query("channelid"))
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err)
+		return
+	}
+	res, err := sipapi.SipConfigDownload(activeDevice, channel, configType)
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err)
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, res)
+}
+
+// @Summary     设备配置修改
+// @Description 向设备发送DeviceConfig配置，请求体为json，至少包含一项配置，等待设备返回配置结果
+// @Tags        devices
+// @Accept      json
+// @Produce     json
+// @Param       id        path     string                     true  "设备id"
+// @Param       channelid query    string                     false "通道id，配置设备下指定通道"
+// @Param       config    body     sipapi.DeviceConfigRequest true  "配置内容"
+// @Success     0         {object} sipapi.MessageResponse
+// @Failure     1000      {object} string
+// @Failure     1001      {object} string
+// @Failure     1002      {object} string
+// @Failure     1003      {object} string
+// @Router      /devices/{id}/config [post]
+func DevicesConfig(c *gin.Context) {
+	req := &sipapi.DeviceConfigRequest{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err)
+		return
+	}
+	if req.BasicParam == nil && req.VideoParamOpt == nil && req.SVACEncodeConfig == nil && req.SVACDecodeConfig == nil && req.OSDConfig == nil {
+		m.JsonResponse(c, m.StatusParamsERR, "缺少配置项")
+		return
+	}
+	activeDevice, ok := sipapi.GetActiveDevice(c.Param("id"))
+	if !ok {
+		m.JsonResponse(c, m.StatusParamsERR, "设备未注册或离线")
+		return
+	}
+	channel, err := deviceConfigChannel(activeDevice.DeviceID, c.Query("channelid"))
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err)
+		return
+	}
+	res, err := sipapi.SipDeviceConfig(activeDevice, channel, req)
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err)
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, res)
+}
+
+// 获取设备下的指定通道，channelid 为空时返回nil
+func deviceConfigChannel(deviceID, channelID string) (*sipapi.Channels, error) {
+	if channelID == "" {
+		return nil, nil
+	}
+	channel := &sipapi.Channels{ChannelID: channelID}
+	if err := db.Get(db.DBClient, channel); err != nil {
+		return nil, err
+	}
+	if channel.DeviceID != deviceID {
+		return nil, errors.New("通道不属于该设备")
+	}
+	return channel, nil
+}
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/panjjo/gosip/m"
+	sipapi "github.com/panjjo/gosip/sip"
+)
+
+// @Summary     媒体节点列表
+// @Description 查询所有媒体服务器节点及其负载，新流按分区和负载选择节点
+// @Tags        media
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Success     0    {object} []sipapi.MediaNode
+// @Failure     1000 {object} string
+// @Failure     1001 {object} string
+// @Failure     1002 {object} string
+// @Failure     1003 {object} string
+// @Router      /media/nodes [get]
+func MediaNodesList(c *gin.Context) {
+	m.JsonResponse(c, m.StatusSucc, sipapi.MediaNodes())
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/panjjo/gosip/m"
+	sipapi "github.com/panjjo/gosip/sip"
+)
+
+// @Summary     onvif设备发现
+// @Description 在配置的网卡上发送 WS-Discovery 探测，返回应答的onvif设备，等待时间为配置的 onvif.timeout
+// @Tags        onvif
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Success     0    {object} []onvif.Device
+// @Failure     1000 {object} string
+// @Failure     1001 {object} string
+// @Failure     1002 {object} string
+// @Failure     1003 {object} string
+// @Router      /onvif/devices [get]
+func OnvifDiscover(c *gin.Context) {
+	devices, err := sipapi.OnvifDiscover()
+	if err != nil {
+		m.JsonResponse(c, m.StatusSysERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, devices)
+}
+
+type OnvifOnboardResponse struct {
+	Device   sipapi.Devices
+	Channels []sipapi.Channels
+}
+
+// @Summary     onvif设备接入
+// @Description 查询onvif设备的媒体配置，创建设备并为每个媒体配置创建拉流通道，可与国标通道一样使用播放接口
+// @Description 同一设备服务地址重复接入时更新已有通道的拉流地址和账号
+// @Tags        onvif
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       xaddr    formData string true  "设备服务地址，发现接口返回的xaddrs，如 http://192.168.1.64/onvif/device_service"
+// @Param       username formData string false "设备账号"
+// @Param       password formData string false "设备密码"
+// @Param       name     formData string false "设备名称，默认为厂商和型号"
+// @Success     0        {object} OnvifOnboardResponse
+// @Failure     1000     {object} string
+// @Failure     1001     {object} string
+// @Failure     1002     {object} string
+// @Failure     1003     {object} string
+// @Router      /onvif/devices [post]
+func OnvifOnboard(c *gin.Context) {
+	xaddr := c.PostForm("xaddr")
+	if xaddr == "" {
+		m.JsonResponse(c, m.StatusParamsERR, "缺少设备服务地址")
+		return
+	}
+	device, channels, err := sipapi.OnvifOnboard(xaddr, c.PostForm("username"), c.PostForm("password"), c.PostForm("name"))
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, OnvifOnboardResponse{Device: *device, Channels: channels})
+}
@@ -0,0 +1,676 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/panjjo/gosip/api/middleware"
+	"github.com/panjjo/gosip/m"
+	sipapi "github.com/panjjo/gosip/sip"
+)
+
+// PTZExtCmd 预置位、巡航、扫描类PTZ指令码
+type PTZExtCmd byte
+
+const (
+	PTZ_CMD_PRESET_SET   PTZExtCmd = 0x81 // 设置预置位
+	PTZ_CMD_PRESET_CALL  PTZExtCmd = 0x82 // 调用预置位
+	PTZ_CMD_PRESET_DEL   PTZExtCmd = 0x83 // 删除预置位
+	PTZ_CMD_CRUISE_ADD   PTZExtCmd = 0x84 // 加入巡航点
+	PTZ_CMD_CRUISE_DEL   PTZExtCmd = 0x85 // 删除巡航点
+	PTZ_CMD_CRUISE_SPEED PTZExtCmd = 0x86 // 设置巡航速度
+	PTZ_CMD_CRUISE_DWELL PTZExtCmd = 0x87 // 设置巡航停留时间
+	PTZ_CMD_CRUISE_START PTZExtCmd = 0x88 // 开始巡航
+	PTZ_CMD_SCAN         PTZExtCmd = 0x89 // 开始自动扫描、设置扫描边界
+	PTZ_CMD_SCAN_SPEED   PTZExtCmd = 0x8A // 设置自动扫描速度
+	PTZ_SCAN_START                 = 0x00 // 开始自动扫描
+	PTZ_SCAN_LEFT                  = 0x01 // 设置自动扫描左边界
+	PTZ_SCAN_RIGHT                 = 0x02 // 设置自动扫描右边界
+	ptzMaxData2                    = 0xFFF
+)
+
+// ParsePTZExtCmd 解析预置位、巡航、扫描类PTZ命令
+// data1 写入字节5，data2 低8位写入字节6，高4位写入字节7的高4位
+func ParsePTZExtCmd(cmd PTZExtCmd, data1, data2 int) string {
+	ptzCmdStr := [8]byte{0xA5, 0x0F, 0x01, byte(cmd), 0x00, 0x00, 0x00, 0x00}
+	ptzCmdStr[4] = byte(data1 & 0xFF)
+	ptzCmdStr[5] = byte(data2 & 0xFF)
+	ptzCmdStr[6] = byte(((data2 >> 8) & 0x0F) << 4)
+	return ptzCmdString(ptzCmdStr)
+}
+
+// 读取整型参数并检查范围，参数错误时直接返回错误信息
+func ptzIntParam(c *gin.Context, name, value string, min, max int) (int, bool) {
+	v, err := strconv.Atoi(value)
+	if err != nil || v < min || v > max {
+		m.JsonResponse(c, m.StatusParamsERR, fmt.Sprintf("%s错误，范围%d-%d", name, min, max))
+		return 0, false
+	}
+	return v, true
+}
+
+// 获取云台操作员，操作员和控制优先级均由服务端根据请求方身份确定
+func ptzOperator(c *gin.Context) sipapi.PTZOperator {
+	principal := middleware.Principal(c)
+	if principal == "" {
+		principal = c.ClientIP()
+	}
+	return sipapi.PTZOperator{Operator: principal, Priority: sipapi.PTZPriority(principal)}
+}
+
+// 发送PTZ指令并返回结果
+func ptzSend(c *gin.Context, ptzCmd string) {
+	op := ptzOperator(c)
+	if err := sipapi.SipChannelPTZControl(c.Param("id"), op, ptzCmd); err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, "云台控制指令已发送")
+}
+
+// @Summary     预置位列表
+// @Description 向设备查询通道预置位，预置位名称优先使用本地保存的名称
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id   path     string true "通道id"
+// @Success     0    {object} []sipapi.Presets
+// @Failure     1000 {object} string
+// @Failure     1001 {object} string
+// @Failure     1002 {object} string
+// @Failure     1003 {object} string
+// @Router      /channels/{id}/ptz/presets [get]
+func PTZPresetsList(c *gin.Context) {
+	list, err := sipapi.SipPresetQuery(c.Param("id"))
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, list)
+}
+
+// @Summary     设置预置位
+// @Description 将云台当前位置设置为预置位，并保存预置位名称
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id       path     string true  "通道id"
+// @Param       presetid formData int    true  "预置位编号(1-255)"
+// @Param       name     formData string false "预置位名称"
+// @Success     0        {object} sipapi.Presets
+// @Failure     1000     {object} string
+// @Failure     1001     {object} string
+// @Failure     1002     {object} string
+// @Failure     1003     {object} string
+// @Router      /channels/{id}/ptz/presets [post]
+func PTZPresetSet(c *gin.Context) {
+	presetID, ok := ptzIntParam(c, "预置位编号", c.PostForm("presetid"), 1, 255)
+	if !ok {
+		return
+	}
+	name := c.PostForm("name")
+	if len([]rune(name)) > 64 {
+		m.JsonResponse(c, m.StatusParamsERR, "预置位名称过长")
+		return
+	}
+	if name == "" {
+		name = fmt.Sprintf("预置位%d", presetID)
+	}
+	op := ptzOperator(c)
+	if err := sipapi.SipChannelPTZControl(c.Param("id"), op, ParsePTZExtCmd(PTZ_CMD_PRESET_SET, 0, presetID)); err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	preset, err := sipapi.SavePreset(c.Param("id"), presetID, name)
+	if err != nil {
+		m.JsonResponse(c, m.StatusDBERR, err)
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, preset)
+}
+
+// @Summary     调用预置位
+// @Description 云台转动到指定预置位
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id       path     string true "通道id"
+// @Param       presetid path     int    true "预置位编号(1-255)"
+// @Success     0        {object} string
+// @Failure     1000     {object} string
+// @Failure     1001     {object} string
+// @Failure     1002     {object} string
+// @Failure     1003     {object} string
+// @Router      /channels/{id}/ptz/presets/{presetid}/call [post]
+func PTZPresetCall(c *gin.Context) {
+	presetID, ok := ptzIntParam(c, "预置位编号", c.Param("presetid"), 1, 255)
+	if !ok {
+		return
+	}
+	ptzSend(c, ParsePTZExtCmd(PTZ_CMD_PRESET_CALL, 0, presetID))
+}
+
+// @Summary     删除预置位
+// @Description 删除设备上的预置位，同时删除本地保存的预置位名称
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id       path     string true "通道id"
+// @Param       presetid path     int    true "预置位编号(1-255)"
+// @Success     0        {object} string
+// @Failure     1000     {object} string
+// @Failure     1001     {object} string
+// @Failure     1002     {object} string
+// @Failure     1003     {object} string
+// @Router      /channels/{id}/ptz/presets/{presetid} [delete]
+func PTZPresetDelete(c *gin.Context) {
+	presetID, ok := ptzIntParam(c, "预置位编号", c.Param("presetid"), 1, 255)
+	if !ok {
+		return
+	}
+	op := ptzOperator(c)
+	if err := sipapi.SipChannelPTZControl(c.Param("id"), op, ParsePTZExtCmd(PTZ_CMD_PRESET_DEL, 0, presetID)); err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	if err := sipapi.DeletePreset(c.Param("id"), presetID); err != nil {
+		m.JsonResponse(c, m.StatusDBERR, err)
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, "")
+}
+
+// @Summary     加入巡航点
+// @Description 将预置位加入巡航轨迹
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id       path     string true "通道id"
+// @Param       group    path     int    true "巡航组号(0-255)"
+// @Param       presetid formData int    true "预置位编号(1-255)"
+// @Success     0        {object} string
+// @Failure     1000     {object} string
+// @Failure     1001     {object} string
+// @Failure     1002     {object} string
+// @Failure     1003     {object} string
+// @Router      /channels/{id}/ptz/cruises/{group}/points [post]
+func PTZCruisePointAdd(c *gin.Context) {
+	group, ok := ptzIntParam(c, "巡航组号", c.Param("group"), 0, 255)
+	if !ok {
+		return
+	}
+	presetID, ok := ptzIntParam(c, "预置位编号", c.PostForm("presetid"), 1, 255)
+	if !ok {
+		return
+	}
+	ptzSend(c, ParsePTZExtCmd(PTZ_CMD_CRUISE_ADD, group, presetID))
+}
+
+// @Summary     删除巡航点
+// @Description 从巡航轨迹中删除预置位，预置位编号为0时删除整条巡航轨迹
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id       path     string true "通道id"
+// @Param       group    path     int    true "巡航组号(0-255)"
+// @Param       presetid path     int    true "预置位编号(0-255)"
+// @Success     0        {object} string
+// @Failure     1000     {object} string
+// @Failure     1001     {object} string
+// @Failure     1002     {object} string
+// @Failure     1003     {object} string
+// @Router      /channels/{id}/ptz/cruises/{group}/points/{presetid} [delete]
+func PTZCruisePointDelete(c *gin.Context) {
+	group, ok := ptzIntParam(c, "巡航组号", c.Param("group"), 0, 255)
+	if !ok {
+		return
+	}
+	presetID, ok := ptzIntParam(c, "预置位编号", c.Param("presetid"), 0, 255)
+	if !ok {
+		return
+	}
+	ptzSend(c, ParsePTZExtCmd(PTZ_CMD_CRUISE_DEL, group, presetID))
+}
+
+// @Summary     设置巡航参数
+// @Description 设置巡航速度和停留时间，至少传入一项
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id    path     string true  "通道id"
+// @Param       group path     int    true  "巡航组号(0-255)"
+// @Param       speed formData int    false "巡航速度(1-4095)"
+// @Param       dwell formData int    false "停留时间，单位秒(1-4095)"
+// @Success     0     {object} string
+// @Failure     1000  {object} string
+// @Failure     1001  {object} string
+// @Failure     1002  {object} string
+// @Failure     1003  {object} string
+// @Router      /channels/{id}/ptz/cruises/{group} [post]
+func PTZCruiseSet(c *gin.Context) {
+	group, ok := ptzIntParam(c, "巡航组号", c.Param("group"), 0, 255)
+	if !ok {
+		return
+	}
+	speedStr, dwellStr := c.PostForm("speed"), c.PostForm("dwell")
+	if speedStr == "" && dwellStr == "" {
+		m.JsonResponse(c, m.StatusParamsERR, "缺少巡航速度或停留时间")
+		return
+	}
+	cmds := []string{}
+	if speedStr != "" {
+		speed, ok := ptzIntParam(c, "巡航速度", speedStr, 1, ptzMaxData2)
+		if !ok {
+			return
+		}
+		cmds = append(cmds, ParsePTZExtCmd(PTZ_CMD_CRUISE_SPEED, group, speed))
+	}
+	if dwellStr != "" {
+		dwell, ok := ptzIntParam(c, "停留时间", dwellStr, 1, ptzMaxData2)
+		if !ok {
+			return
+		}
+		cmds = append(cmds, ParsePTZExtCmd(PTZ_CMD_CRUISE_DWELL, group, dwell))
+	}
+	op := ptzOperator(c)
+	for _, cmd := range cmds {
+		if err := sipapi.SipChannelPTZControl(c.Param("id"), op, cmd); err != nil {
+			m.JsonResponse(c, m.StatusParamsERR, err.Error())
+			return
+		}
+	}
+	m.JsonResponse(c, m.StatusSucc, "云台控制指令已发送")
+}
+
+// @Summary     开始巡航
+// @Description 按巡航轨迹开始巡航，停止巡航使用 /channels/{id}/ptz/stop
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id    path     string true "通道id"
+// @Param       group path     int    true "巡航组号(0-255)"
+// @Success     0     {object} string
+// @Failure     1000  {object} string
+// @Failure     1001  {object} string
+// @Failure     1002  {object} string
+// @Failure     1003  {object} string
+// @Router      /channels/{id}/ptz/cruises/{group}/start [post]
+func PTZCruiseStart(c *gin.Context) {
+	group, ok := ptzIntParam(c, "巡航组号", c.Param("group"), 0, 255)
+	if !ok {
+		return
+	}
+	ptzSend(c, ParsePTZExtCmd(PTZ_CMD_CRUISE_START, group, 0))
+}
+
+// @Summary     设置自动扫描参数
+// @Description 将云台当前位置设置为自动扫描左/右边界，或设置自动扫描速度，至少传入一项
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id       path     string true  "通道id"
+// @Param       group    path     int    true  "扫描组号(0-255)"
+// @Param       boundary formData string false "扫描边界 left/right"
+// @Param       speed    formData int    false "扫描速度(1-4095)"
+// @Success     0        {object} string
+// @Failure     1000     {object} string
+// @Failure     1001     {object} string
+// @Failure     1002     {object} string
+// @Failure     1003     {object} string
+// @Router      /channels/{id}/ptz/scans/{group} [post]
+func PTZScanSet(c *gin.Context) {
+	group, ok := ptzIntParam(c, "扫描组号", c.Param("group"), 0, 255)
+	if !ok {
+		return
+	}
+	boundary, speedStr := c.PostForm("boundary"), c.PostForm("speed")
+	if boundary == "" && speedStr == "" {
+		m.JsonResponse(c, m.StatusParamsERR, "缺少扫描边界或扫描速度")
+		return
+	}
+	cmds := []string{}
+	switch boundary {
+	case "":
+	case "left":
+		cmds = append(cmds, ParsePTZExtCmd(PTZ_CMD_SCAN, group, PTZ_SCAN_LEFT))
+	case "right":
+		cmds = append(cmds, ParsePTZExtCmd(PTZ_CMD_SCAN, group, PTZ_SCAN_RIGHT))
+	default:
+		m.JsonResponse(c, m.StatusParamsERR, "扫描边界错误")
+		return
+	}
+	if speedStr != "" {
+		speed, ok := ptzIntParam(c, "扫描速度", speedStr, 1, ptzMaxData2)
+		if !ok {
+			return
+		}
+		cmds = append(cmds, ParsePTZExtCmd(PTZ_CMD_SCAN_SPEED, group, speed))
+	}
+	op := ptzOperator(c)
+	for _, cmd := range cmds {
+		if err := sipapi.SipChannelPTZControl(c.Param("id"), op, cmd); err != nil {
+			m.JsonResponse(c, m.StatusParamsERR, err.Error())
+			return
+		}
+	}
+	m.JsonResponse(c, m.StatusSucc, "云台控制指令已发送")
+}
+
+// @Summary     开始自动扫描
+// @Description 在左右边界之间开始自动扫描，停止扫描使用 /channels/{id}/ptz/stop
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id    path     string true "通道id"
+// @Param       group path     int    true "扫描组号(0-255)"
+// @Success     0     {object} string
+// @Failure     1000  {object} string
+// @Failure     1001  {object} string
+// @Failure     1002  {object} string
+// @Failure     1003  {object} string
+// @Router      /channels/{id}/ptz/scans/{group}/start [post]
+func PTZScanStart(c *gin.Context) {
+	group, ok := ptzIntParam(c, "扫描组号", c.Param("group"), 0, 255)
+	if !ok {
+		return
+	}
+	ptzSend(c, ParsePTZExtCmd(PTZ_CMD_SCAN, group, PTZ_SCAN_START))
+}
+
+// @Summary     停止云台动作
+// @Description 发送停止指令，停止云台转动、巡航以及自动扫描
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id   path     string true "通道id"
+// @Success     0    {object} string
+// @Failure     1000 {object} string
+// @Failure     1001 {object} string
+// @Failure     1002 {object} string
+// @Failure     1003 {object} string
+// @Router      /channels/{id}/ptz/stop [post]
+func PTZStop(c *gin.Context) {
+	ptzSend(c, ParsePTZCmd(PTZ_CTRL_HALT, 0))
+}
+
+// @Summary     看守位设置
+// @Description 开启或关闭看守位，云台空闲超过自动归位时间后转动到看守位预置位
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id          path     string true  "通道id"
+// @Param       enabled     formData int    true  "看守位开关 0关闭 1开启"
+// @Param       resettime   formData int    false "自动归位时间，单位秒，开启时必填"
+// @Param       presetindex formData int    false "看守位预置位编号(1-255)，开启时必填"
+// @Success     0           {object} sipapi.MessageResponse
+// @Failure     1000        {object} string
+// @Failure     1001        {object} string
+// @Failure     1002        {object} string
+// @Failure     1003        {object} string
+// @Router      /channels/{id}/ptz/home [post]
+func PTZHomePosition(c *gin.Context) {
+	enabled, ok := ptzIntParam(c, "看守位开关", c.PostForm("enabled"), 0, 1)
+	if !ok {
+		return
+	}
+	resetTime, presetIndex := 0, 0
+	if enabled == 1 {
+		if resetTime, ok = ptzIntParam(c, "自动归位时间", c.PostForm("resettime"), 1, 86400); !ok {
+			return
+		}
+		if presetIndex, ok = ptzIntParam(c, "看守位预置位编号", c.PostForm("presetindex"), 1, 255); !ok {
+			return
+		}
+	}
+	res, err := sipapi.SipHomePosition(c.Param("id"), enabled, resetTime, presetIndex)
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, res)
+}
+
+// @Summary     通道云台控制
+// @Description 控制通道云台方向、变倍、光圈、聚焦，被其他同等及以上优先级的操作员控制时返回错误
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id       path     string true  "通道id"
+// @Param       ptz_type formData string true  "方向(halt,right,rightup,up,leftup,left,leftdown,down,rightdown,zoom,iris,focus)"
+// @Param       speed    formData int    false "速度(1-255)，变倍、光圈、聚焦时负数表示反向"
+// @Success     0        {object} string
+// @Failure     1000     {object} string
+// @Failure     1001     {object} string
+// @Failure     1002     {object} string
+// @Failure     1003     {object} string
+// @Router      /channels/{id}/ptz [post]
+func PTZControl(c *gin.Context) {
+	ptzCmd, ok := ptzMoveCmd(c)
+	if !ok {
+		return
+	}
+	ptzSend(c, ptzCmd)
+}
+
+// 解析方向、变倍、光圈、聚焦类PTZ指令
+func ptzMoveCmd(c *gin.Context) (string, bool) {
+	ptzType := PTZControlType(c.PostForm("ptz_type"))
+	switch ptzType {
+	case PTZ_CTRL_HALT, PTZ_CTRL_RIGHT, PTZ_CTRL_RIGHTUP, PTZ_CTRL_UP, PTZ_CTRL_LEFTUP, PTZ_CTRL_LEFT,
+		PTZ_CTRL_LEFTDOWN, PTZ_CTRL_DOWN, PTZ_CTRL_RIGHTDOWN, PTZ_CTRL_ZOOM, PTZ_CTRL_IRIS, PTZ_CTRL_FOCUS:
+	default:
+		m.JsonResponse(c, m.StatusParamsERR, "云台控制类型错误")
+		return "", false
+	}
+	speed := 100
+	if speedStr := c.PostForm("speed"); speedStr != "" {
+		v, ok := ptzIntParam(c, "速度", speedStr, -255, 255)
+		if !ok {
+			return "", false
+		}
+		speed = v
+	}
+	return ParsePTZCmd(ptzType, speed), true
+}
+
+// @Summary     开始持续转动
+// @Description 开始持续转动，客户端需要在5秒内调用心跳接口保持转动，超时未收到心跳自动发送停止指令
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id       path     string true  "通道id"
+// @Param       ptz_type formData string true  "方向(right,rightup,up,leftup,left,leftdown,down,rightdown,zoom,iris,focus)"
+// @Param       speed    formData int    false "速度(1-255)，变倍、光圈、聚焦时负数表示反向"
+// @Success     0        {object} sipapi.PTZLock
+// @Failure     1000     {object} string
+// @Failure     1001     {object} string
+// @Failure     1002     {object} string
+// @Failure     1003     {object} string
+// @Router      /channels/{id}/ptz/move [post]
+func PTZMoveStart(c *gin.Context) {
+	ptzCmd, ok := ptzMoveCmd(c)
+	if !ok {
+		return
+	}
+	op := ptzOperator(c)
+	lock, err := sipapi.SipPTZMoveStart(c.Param("id"), op, ptzCmd)
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, lock)
+}
+
+// @Summary     持续转动心跳
+// @Description 保持持续转动，需由开始转动的操作员调用
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id       path     string true  "通道id"
+// @Success     0        {object} sipapi.PTZLock
+// @Failure     1000     {object} string
+// @Failure     1001     {object} string
+// @Failure     1002     {object} string
+// @Failure     1003     {object} string
+// @Router      /channels/{id}/ptz/move/heartbeat [post]
+func PTZMoveHeartbeat(c *gin.Context) {
+	op := ptzOperator(c)
+	lock, err := sipapi.SipPTZMoveHeartbeat(c.Param("id"), op)
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, lock)
+}
+
+// @Summary     结束持续转动
+// @Description 结束持续转动并发送停止指令
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id       path     string true  "通道id"
+// @Success     0        {object} string
+// @Failure     1000     {object} string
+// @Failure     1001     {object} string
+// @Failure     1002     {object} string
+// @Failure     1003     {object} string
+// @Router      /channels/{id}/ptz/move [delete]
+func PTZMoveStop(c *gin.Context) {
+	op := ptzOperator(c)
+	if err := sipapi.SipPTZMoveStop(c.Param("id"), op); err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, "")
+}
+
+// @Summary     云台控制锁
+// @Description 获取通道当前的云台控制者，无人控制时返回空
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id   path     string true "通道id"
+// @Success     0    {object} sipapi.PTZLock
+// @Failure     1000 {object} string
+// @Failure     1001 {object} string
+// @Failure     1002 {object} string
+// @Failure     1003 {object} string
+// @Router      /channels/{id}/ptz/lock [get]
+func PTZLockInfo(c *gin.Context) {
+	lock, ok := sipapi.GetPTZLock(c.Param("id"))
+	if !ok {
+		m.JsonResponse(c, m.StatusSucc, nil)
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, lock)
+}
+
+// 读取浮点型参数并检查范围，参数错误时直接返回错误信息
+func ptzFloatParam(c *gin.Context, name, value string, min, max float64) (float64, bool) {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || v < min || v > max {
+		m.JsonResponse(c, m.StatusParamsERR, fmt.Sprintf("%s错误，范围%v-%v", name, min, max))
+		return 0, false
+	}
+	return v, true
+}
+
+// @Summary     拉框放大/缩小
+// @Description 在播放画面上拉框，摄像机将框选区域放大或缩小。坐标为归一化坐标，以画面左上角为原点，取值0-1
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id       path     string true  "通道id"
+// @Param       zoom     formData string true  "in 拉框放大 out 拉框缩小"
+// @Param       x        formData number true  "拉框左上角X坐标(0-1)"
+// @Param       y        formData number true  "拉框左上角Y坐标(0-1)"
+// @Param       w        formData number true  "拉框宽度(0-1)"
+// @Param       h        formData number true  "拉框高度(0-1)"
+// @Param       length   formData int    false "播放窗口长度像素值，默认1920"
+// @Param       width    formData int    false "播放窗口宽度像素值，默认1080"
+// @Success     0        {object} string
+// @Failure     1000     {object} string
+// @Failure     1001     {object} string
+// @Failure     1002     {object} string
+// @Failure     1003     {object} string
+// @Router      /channels/{id}/ptz/drag_zoom [post]
+func PTZDragZoom(c *gin.Context) {
+	zoom := sipapi.DragZoom{Length: 1920, Width: 1080}
+	switch c.PostForm("zoom") {
+	case "in":
+		zoom.ZoomIn = true
+	case "out":
+	default:
+		m.JsonResponse(c, m.StatusParamsERR, "zoom错误，取值in/out")
+		return
+	}
+	var ok bool
+	if length := c.PostForm("length"); length != "" {
+		if zoom.Length, ok = ptzIntParam(c, "播放窗口长度", length, 1, 65535); !ok {
+			return
+		}
+	}
+	if width := c.PostForm("width"); width != "" {
+		if zoom.Width, ok = ptzIntParam(c, "播放窗口宽度", width, 1, 65535); !ok {
+			return
+		}
+	}
+	rect := [4]float64{}
+	for i, name := range []string{"x", "y", "w", "h"} {
+		if rect[i], ok = ptzFloatParam(c, name, c.PostForm(name), 0, 1); !ok {
+			return
+		}
+	}
+	x, y, w, h := rect[0], rect[1], rect[2], rect[3]
+	if w == 0 || h == 0 || x+w > 1 || y+h > 1 {
+		m.JsonResponse(c, m.StatusParamsERR, "拉框区域超出画面范围")
+		return
+	}
+	zoom.MidPointX = int((x + w/2) * float64(zoom.Length))
+	zoom.MidPointY = int((y + h/2) * float64(zoom.Width))
+	zoom.LengthX = int(w * float64(zoom.Length))
+	zoom.LengthY = int(h * float64(zoom.Width))
+	op := ptzOperator(c)
+	if err := sipapi.SipDragZoom(c.Param("id"), op, zoom); err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, "云台控制指令已发送")
+}
+
+// @Summary     云台精准控制
+// @Description 控制云台转动到指定的水平、垂直角度和变倍倍数（GB/T 28181-2022 PTZPreciseCtrl）
+// @Tags        ptz
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id       path     string true  "通道id"
+// @Param       pan      formData number true  "水平角度(0-360)"
+// @Param       tilt     formData number true  "垂直角度(-90-90)"
+// @Param       zoom     formData number true  "变倍倍数(1-1000)"
+// @Success     0        {object} string
+// @Failure     1000     {object} string
+// @Failure     1001     {object} string
+// @Failure     1002     {object} string
+// @Failure     1003     {object} string
+// @Router      /channels/{id}/ptz/precise [post]
+func PTZPreciseCtrl(c *gin.Context) {
+	pan, ok := ptzFloatParam(c, "水平角度", c.PostForm("pan"), 0, 360)
+	if !ok {
+		return
+	}
+	tilt, ok := ptzFloatParam(c, "垂直角度", c.PostForm("tilt"), -90, 90)
+	if !ok {
+		return
+	}
+	zoom, ok := ptzFloatParam(c, "变倍倍数", c.PostForm("zoom"), 1, 1000)
+	if !ok {
+		return
+	}
+	op := ptzOperator(c)
+	if err := sipapi.SipPTZPreciseCtrl(c.Param("id"), op, pan, tilt, zoom); err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, "云台控制指令已发送")
+}
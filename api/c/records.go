@@ -68,3 +68,44 @@ func RecordsList(c *gin.Context) {
 	}
 	m.JsonResponse(c, m.StatusSucc, res)
 }
+
+// @Summary     下载历史媒体文件
+// @Description 设备按下载倍速发送历史媒体流，zlm录制为mp4文件，可通过流列表查看下载进度，完成后文件记录在文件列表中并发送records.download_done通知
+// @Tags        records
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id    path     string true  "通道id"
+// @Param       start formData int    true  "开始时间，时间戳"
+// @Param       end   formData int    true  "结束时间，时间戳"
+// @Param       speed formData int    false "下载倍速(1,2,4,8)，默认4"
+// @Success     0     {object} sipapi.Streams
+// @Failure     1000  {object} string
+// @Failure     1001  {object} string
+// @Failure     1002  {object} string
+// @Failure     1003  {object} string
+// @Router      /channels/{id}/records/download [post]
+func RecordsDownload(c *gin.Context) {
+	start, err := strconv.ParseInt(c.PostForm("start"), 10, 64)
+	if err != nil || start <= 0 {
+		m.JsonResponse(c, m.StatusParamsERR, "开始时间错误")
+		return
+	}
+	end, err := strconv.ParseInt(c.PostForm("end"), 10, 64)
+	if err != nil || end <= start {
+		m.JsonResponse(c, m.StatusParamsERR, "结束时间错误")
+		return
+	}
+	var speed int
+	if v := c.PostForm("speed"); v != "" {
+		if speed, err = strconv.Atoi(v); err != nil {
+			m.JsonResponse(c, m.StatusParamsERR, "下载倍速错误")
+			return
+		}
+	}
+	res, err := sipapi.SipDownload(c.Param("id"), start, end, speed)
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, res)
+}
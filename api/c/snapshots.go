@@ -0,0 +1,129 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/panjjo/gosip/db"
+	"github.com/panjjo/gosip/m"
+	sipapi "github.com/panjjo/gosip/sip"
+	"github.com/sirupsen/logrus"
+)
+
+// 单张抓拍图片大小限制
+const snapshotMaxSize = 10 << 20
+
+// @Summary     通道抓拍
+// @Description 向通道下发图像抓拍配置，设备抓拍后将图片上传到本服务，上传完成后发送snapshots.finished通知
+// @Tags        snapshots
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id       path     string true  "通道id"
+// @Param       snapnum  formData int    false "抓拍张数(1-10)，默认1"
+// @Param       interval formData int    false "抓拍间隔，单位秒(1-600)，默认1"
+// @Success     0        {object} sipapi.SnapshotTask
+// @Failure     1000     {object} string
+// @Failure     1001     {object} string
+// @Failure     1002     {object} string
+// @Failure     1003     {object} string
+// @Router      /channels/{id}/snapshot [post]
+func ChannelsSnapshot(c *gin.Context) {
+	snapNum, interval := 1, 1
+	var ok bool
+	if v := c.PostForm("snapnum"); v != "" {
+		if snapNum, ok = ptzIntParam(c, "抓拍张数", v, 1, 10); !ok {
+			return
+		}
+	}
+	if v := c.PostForm("interval"); v != "" {
+		if interval, ok = ptzIntParam(c, "抓拍间隔", v, 1, 600); !ok {
+			return
+		}
+	}
+	task, err := sipapi.SipSnapShot(c.Param("id"), snapNum, interval)
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, task)
+}
+
+type SnapshotsListResponse struct {
+	Total int64
+	List  []sipapi.Snapshots
+}
+
+// @Summary     通道抓拍图片列表
+// @Description 查询通道的抓拍图片，图片访问地址为 /snapshots/files/{file}
+// @Tags        snapshots
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id        path     string  true  "通道id"
+// @Param       sessionid query    string  false "抓拍会话id"
+// @Param       limit     query    integer false "条数(0-100) 默认20"
+// @Param       skip      query    integer false "间隔 默认0"
+// @Param       sort      query    string  false "排序,例:-key,根据key倒序,key,根据key正序"
+// @Success     0         {object} SnapshotsListResponse
+// @Failure     1000      {object} string
+// @Failure     1001      {object} string
+// @Failure     1002      {object} string
+// @Failure     1003      {object} string
+// @Router      /channels/{id}/snapshots [get]
+func ChannelsSnapshotsList(c *gin.Context) {
+	limit := m.GetLimit(c)
+	skip := m.GetSkip(c)
+	sort := m.GetSort(c)
+	query := db.M{"channelid=?": c.Param("id")}
+	if sessionID := c.Query("sessionid"); sessionID != "" {
+		query["sessionid=?"] = sessionID
+	}
+	list := []sipapi.Snapshots{}
+	total, err := db.FindT(db.DBClient, new(sipapi.Snapshots), &list, query, sort, skip, limit, true)
+	if err != nil {
+		m.JsonResponse(c, m.StatusDBERR, err)
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, SnapshotsListResponse{
+		Total: total,
+		List:  list,
+	})
+}
+
+// SnapshotUpload 接收设备上传的抓拍图片，支持 multipart/form-data 和直接上传图片内容
+func SnapshotUpload(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, snapshotMaxSize)
+	sessionID := c.Param("session")
+	name := strings.Trim(c.Param("file"), "/")
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		form, err := c.MultipartForm()
+		if err != nil {
+			m.JsonResponse(c, m.StatusParamsERR, err.Error())
+			return
+		}
+		for _, files := range form.File {
+			for _, fh := range files {
+				f, err := fh.Open()
+				if err != nil {
+					m.JsonResponse(c, m.StatusParamsERR, err.Error())
+					return
+				}
+				_, err = sipapi.SaveSnapshot(sessionID, fh.Filename, f)
+				f.Close()
+				if err != nil {
+					logrus.Warnln("save snapshot error,", sessionID, err)
+					m.JsonResponse(c, m.StatusParamsERR, err.Error())
+					return
+				}
+			}
+		}
+		m.JsonResponse(c, m.StatusSucc, "")
+		return
+	}
+	if _, err := sipapi.SaveSnapshot(sessionID, name, c.Request.Body); err != nil {
+		logrus.Warnln("save snapshot error,", sessionID, err)
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, "")
+}
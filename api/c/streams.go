@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/panjjo/gosip/api/middleware"
 	"github.com/panjjo/gosip/db"
 	"github.com/panjjo/gosip/m"
 	sipapi "github.com/panjjo/gosip/sip"
@@ -13,22 +14,32 @@ import (
 
 // @Summary     监控播放（直播/回放）
 // @Description 直播一个通道最多存在一个流，回放每请求一次生成一个流
+// @Description 每次调用创建一个观看会话，返回的token需定时心跳，存在会话时流保持打开，最后一个会话结束后流按配置的保留时间关闭
 // @Tags        streams
 // @Accept      x-www-form-urlencoded
 // @Produce     json
-// @Param       id     path     string true  "通道id"
-// @Param       replay formData int    false "是否回放，1回放，0直播，默认0"
-// @Param       start  formData int    false "回放开始时间，时间戳，replay=1时必传"
-// @Param       end    formData int    false "回放结束时间，时间戳，replay=1时必传"
-// @Success     0      {object} sipapi.Streams
-// @Failure     1000 {object} string
-// @Failure     1001 {object} string
-// @Failure     1002 {object} string
-// @Failure     1003 {object} string
+// @Param       id        path     string true  "通道id"
+// @Param       replay    formData int    false "是否回放，1回放，0直播，默认0"
+// @Param       start     formData int    false "回放开始时间，时间戳，replay=1时必传"
+// @Param       end       formData int    false "回放结束时间，时间戳，replay=1时必传"
+// @Param       transport formData string false "传输方式 udp/tcp_passive/tcp_active，默认使用通道设置，设备拒绝时自动回退"
+// @Param       bind      formData int    false "开启播放地址签名时是否绑定请求方ip，1绑定，只允许请求方ip使用播放地址"
+// @Success     0         {object} sipapi.Streams
+// @Failure     1000      {object} string
+// @Failure     1001      {object} string
+// @Failure     1002      {object} string
+// @Failure     1003      {object} string
 // @Router      /channels/{id}/streams [post]
 func Play(c *gin.Context) {
 	channelid := c.Param("id")
 	pm := &sipapi.Streams{S: time.Time{}, E: time.Time{}, ChannelID: channelid, Ttag: db.M{}, Ftag: db.M{}}
+	if transport := c.PostForm("transport"); transport != "" {
+		if !m.CheckTransport(transport) {
+			m.JsonResponse(c, m.StatusParamsERR, "传输方式错误")
+			return
+		}
+		pm.SetTransport(transport)
+	}
 	if c.PostForm("replay") == "1" {
 		// 回放，获取时间
 		pm.T = 1
@@ -45,9 +56,9 @@ func Play(c *gin.Context) {
 			return
 		}
 	} else {
-		// 直播 判断当前通道是否存在流了。
-		if succ, ok := sipapi.StreamList.Succ.Load(channelid); ok {
-			m.JsonResponse(c, m.StatusSucc, succ)
+		// 直播 判断当前通道是否存在可用的流了。
+		if succ, ok := sipapi.LiveStream(channelid); ok {
+			m.JsonResponse(c, m.StatusSucc, viewerStream(c, succ))
 			return
 		}
 	}
@@ -56,7 +67,88 @@ func Play(c *gin.Context) {
 		m.JsonResponse(c, m.StatusParamsERR, err.Error())
 		return
 	}
-	m.JsonResponse(c, m.StatusSucc, res)
+	m.JsonResponse(c, m.StatusSucc, viewerStream(c, res))
+}
+
+// 为流创建观看会话，返回带会话token和签名播放地址的流信息副本
+func viewerStream(c *gin.Context, data *sipapi.Streams) sipapi.Streams {
+	session := sipapi.ViewerOpen(data)
+	res := *data
+	res.Token = session.Token
+	res.Viewers = sipapi.StreamViewers(data.StreamID)
+	// 签名用户和绑定ip由服务端确定，不使用客户端传入的值
+	ip := ""
+	if c.PostForm("bind") == "1" {
+		ip = c.ClientIP()
+	}
+	sipapi.SignPlayURLs(&res, middleware.Principal(c), ip)
+	return res
+}
+
+// @Summary     吊销用户播放地址
+// @Description 开启播放地址签名时，吊销用户此前获取的所有播放地址，之后重新调用播放接口获取的地址不受影响
+// @Tags        streams
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       user formData string true "观看用户，即调用播放接口的请求方身份"
+// @Success     0    {object} string
+// @Failure     1000 {object} string
+// @Failure     1001 {object} string
+// @Failure     1002 {object} string
+// @Failure     1003 {object} string
+// @Router      /streams/revoke [post]
+func StreamsRevoke(c *gin.Context) {
+	user := c.PostForm("user")
+	if user == "" {
+		m.JsonResponse(c, m.StatusParamsERR, "缺少用户")
+		return
+	}
+	if err := sipapi.RevokePlayUser(user); err != nil {
+		m.JsonResponse(c, m.StatusDBERR, err)
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, "")
+}
+
+// @Summary     观看会话心跳
+// @Description 播放接口返回的token需定时心跳，超过配置的 stream.viewer_timeout 未心跳的会话失效
+// @Tags        streams
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       token path     string true "观看会话token，播放接口返回"
+// @Success     0     {object} sipapi.ViewerSession
+// @Failure     1000  {object} string
+// @Failure     1001  {object} string
+// @Failure     1002  {object} string
+// @Failure     1003  {object} string
+// @Router      /viewers/{token}/heartbeat [post]
+func ViewerHeartbeat(c *gin.Context) {
+	session, err := sipapi.ViewerHeartbeat(c.Param("token"))
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, session)
+}
+
+// @Summary     结束观看
+// @Description 结束观看会话，流在最后一个会话结束并超过配置的 stream.linger 后关闭
+// @Tags        streams
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       token path     string true "观看会话token，播放接口返回"
+// @Success     0     {object} string
+// @Failure     1000  {object} string
+// @Failure     1001  {object} string
+// @Failure     1002  {object} string
+// @Failure     1003  {object} string
+// @Router      /viewers/{token} [delete]
+func ViewerRelease(c *gin.Context) {
+	if err := sipapi.ViewerRelease(c.Param("token")); err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, "")
 }
 
 // @Summary     停止播放（直播/回放）
@@ -82,13 +174,35 @@ func Stop(c *gin.Context) {
 	m.JsonResponse(c, m.StatusSucc, "")
 }
 
+// @Summary     视频流统计
+// @Description 返回流最近的统计采样窗口，包括收流码率、观看人数、帧率、丢包率等，每5秒采样一次
+// @Description stalled 为流是否卡死，直播流卡死后自动重新点播
+// @Tags        streams
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id   path     string true "流id,播放接口返回的streamid"
+// @Success     0    {object} sipapi.StreamStats
+// @Failure     1000 {object} string
+// @Failure     1001 {object} string
+// @Failure     1002 {object} string
+// @Failure     1003 {object} string
+// @Router      /streams/{id}/stats [get]
+func StreamsStats(c *gin.Context) {
+	stats, err := sipapi.GetStreamStats(c.Param("id"))
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, stats)
+}
+
 type StreamsListResponse struct {
 	Total int64
 	List  []sipapi.Streams
 }
 
 // @Summary     视频流列表接口
-// @Description 可以根据查询条件查询视频流列表
+// @Description 可以根据查询条件查询视频流列表，viewers为当前观看会话数量
 // @Tags        streams
 // @Accept      x-www-form-urlencoded
 // @Produce     json
@@ -112,8 +226,96 @@ func StreamsList(c *gin.Context) {
 		m.JsonResponse(c, m.StatusDBERR, err)
 		return
 	}
+	for i := range streams {
+		streams[i].Viewers = sipapi.StreamViewers(streams[i].StreamID)
+	}
 	m.JsonResponse(c, m.StatusSucc, StreamsListResponse{
 		Total: total,
 		List:  streams,
 	})
 }
+
+// 回放控制结果
+func playbackControl(c *gin.Context, err error) {
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, "")
+}
+
+// @Summary     暂停回放
+// @Description 在回放会话内发送 MANSRTSP PAUSE 指令
+// @Tags        streams
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id   path     string true "流id,播放接口返回的streamid"
+// @Success     0    {object} string
+// @Failure     1000 {object} string
+// @Failure     1001 {object} string
+// @Failure     1002 {object} string
+// @Failure     1003 {object} string
+// @Router      /streams/{id}/pause [post]
+func StreamsPause(c *gin.Context) {
+	playbackControl(c, sipapi.SipPlaybackPause(c.Param("id")))
+}
+
+// @Summary     继续回放
+// @Description 在回放会话内发送 MANSRTSP PLAY 指令，从暂停位置继续播放
+// @Tags        streams
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id   path     string true "流id,播放接口返回的streamid"
+// @Success     0    {object} string
+// @Failure     1000 {object} string
+// @Failure     1001 {object} string
+// @Failure     1002 {object} string
+// @Failure     1003 {object} string
+// @Router      /streams/{id}/resume [post]
+func StreamsResume(c *gin.Context) {
+	playbackControl(c, sipapi.SipPlaybackResume(c.Param("id")))
+}
+
+// @Summary     回放跳转
+// @Description 在回放会话内发送带 Range 的 MANSRTSP PLAY 指令，跳转到指定时间播放
+// @Tags        streams
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id   path     string true "流id,播放接口返回的streamid"
+// @Param       time formData int    true "跳转时间，时间戳，需在回放开始和结束时间之间"
+// @Success     0    {object} string
+// @Failure     1000 {object} string
+// @Failure     1001 {object} string
+// @Failure     1002 {object} string
+// @Failure     1003 {object} string
+// @Router      /streams/{id}/seek [post]
+func StreamsSeek(c *gin.Context) {
+	t, _ := strconv.ParseInt(c.PostForm("time"), 10, 64)
+	if t <= 0 {
+		m.JsonResponse(c, m.StatusParamsERR, "跳转时间错误")
+		return
+	}
+	playbackControl(c, sipapi.SipPlaybackSeek(c.Param("id"), t))
+}
+
+// @Summary     回放倍速
+// @Description 在回放会话内发送带 Scale 的 MANSRTSP PLAY 指令
+// @Tags        streams
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id    path     string true "流id,播放接口返回的streamid"
+// @Param       speed formData number true "倍速 0.25/0.5/1/2/4"
+// @Success     0     {object} string
+// @Failure     1000  {object} string
+// @Failure     1001  {object} string
+// @Failure     1002  {object} string
+// @Failure     1003  {object} string
+// @Router      /streams/{id}/speed [post]
+func StreamsSpeed(c *gin.Context) {
+	speed, err := strconv.ParseFloat(c.PostForm("speed"), 64)
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, "倍速错误")
+		return
+	}
+	playbackControl(c, sipapi.SipPlaybackSpeed(c.Param("id"), speed))
+}
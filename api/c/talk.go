@@ -1,33 +1,66 @@
 package api
 
 import (
-	"time"
-
 	"github.com/gin-gonic/gin"
-	"github.com/panjjo/gosip/db"
 	"github.com/panjjo/gosip/m"
 	sipapi "github.com/panjjo/gosip/sip"
 )
 
 // @Summary     通道对讲
-// @Description 对讲一个通道最多存在一个流
-// @Tags        streams
+// @Description 创建语音对讲会话，返回浏览器麦克风音频的 WebRTC 推流地址，一个通道最多存在一个对讲会话
+// @Description 浏览器推流后服务端自动与设备建立会话，音频按设备支持的 G711A/G711U/AAC 编码转发，没有音频数据超过空闲时间后自动关闭
+// @Tags        talk
 // @Accept      x-www-form-urlencoded
 // @Produce     json
-// @Param       id     path     string true  "通道id"
-// @Success     0      {object} sipapi.Streams
-// @Failure     1000 {object} string
-// @Failure     1001 {object} string
-// @Failure     1002 {object} string
-// @Failure     1003 {object} string
+// @Param       id    path     string true  "通道id"
+// @Param       mode  formData string false "对讲模式 talk 双向对讲 broadcast 语音广播，默认talk"
+// @Param       codec formData string false "浏览器推送的音频编码 PCMA/PCMU/AAC，默认使用配置"
+// @Success     0     {object} sipapi.TalkSession
+// @Failure     1000  {object} string
+// @Failure     1001  {object} string
+// @Failure     1002  {object} string
+// @Failure     1003  {object} string
 // @Router      /channels/{id}/start_talk [post]
 func StartTalk(c *gin.Context) {
-	channelid := c.Param("id")
-	pm := &sipapi.Streams{S: time.Time{}, E: time.Time{}, ChannelID: channelid, Ttag: db.M{}, Ftag: db.M{}}
-	res, err := sipapi.SipTalk(pm)
+	res, err := sipapi.SipStartTalk(c.Param("id"), c.PostForm("mode"), c.PostForm("codec"))
 	if err != nil {
 		m.JsonResponse(c, m.StatusParamsERR, err.Error())
 		return
 	}
 	m.JsonResponse(c, m.StatusSucc, res)
 }
+
+// @Summary     对讲列表
+// @Description 查询当前所有语音对讲会话
+// @Tags        talk
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Success     0    {object} []sipapi.TalkSession
+// @Failure     1000 {object} string
+// @Failure     1001 {object} string
+// @Failure     1002 {object} string
+// @Failure     1003 {object} string
+// @Router      /talks [get]
+func TalksList(c *gin.Context) {
+	m.JsonResponse(c, m.StatusSucc, sipapi.GetTalkSessions())
+}
+
+// @Summary     结束对讲
+// @Description 结束语音对讲会话，通知设备结束会话并断开浏览器推流
+// @Tags        talk
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id   path     string true "对讲会话id"
+// @Success     0    {object} string
+// @Failure     1000 {object} string
+// @Failure     1001 {object} string
+// @Failure     1002 {object} string
+// @Failure     1003 {object} string
+// @Router      /talks/{id} [delete]
+func TalksStop(c *gin.Context) {
+	if err := sipapi.SipStopTalk(c.Param("id")); err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, "")
+}
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/panjjo/gosip/db"
+	"github.com/panjjo/gosip/m"
+	sipapi "github.com/panjjo/gosip/sip"
+)
+
+// @Summary     固件上传
+// @Description 上传设备固件文件，设备升级时从 /firmwares/files/{file} 下载
+// @Tags        upgrades
+// @Accept      multipart/form-data
+// @Produce     json
+// @Param       file         formData file   true  "固件文件"
+// @Param       firmware     formData string true  "固件版本"
+// @Param       name         formData string false "固件名称，默认使用文件名"
+// @Param       manufacturer formData string false "适用的设备厂商，为空时不限制"
+// @Success     0            {object} sipapi.Firmwares
+// @Failure     1000         {object} string
+// @Failure     1001         {object} string
+// @Failure     1002         {object} string
+// @Failure     1003         {object} string
+// @Router      /firmwares [post]
+func FirmwaresCreate(c *gin.Context) {
+	fw := &sipapi.Firmwares{
+		Name:         c.PostForm("name"),
+		Firmware:     c.PostForm("firmware"),
+		Manufacturer: c.PostForm("manufacturer"),
+	}
+	if fw.Firmware == "" {
+		m.JsonResponse(c, m.StatusParamsERR, "缺少固件版本")
+		return
+	}
+	fh, err := c.FormFile("file")
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, "缺少固件文件")
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	defer f.Close()
+	if err := sipapi.SaveFirmware(fw, fh.Filename, f); err != nil {
+		m.JsonResponse(c, m.StatusDBERR, err)
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, fw)
+}
+
+type FirmwaresListResponse struct {
+	Total int64
+	List  []sipapi.Firmwares
+}
+
+// @Summary     固件列表
+// @Description 可以根据查询条件查询固件列表
+// @Tags        upgrades
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       limit   query    integer false "条数(0-100) 默认20"
+// @Param       skip    query    integer false "间隔 默认0"
+// @Param       sort    query    string  false "排序,例:-key,根据key倒序,key,根据key正序"
+// @Param       filters query    string  false "查询条件,使用规则详情请看帮助"
+// @Success     0       {object} FirmwaresListResponse
+// @Failure     1000    {object} string
+// @Failure     1001    {object} string
+// @Failure     1002    {object} string
+// @Failure     1003    {object} string
+// @Router      /firmwares [get]
+func FirmwaresList(c *gin.Context) {
+	limit := m.GetLimit(c)
+	skip := m.GetSkip(c)
+	sort := m.GetSort(c)
+	list := []sipapi.Firmwares{}
+	total, err := db.FindWithJson(db.DBClient, new(sipapi.Firmwares), &list, c.Query("filters"), sort, skip, limit, true)
+	if err != nil {
+		m.JsonResponse(c, m.StatusDBERR, err)
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, FirmwaresListResponse{
+		Total: total,
+		List:  list,
+	})
+}
+
+// @Summary     创建升级任务
+// @Description 按设备筛选条件选择设备进行固件升级，固件指定厂商时只升级该厂商的设备。按并发数依次升级，每台设备等待升级结果后再升级下一台
+// @Tags        upgrades
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       firmwareid  formData int    true  "固件id"
+// @Param       filters     formData string false "设备查询条件，同设备列表接口，为空时选择全部设备"
+// @Param       concurrency formData int    false "同时升级的设备数(1-100)，默认使用配置"
+// @Success     0           {object} sipapi.UpgradeTasks
+// @Failure     1000        {object} string
+// @Failure     1001        {object} string
+// @Failure     1002        {object} string
+// @Failure     1003        {object} string
+// @Router      /upgrades [post]
+func UpgradesCreate(c *gin.Context) {
+	firmwareID, ok := ptzIntParam(c, "固件id", c.PostForm("firmwareid"), 1, 1<<31-1)
+	if !ok {
+		return
+	}
+	concurrency := 0
+	if v := c.PostForm("concurrency"); v != "" {
+		if concurrency, ok = ptzIntParam(c, "并发数", v, 1, 100); !ok {
+			return
+		}
+	}
+	task, err := sipapi.StartUpgradeTask(uint(firmwareID), c.PostForm("filters"), concurrency)
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, task)
+}
+
+type UpgradesListResponse struct {
+	Total int64
+	List  []sipapi.UpgradeTasks
+}
+
+// @Summary     升级任务列表
+// @Description 可以根据查询条件查询升级任务列表
+// @Tags        upgrades
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       limit   query    integer false "条数(0-100) 默认20"
+// @Param       skip    query    integer false "间隔 默认0"
+// @Param       sort    query    string  false "排序,例:-key,根据key倒序,key,根据key正序"
+// @Param       filters query    string  false "查询条件,使用规则详情请看帮助"
+// @Success     0       {object} UpgradesListResponse
+// @Failure     1000    {object} string
+// @Failure     1001    {object} string
+// @Failure     1002    {object} string
+// @Failure     1003    {object} string
+// @Router      /upgrades [get]
+func UpgradesList(c *gin.Context) {
+	limit := m.GetLimit(c)
+	skip := m.GetSkip(c)
+	sort := m.GetSort(c)
+	list := []sipapi.UpgradeTasks{}
+	total, err := db.FindWithJson(db.DBClient, new(sipapi.UpgradeTasks), &list, c.Query("filters"), sort, skip, limit, true)
+	if err != nil {
+		m.JsonResponse(c, m.StatusDBERR, err)
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, UpgradesListResponse{
+		Total: total,
+		List:  list,
+	})
+}
+
+type UpgradeDetailResponse struct {
+	Task    sipapi.UpgradeTasks
+	Devices []sipapi.Upgrades
+}
+
+// @Summary     升级任务详情
+// @Description 获取升级任务以及每台设备的升级进度和结果
+// @Tags        upgrades
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id   path     int true "升级任务id"
+// @Success     0    {object} UpgradeDetailResponse
+// @Failure     1000 {object} string
+// @Failure     1001 {object} string
+// @Failure     1002 {object} string
+// @Failure     1003 {object} string
+// @Router      /upgrades/{id} [get]
+func UpgradesDetail(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, "升级任务id错误")
+		return
+	}
+	res := UpgradeDetailResponse{Devices: []sipapi.Upgrades{}}
+	if err := db.GetQ(db.DBClient, &res.Task, db.M{"id=?": id}); err != nil {
+		if db.RecordNotFound(err) {
+			m.JsonResponse(c, m.StatusParamsERR, "升级任务不存在")
+			return
+		}
+		m.JsonResponse(c, m.StatusDBERR, err)
+		return
+	}
+	if _, err := db.FindT(db.DBClient, new(sipapi.Upgrades), &res.Devices, db.M{"taskid=?": id}, "id", 0, -1, false); err != nil {
+		m.JsonResponse(c, m.StatusDBERR, err)
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, res)
+}
+
+// @Summary     取消升级任务
+// @Description 未开始升级的设备不再升级，正在升级的设备继续等待升级结果
+// @Tags        upgrades
+// @Accept      x-www-form-urlencoded
+// @Produce     json
+// @Param       id   path     int true "升级任务id"
+// @Success     0    {object} string
+// @Failure     1000 {object} string
+// @Failure     1001 {object} string
+// @Failure     1002 {object} string
+// @Failure     1003 {object} string
+// @Router      /upgrades/{id}/cancel [post]
+func UpgradesCancel(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, "升级任务id错误")
+		return
+	}
+	if err := sipapi.CancelUpgradeTask(uint(id)); err != nil {
+		m.JsonResponse(c, m.StatusParamsERR, err.Error())
+		return
+	}
+	m.JsonResponse(c, m.StatusSucc, "")
+}
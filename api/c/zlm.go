@@ -18,30 +18,28 @@ func ZLMWebHook(c *gin.Context) {
 	method := c.Param("method")
 	switch method {
 	case "on_server_started":
-		// zlm 启动，具体业务自行实现
+		// zlm 启动，注册或上线媒体节点
 		m.MConfig.GB28181.MediaServer = true
-		c.JSON(http.StatusOK, map[string]any{
-			"code": 0,
-			"msg":  "success"})
+		zlmServerStarted(c)
+	case "on_server_keepalive":
+		// zlm 心跳
+		zlmServerKeepalive(c)
 	case "on_http_access":
-		// http请求鉴权，具体业务自行实现
-		c.JSON(http.StatusOK, map[string]any{
-			"code":   0,
-			"second": 86400})
+		// http请求鉴权，校验播放地址签名
+		zlmHTTPAccess(c)
 	case "on_play":
-		//视频播放触发鉴权
-		c.JSON(http.StatusOK, map[string]any{
-			"code": 0,
-			"msg":  "",
-		})
+		//视频播放触发鉴权，校验播放地址签名
+		zlmPlay(c)
 	case "on_publish":
 		// 推流鉴权
 		c.JSON(http.StatusOK, map[string]any{
-			"code":       0,
-			"enableHls":  m.MConfig.Stream.HLS,
-			"enableMP4":  false,
-			"enableRtxp": m.MConfig.Stream.RTMP,
-			"msg":        "success",
+			"code":            0,
+			"enableHls":       m.MConfig.Stream.HLS,
+			"enableMP4":       false,
+			"enableRtxp":      m.MConfig.Stream.RTMP,
+			"enable_fmp4":     m.MConfig.Stream.FMP4,
+			"enable_hls_fmp4": m.MConfig.Stream.HLSFMP4,
+			"msg":             "success",
 		})
 	case "on_stream_none_reader":
 		// 无人阅读通知 关闭流
@@ -55,6 +53,12 @@ func ZLMWebHook(c *gin.Context) {
 	case "on_stream_changed":
 		// 流注册和注销通知
 		zlmStreamChanged(c)
+	case "on_rtp_server_timeout":
+		// rtp收流端口超时未收到流
+		zlmRtpServerTimeout(c)
+	case "on_send_rtp_stopped":
+		// 停止发送rtp，对讲、广播
+		zlmSendRtpStopped(c)
 	default:
 		c.JSON(http.StatusOK, map[string]any{
 			"code": -1,
@@ -90,6 +94,26 @@ func zlmStreamChanged(c *gin.Context) {
 		})
 		return
 	}
+	if req.APP == sipapi.BroadcastApp {
+		// 广播音频源，注销后结束使用该音频源的广播
+		if !req.Regist && req.Schema == "rtmp" {
+			sipapi.StopBroadcastsBySource(req.APP, req.Stream)
+		}
+		c.JSON(http.StatusOK, map[string]any{
+			"code": 0,
+			"msg":  "success"})
+		return
+	}
+	if sipapi.IsTalkStream(req.APP, req.Stream) {
+		// 对讲音频源注册后与设备建立会话，注销由对讲空闲检查处理
+		if req.Regist && req.Schema == "rtmp" && req.APP == sipapi.TalkApp {
+			sipapi.TalkSourceRegisted(req.Stream)
+		}
+		c.JSON(http.StatusOK, map[string]any{
+			"code": 0,
+			"msg":  "success"})
+		return
+	}
 	ssrc := req.Stream
 	if req.Regist {
 		if req.Schema == "rtmp" {
@@ -102,6 +126,8 @@ func zlmStreamChanged(c *gin.Context) {
 				sipapi.StreamList.Response.Store(ssrc, params)
 				// 接收到流注册后进行视频流编码分析，分析出此设备对应的编码格式并保存或更新
 				sipapi.SyncDevicesCodec(ssrc, params.DeviceID)
+				// 下载流注册后开始录制
+				sipapi.DownloadRegisted(ssrc)
 			} else {
 				// ssrc不存在，关闭流
 				sipapi.SipStopPlay(ssrc)
@@ -129,11 +155,13 @@ type ZLMRecordMp4Data struct {
 	Stream    string `json:"stream"`
 	FileName  string `json:"file_name"`
 	FilePath  string `json:"file_path"`
-	FileSize  int    `json:"file_size"`
+	FileSize  int64  `json:"file_size"`
 	Folder    string `json:"folder"`
 	StartTime int64  `json:"start_time"`
 	TimeLen   int    `json:"time_len"`
 	URL       string `json:"url"`
+
+	MediaServerID string `json:"mediaServerId"`
 }
 
 func zlmRecordMp4(c *gin.Context) {
@@ -155,6 +183,12 @@ func zlmRecordMp4(c *gin.Context) {
 		})
 		return
 	}
+	if sipapi.DownloadRecorded(req.MediaServerID, req.Stream, req.URL, req.FileSize) {
+		c.JSON(http.StatusOK, map[string]any{
+			"code": 0,
+			"msg":  "success"})
+		return
+	}
 	if item, ok := sipapi.RecordList.Get(req.Stream); ok {
 		sipapi.RecordList.Stop(req.Stream)
 		item.Down(req.URL)
@@ -202,8 +236,8 @@ func zlmStreamNotFound(c *gin.Context) {
 				// 存在推流记录关闭当前，重新发起推流
 				sipapi.SipStopPlay(ssrc)
 				logrus.Infoln("closeStream stream pushed!", req.Stream)
-			} else {
-				// 拉流的，重新拉流
+			} else if params.ProxyKey == "" {
+				// 拉流代理已不存在，重新拉流；代理存在时由媒体服务器自行重试
 				sipapi.SipPlay(params)
 				logrus.Infoln("closeStream stream pulled!", req.Stream)
 			}
@@ -246,6 +280,14 @@ func zlmStreamNoneReader(c *gin.Context) {
 		})
 		return
 	}
+	if req.APP == sipapi.BroadcastApp || sipapi.IsTalkStream(req.APP, req.Stream) || sipapi.IsDownloadStream(req.Stream) || sipapi.IsViewerStream(req.Stream) {
+		// 广播和对讲的音频流、下载流、观看会话管理的流由会话自行管理，没有观看者时不关闭
+		c.JSON(http.StatusOK, map[string]any{
+			"code":  0,
+			"close": false,
+		})
+		return
+	}
 	sipapi.SipStopPlay(req.Stream)
 	c.JSON(http.StatusOK, map[string]any{
 		"code":  0,
@@ -253,3 +295,262 @@ func zlmStreamNoneReader(c *gin.Context) {
 	})
 	logrus.Infoln("closeStream on_stream_none_reader", req.Stream)
 }
+
+// 读取 zlm 配置项，配置值可能为字符串或数字
+func zlmConfigValue(data map[string]any, key string) string {
+	if v, ok := data[key]; ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+func zlmServerStarted(c *gin.Context) {
+	body := c.Request.Body
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		c.JSON(http.StatusOK, map[string]any{
+			"code": -1,
+			"msg":  "body error",
+		})
+		return
+	}
+	req := map[string]any{}
+	if err := utils.JSONDecode(data, &req); err != nil {
+		c.JSON(http.StatusOK, map[string]any{
+			"code": -1,
+			"msg":  "body error",
+		})
+		return
+	}
+	id := zlmConfigValue(req, "mediaServerId")
+	if id == "" {
+		id = zlmConfigValue(req, "general.mediaServerId")
+	}
+	if id == "" {
+		id = m.MConfig.Media.ID
+	}
+	// 未在配置文件中的节点，按通知来源地址和zlm端口配置生成访问地址
+	host := c.ClientIP()
+	httpPort := zlmConfigValue(req, "http.port")
+	cfg := m.MediaServer{
+		RESTFUL: fmt.Sprintf("http://%s:%s", host, httpPort),
+		HTTP:    fmt.Sprintf("http://%s:%s", host, httpPort),
+		WS:      fmt.Sprintf("ws://%s:%s", host, httpPort),
+		RTMP:    fmt.Sprintf("rtmp://%s:%s", host, zlmConfigValue(req, "rtmp.port")),
+		RTSP:    fmt.Sprintf("rtsp://%s:%s", host, zlmConfigValue(req, "rtsp.port")),
+		RTP:     fmt.Sprintf("http://%s:%s", host, zlmConfigValue(req, "rtp_proxy.port")),
+		Secret:  zlmConfigValue(req, "api.secret"),
+	}
+	// 开启了ssl端口时生成加密访问地址
+	if port := zlmConfigValue(req, "http.sslport"); port != "" && port != "0" {
+		cfg.HTTPS = fmt.Sprintf("https://%s:%s", host, port)
+		cfg.WSS = fmt.Sprintf("wss://%s:%s", host, port)
+	}
+	if port := zlmConfigValue(req, "rtmp.sslport"); port != "" && port != "0" {
+		cfg.RTMPS = fmt.Sprintf("rtmps://%s:%s", host, port)
+	}
+	if port := zlmConfigValue(req, "rtsp.sslport"); port != "" && port != "0" {
+		cfg.RTSPS = fmt.Sprintf("rtsps://%s:%s", host, port)
+	}
+	if err := sipapi.MediaNodeStarted(id, cfg); err != nil {
+		logrus.Warnln("media node started fail,", id, err)
+		c.JSON(http.StatusOK, map[string]any{
+			"code": -1,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, map[string]any{
+		"code": 0,
+		"msg":  "success"})
+}
+
+type ZLMServerKeepaliveData struct {
+	MediaServerID string `json:"mediaServerId"`
+}
+
+func zlmServerKeepalive(c *gin.Context) {
+	body := c.Request.Body
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		c.JSON(http.StatusOK, map[string]any{
+			"code": -1,
+			"msg":  "body error",
+		})
+		return
+	}
+	req := &ZLMServerKeepaliveData{}
+	if err := utils.JSONDecode(data, &req); err != nil {
+		c.JSON(http.StatusOK, map[string]any{
+			"code": -1,
+			"msg":  "body error",
+		})
+		return
+	}
+	if req.MediaServerID == "" {
+		req.MediaServerID = m.MConfig.Media.ID
+	}
+	if !sipapi.MediaNodeKeepalive(req.MediaServerID) {
+		// 未注册的节点需等待启动通知携带的配置
+		logrus.Warnln("media node keepalive unknown node,", req.MediaServerID)
+	}
+	c.JSON(http.StatusOK, map[string]any{
+		"code": 0,
+		"msg":  "success"})
+}
+
+type ZLMPlayData struct {
+	APP    string `json:"app"`
+	Stream string `json:"stream"`
+	Schema string `json:"schema"`
+	Params string `json:"params"`
+	IP     string `json:"ip"`
+}
+
+func zlmPlay(c *gin.Context) {
+	body := c.Request.Body
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		c.JSON(http.StatusOK, map[string]any{
+			"code": -1,
+			"msg":  "body error",
+		})
+		return
+	}
+	req := &ZLMPlayData{}
+	if err := utils.JSONDecode(data, &req); err != nil {
+		c.JSON(http.StatusOK, map[string]any{
+			"code": -1,
+			"msg":  "body error",
+		})
+		return
+	}
+	if _, err := sipapi.CheckPlayAuth(req.APP, req.Stream, req.Params, req.IP); err != nil {
+		logrus.Infoln("zlm on_play denied,", req.APP, req.Stream, req.Schema, req.IP, err)
+		c.JSON(http.StatusOK, map[string]any{
+			"code": -1,
+			"msg":  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, map[string]any{
+		"code": 0,
+		"msg":  "",
+	})
+}
+
+type ZLMHTTPAccessData struct {
+	Path   string `json:"path"`
+	Params string `json:"params"`
+	IP     string `json:"ip"`
+}
+
+func zlmHTTPAccess(c *gin.Context) {
+	body := c.Request.Body
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		c.JSON(http.StatusOK, map[string]any{
+			"code": -1,
+			"err":  "body error",
+		})
+		return
+	}
+	req := &ZLMHTTPAccessData{}
+	if err := utils.JSONDecode(data, &req); err != nil {
+		c.JSON(http.StatusOK, map[string]any{
+			"code": -1,
+			"err":  "body error",
+		})
+		return
+	}
+	path, second, err := sipapi.CheckHTTPPlayAuth(req.Path, req.Params, req.IP)
+	if err != nil {
+		logrus.Infoln("zlm on_http_access denied,", req.Path, req.IP, err)
+		c.JSON(http.StatusOK, map[string]any{
+			"code":   -1,
+			"err":    err.Error(),
+			"path":   "",
+			"second": 0,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, map[string]any{
+		"code":   0,
+		"err":    "",
+		"path":   path,
+		"second": second,
+	})
+}
+
+type ZLMRtpServerTimeoutData struct {
+	MediaServerID string `json:"mediaServerId"`
+	StreamID      string `json:"stream_id"`
+	LocalPort     int    `json:"local_port"`
+	SSRC          any    `json:"ssrc"`
+	TCPMode       int    `json:"tcp_mode"`
+}
+
+func zlmRtpServerTimeout(c *gin.Context) {
+	body := c.Request.Body
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		c.JSON(http.StatusOK, map[string]any{
+			"code": -1,
+			"msg":  "body error",
+		})
+		return
+	}
+	req := &ZLMRtpServerTimeoutData{}
+	if err := utils.JSONDecode(data, &req); err != nil {
+		c.JSON(http.StatusOK, map[string]any{
+			"code": -1,
+			"msg":  "body error",
+		})
+		return
+	}
+	logrus.Infoln("zlm rtp server timeout,", req.MediaServerID, req.StreamID, req.LocalPort, req.SSRC)
+	sipapi.RtpServerTimeout(req.MediaServerID, req.StreamID)
+	c.JSON(http.StatusOK, map[string]any{
+		"code": 0,
+		"msg":  "success",
+	})
+}
+
+type ZLMSendRtpStoppedData struct {
+	APP    string `json:"app"`
+	Stream string `json:"stream"`
+	SSRC   string `json:"ssrc"`
+	Msg    string `json:"msg"`
+}
+
+func zlmSendRtpStopped(c *gin.Context) {
+	body := c.Request.Body
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		c.JSON(http.StatusOK, map[string]any{
+			"code": -1,
+			"msg":  "body error",
+		})
+		return
+	}
+	req := &ZLMSendRtpStoppedData{}
+	if err := utils.JSONDecode(data, &req); err != nil {
+		c.JSON(http.StatusOK, map[string]any{
+			"code": -1,
+			"msg":  "body error",
+		})
+		return
+	}
+	logrus.Infoln("zlm send rtp stopped,", req.APP, req.Stream, req.SSRC, req.Msg)
+	sipapi.SendRtpStopped(req.APP, req.Stream, req.SSRC, req.Msg)
+	c.JSON(http.StatusOK, map[string]any{
+		"code": 0,
+		"msg":  "success",
+	})
+}
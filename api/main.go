@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 	api "github.com/panjjo/gosip/api/c"
 	"github.com/panjjo/gosip/api/middleware"
+	"github.com/panjjo/gosip/m"
 )
 
 func Init(r *gin.Engine) {
@@ -17,6 +18,9 @@ func Init(r *gin.Engine) {
 		r.POST("/devices/create", api.DevicesCreate)
 		r.POST("/devices/:id", api.DevicesUpdate)
 		r.DELETE("/devices/:id", api.DevicesDelete)
+		r.GET("/devices/:id/status", api.DevicesStatus)
+		r.GET("/devices/:id/config", api.DevicesConfigQuery)
+		r.POST("/devices/:id/config", api.DevicesConfig)
 		r.POST("/devices/ptz", api.DevicesPTZControl)
 	}
 	// 通道类接口
@@ -27,16 +31,97 @@ func Init(r *gin.Engine) {
 		r.POST("/channels/:id", api.ChannelsUpdate)
 		r.DELETE("/channels/:id", api.ChannelsDelete)
 	}
+	// 云台类接口
+	{
+		r.POST("/channels/:id/ptz", api.PTZControl)
+		r.POST("/channels/:id/ptz/move", api.PTZMoveStart)
+		r.POST("/channels/:id/ptz/move/heartbeat", api.PTZMoveHeartbeat)
+		r.DELETE("/channels/:id/ptz/move", api.PTZMoveStop)
+		r.GET("/channels/:id/ptz/lock", api.PTZLockInfo)
+		r.POST("/channels/:id/ptz/drag_zoom", api.PTZDragZoom)
+		r.POST("/channels/:id/ptz/precise", api.PTZPreciseCtrl)
+		r.GET("/channels/:id/ptz/presets", api.PTZPresetsList)
+		r.POST("/channels/:id/ptz/presets", api.PTZPresetSet)
+		r.POST("/channels/:id/ptz/presets/:presetid/call", api.PTZPresetCall)
+		r.DELETE("/channels/:id/ptz/presets/:presetid", api.PTZPresetDelete)
+		r.POST("/channels/:id/ptz/cruises/:group", api.PTZCruiseSet)
+		r.POST("/channels/:id/ptz/cruises/:group/points", api.PTZCruisePointAdd)
+		r.DELETE("/channels/:id/ptz/cruises/:group/points/:presetid", api.PTZCruisePointDelete)
+		r.POST("/channels/:id/ptz/cruises/:group/start", api.PTZCruiseStart)
+		r.POST("/channels/:id/ptz/scans/:group", api.PTZScanSet)
+		r.POST("/channels/:id/ptz/scans/:group/start", api.PTZScanStart)
+		r.POST("/channels/:id/ptz/stop", api.PTZStop)
+		r.POST("/channels/:id/ptz/home", api.PTZHomePosition)
+	}
+	// 设备控制类接口
+	{
+		r.POST("/devices/:id/teleboot", api.DevicesTeleBoot)
+		r.POST("/devices/:id/guard", api.DevicesGuard)
+		r.POST("/devices/:id/alarm_reset", api.DevicesAlarmReset)
+		r.POST("/channels/:id/record", api.ChannelsRecord)
+		r.POST("/channels/:id/iframe", api.ChannelsIFrame)
+		r.GET("/control_logs", api.ControlLogsList)
+	}
+	// 抓拍类接口
+	{
+		r.POST("/channels/:id/snapshot", api.ChannelsSnapshot)
+		r.GET("/channels/:id/snapshots", api.ChannelsSnapshotsList)
+		r.POST("/snapshots/upload/:session", api.SnapshotUpload)
+		r.PUT("/snapshots/upload/:session", api.SnapshotUpload)
+		r.POST("/snapshots/upload/:session/*file", api.SnapshotUpload)
+		r.PUT("/snapshots/upload/:session/*file", api.SnapshotUpload)
+		r.Static("/snapshots/files", m.MConfig.Snapshot.FilePath)
+	}
+	// 固件升级类接口
+	{
+		r.POST("/firmwares", api.FirmwaresCreate)
+		r.GET("/firmwares", api.FirmwaresList)
+		r.Static("/firmwares/files", m.MConfig.Upgrade.FilePath)
+		r.POST("/upgrades", api.UpgradesCreate)
+		r.GET("/upgrades", api.UpgradesList)
+		r.GET("/upgrades/:id", api.UpgradesDetail)
+		r.POST("/upgrades/:id/cancel", api.UpgradesCancel)
+	}
 	// 播放类接口
 	{
 		r.GET("/streams", api.StreamsList)
 		r.POST("/channels/:id/streams", api.Play)
-		r.POST("/channels/:id/start_talk", api.StartTalk)
 		r.DELETE("/streams/:id", api.Stop)
+		r.POST("/streams/:id/pause", api.StreamsPause)
+		r.POST("/streams/:id/resume", api.StreamsResume)
+		r.POST("/streams/:id/seek", api.StreamsSeek)
+		r.POST("/streams/:id/speed", api.StreamsSpeed)
+		r.GET("/streams/:id/stats", api.StreamsStats)
+		r.POST("/streams/revoke", api.StreamsRevoke)
+		r.POST("/viewers/:token/heartbeat", api.ViewerHeartbeat)
+		r.DELETE("/viewers/:token", api.ViewerRelease)
+	}
+	// 语音对讲类接口
+	{
+		r.POST("/channels/:id/start_talk", api.StartTalk)
+		r.GET("/talks", api.TalksList)
+		r.DELETE("/talks/:id", api.TalksStop)
+	}
+	// 语音广播类接口
+	{
+		r.POST("/broadcasts", api.BroadcastsCreate)
+		r.GET("/broadcasts", api.BroadcastsList)
+		r.DELETE("/broadcasts/:id", api.BroadcastsStop)
 	}
 	// 录像类
 	{
 		r.GET("/channels/:id/records", api.RecordsList)
+		r.POST("/channels/:id/records/download", api.RecordsDownload)
+		r.GET("/files", api.FilesList)
+	}
+	// onvif 设备
+	{
+		r.GET("/onvif/devices", api.OnvifDiscover)
+		r.POST("/onvif/devices", api.OnvifOnboard)
+	}
+	// 媒体节点
+	{
+		r.GET("/media/nodes", api.MediaNodesList)
 	}
 	// zlm webhook
 	{
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/panjjo/gosip/m"
+	sipapi "github.com/panjjo/gosip/sip"
+)
+
+// 设备按下发的上传地址上传抓拍图片，不能被重定向
+func TestSnapshotUploadRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m.MConfig = &m.Config{}
+	r := gin.New()
+	Init(r)
+
+	uploadURL, err := url.Parse(sipapi.SnapshotUploadURL("http://127.0.0.1:8090/", "abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range []string{http.MethodPost, http.MethodPut} {
+		for _, path := range []string{uploadURL.Path, uploadURL.Path + "/1.jpg"} {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(method, path, strings.NewReader("jpeg"))
+			req.Header.Set("Content-Type", "image/jpeg")
+			r.ServeHTTP(w, req)
+			// 会话不存在由上传接口返回错误，说明请求已到达上传接口
+			if !strings.Contains(w.Body.String(), "抓拍会话不存在") {
+				t.Fatalf("%s %s: code %d body %s", method, path, w.Code, w.Body.String())
+			}
+		}
+	}
+}
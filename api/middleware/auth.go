@@ -7,11 +7,21 @@ import (
 	"github.com/panjjo/gosip/utils"
 )
 
+// PrincipalKey 请求方身份在上下文中的key
+const PrincipalKey = "principal"
+
+// Principal 请求方身份，由服务端确定，不接受客户端传入
+// 接口签名鉴权实现前以请求方ip作为身份
+func Principal(c *gin.Context) string {
+	return c.GetString(PrincipalKey)
+}
+
 // Restful API sign 鉴权
 func Auth(c *gin.Context) {
 	if c.GetString("msgid") == "" {
 		c.Set("msgid", utils.RandString(32))
 	}
+	c.Set(PrincipalKey, c.ClientIP())
 	if strings.Contains(c.Request.URL.Path, "/zlm/webhook") {
 		c.Next()
 		return
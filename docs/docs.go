@@ -25,9 +25,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/channels": {
+        "/broadcasts": {
             "get": {
-                "description": "可以根据查询条件查询通道列表",
+                "description": "查询当前正在进行的语音广播",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
@@ -35,40 +35,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "channels"
-                ],
-                "summary": "通道列表接口",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "条数(0-100) 默认20",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "间隔 默认0",
-                        "name": "skip",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "排序,例:-key,根据key倒序,key,根据key正序",
-                        "name": "sort",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "查询条件,使用规则详情请看帮助",
-                        "name": "filters",
-                        "in": "query"
-                    }
+                    "broadcast"
                 ],
+                "summary": "广播列表",
                 "responses": {
                     "0": {
                         "description": "",
                         "schema": {
-                            "$ref": "#/definitions/api.ChannelsListResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/sipapi.Broadcast"
+                            }
                         }
                     },
                     "1000": {
@@ -96,11 +73,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/channels/{id}": {
+            },
             "post": {
-                "description": "调整通道信息",
+                "description": "向一个或多个语音输出通道发送广播通知，设备收到通知后发起INVITE，服务端应答媒体服务器中的音频源\n音频源需先推送到媒体服务器 broadcast 应用下，每个通道独立返回广播结果",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
@@ -108,41 +83,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "channels"
+                    "broadcast"
                 ],
-                "summary": "通道修改接口",
+                "summary": "语音广播",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "通道id",
-                        "name": "id",
-                        "in": "path",
+                        "description": "语音输出通道id，多个使用英文逗号分隔",
+                        "name": "channelids",
+                        "in": "formData",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "通道备注",
-                        "name": "memo",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "string",
-                        "description": "播放类型，pull 媒体服务器拉流，push 摄像头推流,默认push",
-                        "name": "streamtype",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "string",
-                        "description": "静态拉流地址，streamtype=pull 时生效。",
-                        "name": "url",
-                        "in": "formData"
+                        "description": "音频源stream，媒体服务器中 broadcast 应用下的流id",
+                        "name": "stream",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "0": {
                         "description": "",
                         "schema": {
-                            "$ref": "#/definitions/sipapi.Channels"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/sipapi.Broadcast"
+                            }
                         }
                     },
                     "1000": {
@@ -170,9 +137,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/broadcasts/{id}": {
             "delete": {
-                "description": "删除通道信息",
+                "description": "结束通道的语音广播，向设备发送BYE并停止媒体服务器发送音频",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
@@ -180,13 +149,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "channels"
+                    "broadcast"
                 ],
-                "summary": "通道删除接口",
+                "summary": "停止广播",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "通道id",
+                        "description": "语音输出通道id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -226,9 +195,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/channels/{id}/records": {
+        "/channels": {
             "get": {
-                "description": "用来获取通道设备存储的可回放时间段列表，注意控制时间跨度，跨度越大，数据量越多，返回越慢，甚至会超时（最多10s）。",
+                "description": "可以根据查询条件查询通道列表",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
@@ -236,37 +205,40 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "records"
+                    "channels"
                 ],
-                "summary": "回放文件时间列表",
+                "summary": "通道列表接口",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "通道id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "条数(0-100) 默认20",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "开始时间，时间戳",
-                        "name": "start",
-                        "in": "query",
-                        "required": true
+                        "description": "间隔 默认0",
+                        "name": "skip",
+                        "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "结束时间，时间戳",
-                        "name": "end",
-                        "in": "query",
-                        "required": true
+                        "type": "string",
+                        "description": "排序,例:-key,根据key倒序,key,根据key正序",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "查询条件,使用规则详情请看帮助",
+                        "name": "filters",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "0": {
                         "description": "",
                         "schema": {
-                            "$ref": "#/definitions/sipapi.Records"
+                            "$ref": "#/definitions/api.ChannelsListResponse"
                         }
                     },
                     "1000": {
@@ -296,9 +268,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/channels/{id}/start_talk": {
+        "/channels/{id}": {
             "post": {
-                "description": "对讲一个通道最多存在一个流",
+                "description": "调整通道信息",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
@@ -306,9 +278,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "streams"
+                    "channels"
                 ],
-                "summary": "通道对讲",
+                "summary": "通道修改接口",
                 "parameters": [
                     {
                         "type": "string",
@@ -316,13 +288,67 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "通道备注",
+                        "name": "memo",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "播放类型，pull 媒体服务器拉流，push 摄像头推流,默认push",
+                        "name": "streamtype",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "静态拉流地址，streamtype=pull 时生效，支持 rtsp/rtmp/http-flv",
+                        "name": "url",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "拉流账号，streamtype=pull 时生效",
+                        "name": "username",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "拉流密码，streamtype=pull 时生效",
+                        "name": "password",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rtsp拉流方式 0 tcp 1 udp 2 组播，默认tcp",
+                        "name": "rtptype",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "拉流失败重试次数，0或-1为无限重试",
+                        "name": "retry",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "媒体流传输方式 udp/tcp_passive/tcp_active，通道未设置时使用默认配置",
+                        "name": "transport",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "媒体服务器节点分区，点播时优先使用同分区节点",
+                        "name": "zone",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "0": {
                         "description": "",
                         "schema": {
-                            "$ref": "#/definitions/sipapi.Streams"
+                            "$ref": "#/definitions/sipapi.Channels"
                         }
                     },
                     "1000": {
@@ -350,11 +376,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/channels/{id}/streams": {
-            "post": {
-                "description": "直播一个通道最多存在一个流，回放每请求一次生成一个流",
+            },
+            "delete": {
+                "description": "删除通道信息",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
@@ -362,9 +386,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "streams"
+                    "channels"
                 ],
-                "summary": "监控播放（直播/回放）",
+                "summary": "通道删除接口",
                 "parameters": [
                     {
                         "type": "string",
@@ -372,31 +396,13 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "是否回放，1回放，0直播，默认0",
-                        "name": "replay",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "回放开始时间，时间戳，replay=1时必传",
-                        "name": "start",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "回放结束时间，时间戳，replay=1时必传",
-                        "name": "end",
-                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "0": {
                         "description": "",
                         "schema": {
-                            "$ref": "#/definitions/sipapi.Streams"
+                            "type": "string"
                         }
                     },
                     "1000": {
@@ -426,9 +432,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/devices": {
-            "get": {
-                "description": "可以根据查询条件查询设备列表",
+        "/channels/{id}/iframe": {
+            "post": {
+                "description": "向通道发送IFameCmd指令，要求设备立即发送关键帧，设备不返回应答",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
@@ -436,40 +442,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "control"
                 ],
-                "summary": "设备列表接口",
+                "summary": "强制关键帧",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "条数(0-100) 默认20",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "间隔 默认0",
-                        "name": "skip",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "排序,例:-key,根据key倒序,key,根据key正序",
-                        "name": "sort",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
-                        "description": "查询条件,使用规则详情请看帮助",
-                        "name": "filters",
-                        "in": "query"
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "0": {
                         "description": "",
                         "schema": {
-                            "$ref": "#/definitions/api.DevicesListResponse"
+                            "$ref": "#/definitions/sipapi.MessageResponse"
                         }
                     },
                     "1000": {
@@ -499,9 +488,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/devices/create": {
+        "/channels/{id}/ptz": {
             "post": {
-                "description": "通过此接口新增一个设备，获取设备id",
+                "description": "控制通道云台方向、变倍、光圈、聚焦，被其他同等及以上优先级的操作员控制时返回错误",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
@@ -509,28 +498,28 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "ptz"
                 ],
-                "summary": "设备新增接口",
+                "summary": "通道云台控制",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "设备密码(GB28181认证密码)",
-                        "name": "pwd",
-                        "in": "formData",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "设备名称",
-                        "name": "name",
+                        "description": "方向(halt,right,rightup,up,leftup,left,leftdown,down,rightdown,zoom,iris,focus)",
+                        "name": "ptz_type",
                         "in": "formData",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "设备ID(可选，不提供则自动生成)",
-                        "name": "deviceId",
+                        "type": "integer",
+                        "description": "速度(1-255)，变倍、光圈、聚焦时负数表示反向",
+                        "name": "speed",
                         "in": "formData"
                     }
                 ],
@@ -538,7 +527,7 @@ const docTemplate = `{
                     "0": {
                         "description": "",
                         "schema": {
-                            "$ref": "#/definitions/sipapi.Devices"
+                            "type": "string"
                         }
                     },
                     "1000": {
@@ -568,9 +557,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/devices/ptz": {
+        "/channels/{id}/ptz/cruises/{group}": {
             "post": {
-                "description": "通过此接口控制设备云台方向和速度",
+                "description": "设置巡航速度和停留时间，至少传入一项",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
@@ -578,29 +567,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "ptz"
                 ],
-                "summary": "设备PTZ云台控制",
+                "summary": "设置巡航参数",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "设备ID",
-                        "name": "device_id",
-                        "in": "formData",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "方向(halt,right,rightup,up,leftup,left,leftdown,down,rightdown,zoom,iris,focus)",
-                        "name": "ptz_type",
-                        "in": "formData",
+                        "type": "integer",
+                        "description": "巡航组号(0-255)",
+                        "name": "group",
+                        "in": "path",
                         "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "速度(1-255)",
+                        "description": "巡航速度(1-4095)",
                         "name": "speed",
                         "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "停留时间，单位秒(1-4095)",
+                        "name": "dwell",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
@@ -615,13 +610,31 @@ const docTemplate = `{
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/devices/{id}": {
+        "/channels/{id}/ptz/cruises/{group}/points": {
             "post": {
-                "description": "调整设备信息",
+                "description": "将预置位加入巡航轨迹",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
@@ -629,35 +642,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "ptz"
                 ],
-                "summary": "设备修改接口",
+                "summary": "加入巡航点",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "设备id",
+                        "description": "通道id",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "设备密码(GB28181认证密码)",
-                        "name": "pwd",
-                        "in": "formData"
+                        "type": "integer",
+                        "description": "巡航组号(0-255)",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "设备名称",
-                        "name": "name",
-                        "in": "formData"
+                        "type": "integer",
+                        "description": "预置位编号(1-255)",
+                        "name": "presetid",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "0": {
                         "description": "",
                         "schema": {
-                            "$ref": "#/definitions/sipapi.Devices"
+                            "type": "string"
                         }
                     },
                     "1000": {
@@ -685,9 +700,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/channels/{id}/ptz/cruises/{group}/points/{presetid}": {
             "delete": {
-                "description": "删除设备信息",
+                "description": "从巡航轨迹中删除预置位，预置位编号为0时删除整条巡航轨迹",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
@@ -695,16 +712,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "ptz"
                 ],
-                "summary": "设备删除接口",
+                "summary": "删除巡航点",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "设备id",
+                        "description": "通道id",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "巡航组号(0-255)",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "预置位编号(0-255)",
+                        "name": "presetid",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -741,9 +772,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/devices/{id}/channels": {
+        "/channels/{id}/ptz/cruises/{group}/start": {
             "post": {
-                "description": "通过此接口在设备下新增通道，获取通道id",
+                "description": "按巡航轨迹开始巡航，停止巡航使用 /channels/{id}/ptz/stop",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
@@ -751,41 +782,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "channels"
+                    "ptz"
                 ],
-                "summary": "通道新增接口",
+                "summary": "开始巡航",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "设备id",
+                        "description": "通道id",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "通道备注",
-                        "name": "memo",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "string",
-                        "description": "播放类型，pull 媒体服务器拉流，push 摄像头推流,默认push",
-                        "name": "streamtype",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "string",
-                        "description": "静态拉流地址，streamtype=pull 时生效。",
-                        "name": "url",
-                        "in": "formData"
+                        "type": "integer",
+                        "description": "巡航组号(0-255)",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "0": {
                         "description": "",
                         "schema": {
-                            "$ref": "#/definitions/sipapi.Channels"
+                            "type": "string"
                         }
                     },
                     "1000": {
@@ -815,9 +835,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/devices/{id}/channels_sync": {
+        "/channels/{id}/ptz/drag_zoom": {
             "post": {
-                "description": "主动同步指定设备的通道信息",
+                "description": "在播放画面上拉框，摄像机将框选区域放大或缩小。坐标为归一化坐标，以画面左上角为原点，取值0-1",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
@@ -825,16 +845,63 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "channels"
+                    "ptz"
                 ],
-                "summary": "设备通道同步接口",
+                "summary": "拉框放大/缩小",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "设备id",
+                        "description": "通道id",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "in 拉框放大 out 拉框缩小",
+                        "name": "zoom",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "拉框左上角X坐标(0-1)",
+                        "name": "x",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "拉框左上角Y坐标(0-1)",
+                        "name": "y",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "拉框宽度(0-1)",
+                        "name": "w",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "拉框高度(0-1)",
+                        "name": "h",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "播放窗口长度像素值，默认1920",
+                        "name": "length",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "播放窗口宽度像素值，默认1080",
+                        "name": "width",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
@@ -871,9 +938,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/streams": {
-            "get": {
-                "description": "可以根据查询条件查询视频流列表",
+        "/channels/{id}/ptz/home": {
+            "post": {
+                "description": "开启或关闭看守位，云台空闲超过自动归位时间后转动到看守位预置位",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
@@ -881,40 +948,42 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "streams"
+                    "ptz"
                 ],
-                "summary": "视频流列表接口",
+                "summary": "看守位设置",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "条数(0-100) 默认20",
-                        "name": "limit",
-                        "in": "query"
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "间隔 默认0",
-                        "name": "skip",
-                        "in": "query"
+                        "description": "看守位开关 0关闭 1开启",
+                        "name": "enabled",
+                        "in": "formData",
+                        "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "排序,例:-key,根据key倒序,key,根据key正序",
-                        "name": "sort",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "自动归位时间，单位秒，开启时必填",
+                        "name": "resettime",
+                        "in": "formData"
                     },
                     {
-                        "type": "string",
-                        "description": "查询条件,使用规则详情请看帮助",
-                        "name": "filters",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "看守位预置位编号(1-255)，开启时必填",
+                        "name": "presetindex",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "0": {
                         "description": "",
                         "schema": {
-                            "$ref": "#/definitions/api.StreamsListResponse"
+                            "$ref": "#/definitions/sipapi.MessageResponse"
                         }
                     },
                     "1000": {
@@ -944,9 +1013,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/streams/{id}": {
-            "delete": {
-                "description": "无人观看5分钟自动关闭，直播流无需调用此接口。",
+        "/channels/{id}/ptz/lock": {
+            "get": {
+                "description": "获取通道当前的云台控制者，无人控制时返回空",
                 "consumes": [
                     "application/x-www-form-urlencoded"
                 ],
@@ -954,13 +1023,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "streams"
+                    "ptz"
                 ],
-                "summary": "停止播放（直播/回放）",
+                "summary": "云台控制锁",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "流id,播放接口返回的streamid",
+                        "description": "通道id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -970,7 +1039,7 @@ const docTemplate = `{
                     "0": {
                         "description": "",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/sipapi.PTZLock"
                         }
                     },
                     "1000": {
@@ -999,386 +1068,5261 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "api.ChannelsListResponse": {
-            "type": "object",
-            "properties": {
-                "list": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/sipapi.Channels"
-                    }
-                },
-                "total": {
-                    "type": "integer"
-                }
-            }
         },
-        "api.DevicesListResponse": {
-            "type": "object",
-            "properties": {
-                "list": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/sipapi.Devices"
+        "/channels/{id}/ptz/move": {
+            "post": {
+                "description": "开始持续转动，客户端需要在5秒内调用心跳接口保持转动，超时未收到心跳自动发送停止指令",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ptz"
+                ],
+                "summary": "开始持续转动",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "方向(right,rightup,up,leftup,left,leftdown,down,rightdown,zoom,iris,focus)",
+                        "name": "ptz_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "速度(1-255)，变倍、光圈、聚焦时负数表示反向",
+                        "name": "speed",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.PTZLock"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
-                },
-                "total": {
-                    "type": "integer"
                 }
-            }
-        },
-        "api.StreamsListResponse": {
-            "type": "object",
-            "properties": {
-                "list": {
-                    "type": "array",
+            },
+            "delete": {
+                "description": "结束持续转动并发送停止指令",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ptz"
+                ],
+                "summary": "结束持续转动",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/channels/{id}/ptz/move/heartbeat": {
+            "post": {
+                "description": "保持持续转动，需由开始转动的操作员调用",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ptz"
+                ],
+                "summary": "持续转动心跳",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.PTZLock"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/channels/{id}/ptz/precise": {
+            "post": {
+                "description": "控制云台转动到指定的水平、垂直角度和变倍倍数（GB/T 28181-2022 PTZPreciseCtrl）",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ptz"
+                ],
+                "summary": "云台精准控制",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "水平角度(0-360)",
+                        "name": "pan",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "垂直角度(-90-90)",
+                        "name": "tilt",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "变倍倍数(1-1000)",
+                        "name": "zoom",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/channels/{id}/ptz/presets": {
+            "get": {
+                "description": "向设备查询通道预置位，预置位名称优先使用本地保存的名称",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ptz"
+                ],
+                "summary": "预置位列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/sipapi.Presets"
+                            }
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "将云台当前位置设置为预置位，并保存预置位名称",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ptz"
+                ],
+                "summary": "设置预置位",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "预置位编号(1-255)",
+                        "name": "presetid",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "预置位名称",
+                        "name": "name",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.Presets"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/channels/{id}/ptz/presets/{presetid}": {
+            "delete": {
+                "description": "删除设备上的预置位，同时删除本地保存的预置位名称",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ptz"
+                ],
+                "summary": "删除预置位",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "预置位编号(1-255)",
+                        "name": "presetid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/channels/{id}/ptz/presets/{presetid}/call": {
+            "post": {
+                "description": "云台转动到指定预置位",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ptz"
+                ],
+                "summary": "调用预置位",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "预置位编号(1-255)",
+                        "name": "presetid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/channels/{id}/ptz/scans/{group}": {
+            "post": {
+                "description": "将云台当前位置设置为自动扫描左/右边界，或设置自动扫描速度，至少传入一项",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ptz"
+                ],
+                "summary": "设置自动扫描参数",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "扫描组号(0-255)",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "扫描边界 left/right",
+                        "name": "boundary",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "扫描速度(1-4095)",
+                        "name": "speed",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/channels/{id}/ptz/scans/{group}/start": {
+            "post": {
+                "description": "在左右边界之间开始自动扫描，停止扫描使用 /channels/{id}/ptz/stop",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ptz"
+                ],
+                "summary": "开始自动扫描",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "扫描组号(0-255)",
+                        "name": "group",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/channels/{id}/ptz/stop": {
+            "post": {
+                "description": "发送停止指令，停止云台转动、巡航以及自动扫描",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ptz"
+                ],
+                "summary": "停止云台动作",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/channels/{id}/record": {
+            "post": {
+                "description": "向通道发送RecordCmd指令，开始或停止设备端录像",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "control"
+                ],
+                "summary": "设备录像控制",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Record 开始录像 StopRecord 停止录像",
+                        "name": "cmd",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.MessageResponse"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/channels/{id}/records": {
+            "get": {
+                "description": "用来获取通道设备存储的可回放时间段列表，注意控制时间跨度，跨度越大，数据量越多，返回越慢，甚至会超时（最多10s）。",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "records"
+                ],
+                "summary": "回放文件时间列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "开始时间，时间戳",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "结束时间，时间戳",
+                        "name": "end",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.Records"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/channels/{id}/records/download": {
+            "post": {
+                "description": "设备按下载倍速发送历史媒体流，zlm录制为mp4文件，可通过流列表查看下载进度，完成后文件记录在文件列表中并发送records.download_done通知",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "records"
+                ],
+                "summary": "下载历史媒体文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "开始时间，时间戳",
+                        "name": "start",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "结束时间，时间戳",
+                        "name": "end",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "下载倍速(1,2,4,8)，默认4",
+                        "name": "speed",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.Streams"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/channels/{id}/snapshot": {
+            "post": {
+                "description": "向通道下发图像抓拍配置，设备抓拍后将图片上传到本服务，上传完成后发送snapshots.finished通知",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "snapshots"
+                ],
+                "summary": "通道抓拍",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "抓拍张数(1-10)，默认1",
+                        "name": "snapnum",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "抓拍间隔，单位秒(1-600)，默认1",
+                        "name": "interval",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.SnapshotTask"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/channels/{id}/snapshots": {
+            "get": {
+                "description": "查询通道的抓拍图片，图片访问地址为 /snapshots/files/{file}",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "snapshots"
+                ],
+                "summary": "通道抓拍图片列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "抓拍会话id",
+                        "name": "sessionid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "条数(0-100) 默认20",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "间隔 默认0",
+                        "name": "skip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序,例:-key,根据key倒序,key,根据key正序",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/api.SnapshotsListResponse"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/channels/{id}/start_talk": {
+            "post": {
+                "description": "创建语音对讲会话，返回浏览器麦克风音频的 WebRTC 推流地址，一个通道最多存在一个对讲会话\n浏览器推流后服务端自动与设备建立会话，音频按设备支持的 G711A/G711U/AAC 编码转发，没有音频数据超过空闲时间后自动关闭",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "talk"
+                ],
+                "summary": "通道对讲",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "对讲模式 talk 双向对讲 broadcast 语音广播，默认talk",
+                        "name": "mode",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "浏览器推送的音频编码 PCMA/PCMU/AAC，默认使用配置",
+                        "name": "codec",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.TalkSession"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/channels/{id}/streams": {
+            "post": {
+                "description": "直播一个通道最多存在一个流，回放每请求一次生成一个流\n每次调用创建一个观看会话，返回的token需定时心跳，存在会话时流保持打开，最后一个会话结束后流按配置的保留时间关闭",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "streams"
+                ],
+                "summary": "监控播放（直播/回放）",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通道id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "是否回放，1回放，0直播，默认0",
+                        "name": "replay",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "回放开始时间，时间戳，replay=1时必传",
+                        "name": "start",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "回放结束时间，时间戳，replay=1时必传",
+                        "name": "end",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "传输方式 udp/tcp_passive/tcp_active，默认使用通道设置，设备拒绝时自动回退",
+                        "name": "transport",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "开启播放地址签名时是否绑定请求方ip，1绑定，只允许请求方ip使用播放地址",
+                        "name": "bind",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.Streams"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/control_logs": {
+            "get": {
+                "description": "查询设备控制审计日志",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "control"
+                ],
+                "summary": "设备控制日志",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "条数(0-100) 默认20",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "间隔 默认0",
+                        "name": "skip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序,例:-key,根据key倒序,key,根据key正序",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "查询条件,使用规则详情请看帮助",
+                        "name": "filters",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/api.ControlLogsListResponse"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices": {
+            "get": {
+                "description": "可以根据查询条件查询设备列表",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "设备列表接口",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "条数(0-100) 默认20",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "间隔 默认0",
+                        "name": "skip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序,例:-key,根据key倒序,key,根据key正序",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "查询条件,使用规则详情请看帮助",
+                        "name": "filters",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/api.DevicesListResponse"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices/create": {
+            "post": {
+                "description": "通过此接口新增一个设备，获取设备id",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "设备新增接口",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "设备密码(GB28181认证密码)",
+                        "name": "pwd",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备名称",
+                        "name": "name",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备ID(可选，不提供则自动生成)",
+                        "name": "deviceId",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.Devices"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices/ptz": {
+            "post": {
+                "description": "通过此接口控制设备云台方向和速度，传入channel_id时控制指定通道，否则控制设备本身（单通道IPC），被其他同等及以上优先级的请求方控制时返回错误",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "设备PTZ云台控制",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "设备ID",
+                        "name": "device_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "通道ID，多通道NVR下需指定",
+                        "name": "channel_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "方向(halt,right,rightup,up,leftup,left,leftdown,down,rightdown,zoom,iris,focus)",
+                        "name": "ptz_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "速度(1-255)",
+                        "name": "speed",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices/{id}": {
+            "post": {
+                "description": "调整设备信息",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "设备修改接口",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "设备id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备密码(GB28181认证密码)",
+                        "name": "pwd",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备名称",
+                        "name": "name",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.Devices"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "删除设备信息",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "设备删除接口",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "设备id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices/{id}/alarm_reset": {
+            "post": {
+                "description": "向设备或报警通道发送AlarmCmd报警复位指令，不传报警方式和报警类型时复位全部报警",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "control"
+                ],
+                "summary": "报警复位",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "设备id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "报警通道id，为空时控制设备本身",
+                        "name": "channelid",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "报警方式 1电话 2设备 3短信 4GPS 5视频 6设备故障 7其他",
+                        "name": "alarmmethod",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "报警类型，1-99，含义与报警方式相关",
+                        "name": "alarmtype",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.MessageResponse"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices/{id}/channels": {
+            "post": {
+                "description": "通过此接口在设备下新增通道，获取通道id",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "channels"
+                ],
+                "summary": "通道新增接口",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "设备id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "通道备注",
+                        "name": "memo",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "播放类型，pull 媒体服务器拉流，push 摄像头推流,默认push",
+                        "name": "streamtype",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "静态拉流地址，streamtype=pull 时生效，支持 rtsp/rtmp/http-flv",
+                        "name": "url",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "拉流账号，streamtype=pull 时生效",
+                        "name": "username",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "拉流密码，streamtype=pull 时生效",
+                        "name": "password",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "rtsp拉流方式 0 tcp 1 udp 2 组播，默认tcp",
+                        "name": "rtptype",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "拉流失败重试次数，0或-1为无限重试",
+                        "name": "retry",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "媒体流传输方式 udp/tcp_passive/tcp_active，通道未设置时使用默认配置",
+                        "name": "transport",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.Channels"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices/{id}/channels_sync": {
+            "post": {
+                "description": "主动同步指定设备的通道信息",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "channels"
+                ],
+                "summary": "设备通道同步接口",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "设备id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices/{id}/config": {
+            "get": {
+                "description": "向设备发送ConfigDownload查询，返回设备基本参数、视频参数范围、SVAC编解码配置、OSD配置等",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "设备配置查询",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "设备id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "配置类型，BasicParam/VideoParamOpt/SVACEncodeConfig/SVACDecodeConfig/OSDConfig，多个类型使用/分隔",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "通道id，查询设备下指定通道的配置",
+                        "name": "channelid",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.ConfigDownload"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "向设备发送DeviceConfig配置，请求体为json，至少包含一项配置，等待设备返回配置结果",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "设备配置修改",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "设备id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "通道id，配置设备下指定通道",
+                        "name": "channelid",
+                        "in": "query"
+                    },
+                    {
+                        "description": "配置内容",
+                        "name": "config",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.DeviceConfigRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.MessageResponse"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices/{id}/guard": {
+            "post": {
+                "description": "向设备或报警通道发送GuardCmd布防/撤防指令",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "control"
+                ],
+                "summary": "布防/撤防",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "设备id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "SetGuard 布防 ResetGuard 撤防",
+                        "name": "cmd",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "报警通道id，为空时控制设备本身",
+                        "name": "channelid",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.MessageResponse"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices/{id}/status": {
+            "get": {
+                "description": "向设备发送DeviceStatus查询，返回设备在线、工作、编码、录像状态以及报警通道布防状态，同时根据返回结果校正通道状态",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "设备状态查询",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "设备id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.DeviceStatus"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/devices/{id}/teleboot": {
+            "post": {
+                "description": "向设备发送TeleBoot远程启动指令，设备重启不返回应答，指令发送成功即返回",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "control"
+                ],
+                "summary": "设备远程启动",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "设备id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.MessageResponse"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/files": {
+            "get": {
+                "description": "查询录制及下载生成的文件，status=1为文件已生成，url为文件下载地址",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "records"
+                ],
+                "summary": "录制文件列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "条数(0-100) 默认20",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "间隔 默认0",
+                        "name": "skip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序,例:-key,根据key倒序,key,根据key正序",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "查询条件,使用规则详情请看帮助",
+                        "name": "filters",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/api.FilesListResponse"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/firmwares": {
+            "get": {
+                "description": "可以根据查询条件查询固件列表",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "upgrades"
+                ],
+                "summary": "固件列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "条数(0-100) 默认20",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "间隔 默认0",
+                        "name": "skip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序,例:-key,根据key倒序,key,根据key正序",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "查询条件,使用规则详情请看帮助",
+                        "name": "filters",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/api.FirmwaresListResponse"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "上传设备固件文件，设备升级时从 /firmwares/files/{file} 下载",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "upgrades"
+                ],
+                "summary": "固件上传",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "固件文件",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "固件版本",
+                        "name": "firmware",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "固件名称，默认使用文件名",
+                        "name": "name",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "适用的设备厂商，为空时不限制",
+                        "name": "manufacturer",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.Firmwares"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/media/nodes": {
+            "get": {
+                "description": "查询所有媒体服务器节点及其负载，新流按分区和负载选择节点",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "媒体节点列表",
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/sipapi.MediaNode"
+                            }
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/onvif/devices": {
+            "get": {
+                "description": "在配置的网卡上发送 WS-Discovery 探测，返回应答的onvif设备，等待时间为配置的 onvif.timeout",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "onvif"
+                ],
+                "summary": "onvif设备发现",
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/onvif.Device"
+                            }
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "查询onvif设备的媒体配置，创建设备并为每个媒体配置创建拉流通道，可与国标通道一样使用播放接口\n同一设备服务地址重复接入时更新已有通道的拉流地址和账号",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "onvif"
+                ],
+                "summary": "onvif设备接入",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "设备服务地址，发现接口返回的xaddrs，如 http://192.168.1.64/onvif/device_service",
+                        "name": "xaddr",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备账号",
+                        "name": "username",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备密码",
+                        "name": "password",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备名称，默认为厂商和型号",
+                        "name": "name",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/api.OnvifOnboardResponse"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/streams": {
+            "get": {
+                "description": "可以根据查询条件查询视频流列表，viewers为当前观看会话数量",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "streams"
+                ],
+                "summary": "视频流列表接口",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "条数(0-100) 默认20",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "间隔 默认0",
+                        "name": "skip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序,例:-key,根据key倒序,key,根据key正序",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "查询条件,使用规则详情请看帮助",
+                        "name": "filters",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/api.StreamsListResponse"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/streams/revoke": {
+            "post": {
+                "description": "开启播放地址签名时，吊销用户此前获取的所有播放地址，之后重新调用播放接口获取的地址不受影响",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "streams"
+                ],
+                "summary": "吊销用户播放地址",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "观看用户，即调用播放接口的请求方身份",
+                        "name": "user",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/streams/{id}": {
+            "delete": {
+                "description": "无人观看5分钟自动关闭，直播流无需调用此接口。",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "streams"
+                ],
+                "summary": "停止播放（直播/回放）",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "流id,播放接口返回的streamid",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/streams/{id}/pause": {
+            "post": {
+                "description": "在回放会话内发送 MANSRTSP PAUSE 指令",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "streams"
+                ],
+                "summary": "暂停回放",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "流id,播放接口返回的streamid",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/streams/{id}/resume": {
+            "post": {
+                "description": "在回放会话内发送 MANSRTSP PLAY 指令，从暂停位置继续播放",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "streams"
+                ],
+                "summary": "继续回放",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "流id,播放接口返回的streamid",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/streams/{id}/seek": {
+            "post": {
+                "description": "在回放会话内发送带 Range 的 MANSRTSP PLAY 指令，跳转到指定时间播放",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "streams"
+                ],
+                "summary": "回放跳转",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "流id,播放接口返回的streamid",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "跳转时间，时间戳，需在回放开始和结束时间之间",
+                        "name": "time",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/streams/{id}/speed": {
+            "post": {
+                "description": "在回放会话内发送带 Scale 的 MANSRTSP PLAY 指令",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "streams"
+                ],
+                "summary": "回放倍速",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "流id,播放接口返回的streamid",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "倍速 0.25/0.5/1/2/4",
+                        "name": "speed",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/streams/{id}/stats": {
+            "get": {
+                "description": "返回流最近的统计采样窗口，包括收流码率、观看人数、帧率、丢包率等，每5秒采样一次\nstalled 为流是否卡死，直播流卡死后自动重新点播",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "streams"
+                ],
+                "summary": "视频流统计",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "流id,播放接口返回的streamid",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.StreamStats"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/talks": {
+            "get": {
+                "description": "查询当前所有语音对讲会话",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "talk"
+                ],
+                "summary": "对讲列表",
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/sipapi.TalkSession"
+                            }
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/talks/{id}": {
+            "delete": {
+                "description": "结束语音对讲会话，通知设备结束会话并断开浏览器推流",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "talk"
+                ],
+                "summary": "结束对讲",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "对讲会话id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/upgrades": {
+            "get": {
+                "description": "可以根据查询条件查询升级任务列表",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "upgrades"
+                ],
+                "summary": "升级任务列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "条数(0-100) 默认20",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "间隔 默认0",
+                        "name": "skip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序,例:-key,根据key倒序,key,根据key正序",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "查询条件,使用规则详情请看帮助",
+                        "name": "filters",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/api.UpgradesListResponse"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "按设备筛选条件选择设备进行固件升级，固件指定厂商时只升级该厂商的设备。按并发数依次升级，每台设备等待升级结果后再升级下一台",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "upgrades"
+                ],
+                "summary": "创建升级任务",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "固件id",
+                        "name": "firmwareid",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备查询条件，同设备列表接口，为空时选择全部设备",
+                        "name": "filters",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "同时升级的设备数(1-100)，默认使用配置",
+                        "name": "concurrency",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.UpgradeTasks"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/upgrades/{id}": {
+            "get": {
+                "description": "获取升级任务以及每台设备的升级进度和结果",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "upgrades"
+                ],
+                "summary": "升级任务详情",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "升级任务id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/api.UpgradeDetailResponse"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/upgrades/{id}/cancel": {
+            "post": {
+                "description": "未开始升级的设备不再升级，正在升级的设备继续等待升级结果",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "upgrades"
+                ],
+                "summary": "取消升级任务",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "升级任务id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/viewers/{token}": {
+            "delete": {
+                "description": "结束观看会话，流在最后一个会话结束并超过配置的 stream.linger 后关闭",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "streams"
+                ],
+                "summary": "结束观看",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "观看会话token，播放接口返回",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/viewers/{token}/heartbeat": {
+            "post": {
+                "description": "播放接口返回的token需定时心跳，超过配置的 stream.viewer_timeout 未心跳的会话失效",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "streams"
+                ],
+                "summary": "观看会话心跳",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "观看会话token，播放接口返回",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "0": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/sipapi.ViewerSession"
+                        }
+                    },
+                    "1000": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1001": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1002": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "1003": {
+                        "description": "",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.ChannelsListResponse": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/sipapi.Channels"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ControlLogsListResponse": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/sipapi.ControlLogs"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.DevicesListResponse": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/sipapi.Devices"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.FilesListResponse": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/sipapi.Files"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.FirmwaresListResponse": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/sipapi.Firmwares"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.OnvifOnboardResponse": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/sipapi.Channels"
+                    }
+                },
+                "device": {
+                    "$ref": "#/definitions/sipapi.Devices"
+                }
+            }
+        },
+        "api.SnapshotsListResponse": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/sipapi.Snapshots"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.StreamsListResponse": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/sipapi.Streams"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.UpgradeDetailResponse": {
+            "type": "object",
+            "properties": {
+                "devices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/sipapi.Upgrades"
+                    }
+                },
+                "task": {
+                    "$ref": "#/definitions/sipapi.UpgradeTasks"
+                }
+            }
+        },
+        "api.UpgradesListResponse": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/sipapi.UpgradeTasks"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "m.SysInfo": {
+            "type": "object",
+            "properties": {
+                "addtime": {
+                    "type": "integer"
+                },
+                "cid": {
+                    "description": "CID 通道id固定头部",
+                    "type": "string"
+                },
+                "cnum": {
+                    "description": "CNUM 当前通道数",
+                    "type": "integer"
+                },
+                "did": {
+                    "description": "DID 设备id固定头部",
+                    "type": "string"
+                },
+                "dnum": {
+                    "description": "DNUM 当前设备数",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lid": {
+                    "description": "LID 当前服务id",
+                    "type": "string"
+                },
+                "mediaServer": {
+                    "type": "boolean"
+                },
+                "region": {
+                    "description": "Region 当前域",
+                    "type": "string"
+                },
+                "uptime": {
+                    "type": "integer"
+                }
+            }
+        },
+        "onvif.Device": {
+            "type": "object",
+            "properties": {
+                "hardware": {
+                    "description": "Hardware 设备型号，取自 scope onvif://www.onvif.org/hardware/",
+                    "type": "string"
+                },
+                "ip": {
+                    "description": "IP 设备服务地址中的ip",
+                    "type": "string"
+                },
+                "location": {
+                    "description": "Location 设备位置，取自 scope onvif://www.onvif.org/location/",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name 设备名称，取自 scope onvif://www.onvif.org/name/",
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "types": {
+                    "description": "Types 设备类型",
+                    "type": "string"
+                },
+                "uuid": {
+                    "description": "UUID 设备端点地址，如 urn:uuid:xxx",
+                    "type": "string"
+                },
+                "xaddrs": {
+                    "description": "XAddrs 设备服务地址",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "sipapi.BasicParam": {
+            "type": "object",
+            "properties": {
+                "deviceid": {
+                    "type": "string"
+                },
+                "domainname": {
+                    "description": "DomainName 设备所属域名",
+                    "type": "string"
+                },
+                "expiration": {
+                    "description": "Expiration 注册过期时间(秒)",
+                    "type": "integer"
+                },
+                "heartbeatcount": {
+                    "description": "HeartBeatCount 心跳超时次数",
+                    "type": "integer"
+                },
+                "heartbeatinterval": {
+                    "description": "HeartBeatInterval 心跳间隔时间(秒)",
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Name 设备名称",
+                    "type": "string"
+                },
+                "sipserverid": {
+                    "description": "SIPServerID SIP服务器编码",
+                    "type": "string"
+                },
+                "sipserverip": {
+                    "type": "string"
+                },
+                "sipserverport": {
+                    "type": "integer"
+                }
+            }
+        },
+        "sipapi.BasicParamConfig": {
+            "type": "object",
+            "properties": {
+                "expiration": {
+                    "description": "Expiration 注册过期时间(秒)",
+                    "type": "integer",
+                    "maximum": 86400,
+                    "minimum": 60
+                },
+                "heartbeatcount": {
+                    "description": "HeartBeatCount 心跳超时次数",
+                    "type": "integer",
+                    "maximum": 255,
+                    "minimum": 3
+                },
+                "heartbeatinterval": {
+                    "description": "HeartBeatInterval 心跳间隔时间(秒)",
+                    "type": "integer",
+                    "maximum": 3600,
+                    "minimum": 5
+                },
+                "name": {
+                    "description": "Name 设备名称",
+                    "type": "string",
+                    "maxLength": 64
+                }
+            }
+        },
+        "sipapi.Broadcast": {
+            "type": "object",
+            "properties": {
+                "app": {
+                    "description": "App 音频源app",
+                    "type": "string"
+                },
+                "channelid": {
+                    "description": "ChannelID 语音输出通道编码",
+                    "type": "string"
+                },
+                "codec": {
+                    "description": "Codec 音频编码",
+                    "type": "string"
+                },
+                "deviceid": {
+                    "description": "DeviceID 设备编号",
+                    "type": "string"
+                },
+                "msg": {
+                    "description": "Msg 失败原因",
+                    "type": "string"
+                },
+                "port": {
+                    "description": "Port 媒体服务器发送端口",
+                    "type": "integer"
+                },
+                "ssrc": {
+                    "description": "SSRC 设备指定的ssrc",
+                    "type": "string"
+                },
+                "start": {
+                    "description": "Start 开始时间",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "Status 广播状态 waiting/playing/stopped/failed",
+                    "type": "string"
+                },
+                "stream": {
+                    "description": "Stream 音频源stream",
+                    "type": "string"
+                },
+                "transport": {
+                    "description": "Transport 媒体传输方式 udp/tcp",
+                    "type": "string"
+                }
+            }
+        },
+        "sipapi.Channels": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "Active 最后活跃时间",
+                    "type": "integer"
+                },
+                "address": {
+                    "description": "Address ip地址",
+                    "type": "string"
+                },
+                "addtime": {
+                    "type": "integer"
+                },
+                "channelid": {
+                    "description": "ChannelID 通道编码",
+                    "type": "string"
+                },
+                "civilcode": {
+                    "type": "string"
+                },
+                "deviceid": {
+                    "description": "DeviceID 设备编号",
+                    "type": "string"
+                },
+                "dutystatus": {
+                    "description": "DutyStatus 报警通道布防状态 ONDUTY/OFFDUTY/ALARM",
+                    "type": "string"
+                },
+                "fps": {
+                    "description": "视频FPS",
+                    "type": "integer"
+                },
+                "height": {
+                    "description": "视频高",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "manufacturer": {
+                    "type": "string"
+                },
+                "memo": {
+                    "description": "Memo 备注（用来标示通道信息）",
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name 通道名称（设备端设置名称）",
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "parental": {
+                    "type": "integer"
+                },
+                "profiletoken": {
+                    "description": "onvif接入通道对应的媒体配置token",
+                    "type": "string"
+                },
+                "pullretry": {
+                    "description": "拉流失败重试次数，0或-1为无限重试",
+                    "type": "integer"
+                },
+                "pullrtptype": {
+                    "description": "rtsp拉流方式 0 tcp 1 udp 2 组播",
+                    "type": "integer"
+                },
+                "pullusername": {
+                    "description": "拉流账号",
+                    "type": "string"
+                },
+                "registerway": {
+                    "type": "integer"
+                },
+                "safetyway": {
+                    "type": "integer"
+                },
+                "secrecy": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "Status 状态  on 在线",
+                    "type": "string"
+                },
+                "streamtype": {
+                    "description": "pull 媒体服务器主动拉流，push 监控设备主动推流",
+                    "type": "string"
+                },
+                "transport": {
+                    "description": "媒体流传输方式 udp/tcp_passive/tcp_active，为空时使用配置的默认方式",
+                    "type": "string"
+                },
+                "uptime": {
+                    "type": "integer"
+                },
+                "uri": {
+                    "type": "string"
+                },
+                "url": {
+                    "description": "streamtype=pull时，拉流地址 rtsp/rtmp/http-flv",
+                    "type": "string"
+                },
+                "vf": {
+                    "description": "视频编码格式",
+                    "type": "string"
+                },
+                "width": {
+                    "description": "视频宽",
+                    "type": "integer"
+                },
+                "zone": {
+                    "description": "媒体服务器节点分区，为空时按负载选择节点",
+                    "type": "string"
+                }
+            }
+        },
+        "sipapi.ConfigDownload": {
+            "type": "object",
+            "properties": {
+                "basicparam": {
+                    "$ref": "#/definitions/sipapi.BasicParam"
+                },
+                "deviceid": {
+                    "type": "string"
+                },
+                "osdconfig": {
+                    "$ref": "#/definitions/sipapi.OSDConfig"
+                },
+                "result": {
+                    "description": "Result 查询结果 OK/ERROR",
+                    "type": "string"
+                },
+                "svacdecodeconfig": {
+                    "$ref": "#/definitions/sipapi.SVACDecodeConfig"
+                },
+                "svacencodeconfig": {
+                    "$ref": "#/definitions/sipapi.SVACEncodeConfig"
+                },
+                "videoparamopt": {
+                    "$ref": "#/definitions/sipapi.VideoParamOpt"
+                }
+            }
+        },
+        "sipapi.ControlLogs": {
+            "type": "object",
+            "properties": {
+                "addtime": {
+                    "type": "integer"
+                },
+                "channelid": {
+                    "description": "ChannelID 通道编码，控制设备本身时为空",
+                    "type": "string"
+                },
+                "cmd": {
+                    "description": "Cmd 控制命令 TeleBoot/RecordCmd/GuardCmd/AlarmCmd/IFameCmd",
+                    "type": "string"
+                },
+                "deviceid": {
+                    "description": "DeviceID 设备编号",
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Error 错误信息",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "description": "IP 操作员ip",
+                    "type": "string"
+                },
+                "operator": {
+                    "description": "Operator 操作员，为请求方身份",
+                    "type": "string"
+                },
+                "result": {
+                    "description": "Result 设备应答结果 OK/ERROR，未收到应答时为空",
+                    "type": "string"
+                },
+                "uptime": {
+                    "type": "integer"
+                },
+                "value": {
+                    "description": "Value 命令参数",
+                    "type": "string"
+                }
+            }
+        },
+        "sipapi.DeviceAlarmStatus": {
+            "type": "object",
+            "properties": {
+                "deviceid": {
+                    "type": "string"
+                },
+                "dutystatus": {
+                    "description": "DutyStatus 报警设备状态 ONDUTY/OFFDUTY/ALARM",
+                    "type": "string"
+                }
+            }
+        },
+        "sipapi.DeviceConfigRequest": {
+            "type": "object",
+            "properties": {
+                "basicparam": {
+                    "$ref": "#/definitions/sipapi.BasicParamConfig"
+                },
+                "osdconfig": {
+                    "$ref": "#/definitions/sipapi.OSDConfig"
+                },
+                "svacdecodeconfig": {
+                    "$ref": "#/definitions/sipapi.SVACDecodeConfig"
+                },
+                "svacencodeconfig": {
+                    "$ref": "#/definitions/sipapi.SVACEncodeConfig"
+                },
+                "videoparamopt": {
+                    "$ref": "#/definitions/sipapi.VideoParamOpt"
+                }
+            }
+        },
+        "sipapi.DeviceStatus": {
+            "type": "object",
+            "properties": {
+                "alarmstatus": {
+                    "description": "Alarmstatus 报警设备状态列表",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/sipapi.DeviceAlarmStatus"
+                    }
+                },
+                "deviceid": {
+                    "type": "string"
+                },
+                "devicetime": {
+                    "description": "DeviceTime 设备时间和日期",
+                    "type": "string"
+                },
+                "encode": {
+                    "description": "Encode 是否编码 ON/OFF",
+                    "type": "string"
+                },
+                "online": {
+                    "description": "Online 是否在线 ONLINE/OFFLINE",
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "Reason 不正常工作原因",
+                    "type": "string"
+                },
+                "record": {
+                    "description": "Record 是否录像 ON/OFF",
+                    "type": "string"
+                },
+                "result": {
+                    "description": "Result 查询结果 OK/ERROR",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status 是否正常工作 OK/ERROR",
+                    "type": "string"
+                }
+            }
+        },
+        "sipapi.Devices": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "ActiveAt 最后心跳检测时间",
+                    "type": "integer"
+                },
+                "addtime": {
+                    "type": "integer"
+                },
+                "deviceid": {
+                    "description": "DeviceID 设备id",
+                    "type": "string"
+                },
+                "devicetype": {
+                    "description": "设备类型DVR，NVR",
+                    "type": "string"
+                },
+                "firmware": {
+                    "description": "Firmware 固件版本",
+                    "type": "string"
+                },
+                "heartbeatcount": {
+                    "description": "HeartBeatCount 设备心跳超时次数",
+                    "type": "integer"
+                },
+                "heartbeatinterval": {
+                    "description": "HeartBeatInterval 设备心跳间隔(秒)，查询或设置设备基本参数时更新",
+                    "type": "integer"
+                },
+                "host": {
+                    "description": "Host Via 地址",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "manufacturer": {
+                    "description": "Manufacturer 制造厂商",
+                    "type": "string"
+                },
+                "model": {
+                    "description": "Model 型号",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name 设备名称",
+                    "type": "string"
+                },
+                "onvif": {
+                    "description": "Onvif onvif接入设备的设备服务地址",
+                    "type": "string"
+                },
+                "port": {
+                    "description": "Port via 端口",
+                    "type": "string"
+                },
+                "proto": {
+                    "description": "Proto 协议",
+                    "type": "string"
+                },
+                "pwd": {
+                    "description": "PWD 密码",
+                    "type": "string"
+                },
+                "raddr": {
+                    "description": "RAddr via recevied",
+                    "type": "string"
+                },
+                "region": {
+                    "description": "Region 设备域",
+                    "type": "string"
+                },
+                "regist": {
+                    "description": "Regist 是否注册",
+                    "type": "boolean"
+                },
+                "report": {
+                    "description": "Rport via rport",
+                    "type": "string"
+                },
+                "source": {
+                    "description": "Source",
+                    "type": "string"
+                },
+                "sysinfo": {
+                    "$ref": "#/definitions/m.SysInfo"
+                },
+                "transport": {
+                    "description": "TransPort via transport",
+                    "type": "string"
+                },
+                "uptime": {
+                    "type": "integer"
+                },
+                "uri": {
+                    "type": "string"
+                }
+            }
+        },
+        "sipapi.Files": {
+            "type": "object",
+            "properties": {
+                "addtime": {
+                    "type": "integer"
+                },
+                "channelid": {
+                    "description": "下载文件所属通道",
+                    "type": "string"
+                },
+                "clear": {
+                    "type": "boolean"
+                },
+                "end": {
+                    "type": "integer"
+                },
+                "fid": {
+                    "type": "string"
+                },
+                "file": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "size": {
+                    "description": "文件大小，单位字节",
+                    "type": "integer"
+                },
+                "start": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "stream": {
+                    "type": "string"
+                },
+                "uptime": {
+                    "type": "integer"
+                },
+                "url": {
+                    "description": "文件下载地址",
+                    "type": "string"
+                }
+            }
+        },
+        "sipapi.Firmwares": {
+            "type": "object",
+            "properties": {
+                "addtime": {
+                    "type": "integer"
+                },
+                "file": {
+                    "description": "File 固件文件名",
+                    "type": "string"
+                },
+                "firmware": {
+                    "description": "Firmware 固件版本",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "manufacturer": {
+                    "description": "Manufacturer 适用的设备厂商，为空时不限制",
+                    "type": "string"
+                },
+                "md5": {
+                    "description": "MD5 文件md5",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name 固件名称",
+                    "type": "string"
+                },
+                "size": {
+                    "description": "Size 文件大小",
+                    "type": "integer"
+                },
+                "uptime": {
+                    "type": "integer"
+                }
+            }
+        },
+        "sipapi.MediaNode": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "最后一次收到心跳或查询成功的时间",
+                    "type": "integer"
+                },
+                "bytesspeed": {
+                    "description": "节点收流总码率，单位 bytes/s",
+                    "type": "integer"
+                },
+                "http": {
+                    "description": "播放地址前缀",
+                    "type": "string"
+                },
+                "https": {
+                    "description": "加密播放地址前缀，未配置时为空",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "online": {
+                    "description": "节点是否可用",
+                    "type": "boolean"
+                },
+                "rtmp": {
+                    "type": "string"
+                },
+                "rtmps": {
+                    "type": "string"
+                },
+                "rtp": {
+                    "description": "接收rtp推流的地址",
+                    "type": "string"
+                },
+                "rtsp": {
+                    "type": "string"
+                },
+                "rtsps": {
+                    "type": "string"
+                },
+                "static": {
+                    "description": "是否来自配置文件，否则为zlm启动通知注册",
+                    "type": "boolean"
+                },
+                "streams": {
+                    "description": "节点上的国标流数量",
+                    "type": "integer"
+                },
+                "ws": {
+                    "type": "string"
+                },
+                "wss": {
+                    "type": "string"
+                },
+                "zone": {
+                    "description": "节点分区",
+                    "type": "string"
+                }
+            }
+        },
+        "sipapi.MessageResponse": {
+            "type": "object",
+            "properties": {
+                "cmdtype": {
+                    "type": "string"
+                },
+                "deviceid": {
+                    "type": "string"
+                },
+                "result": {
+                    "description": "Result 执行结果 OK/ERROR",
+                    "type": "string"
+                },
+                "sn": {
+                    "type": "integer"
+                }
+            }
+        },
+        "sipapi.OSDConfig": {
+            "type": "object",
+            "properties": {
+                "item": {
+                    "type": "array",
+                    "maxItems": 8,
+                    "items": {
+                        "$ref": "#/definitions/sipapi.OSDItem"
+                    }
+                },
+                "length": {
+                    "description": "Length 配置窗口长度像素值",
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "sumnum": {
+                    "description": "SumNum 文字数量",
+                    "type": "integer"
+                },
+                "textenable": {
+                    "description": "TextEnable 显示文字开关 0关闭 1打开",
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        1
+                    ]
+                },
+                "timeenable": {
+                    "description": "TimeEnable 显示时间开关 0关闭 1打开",
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        1
+                    ]
+                },
+                "timetype": {
+                    "description": "TimeType 时间显示类型 0 YYYY-MM-DD HH:MM:SS 1 YYYY年MM月DD日 HH:MM:SS",
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        1
+                    ]
+                },
+                "timex": {
+                    "description": "TimeX 时间X像素坐标",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "timey": {
+                    "description": "TimeY 时间Y像素坐标",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "width": {
+                    "description": "Width 配置窗口宽度像素值",
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "sipapi.OSDItem": {
+            "type": "object",
+            "properties": {
+                "text": {
+                    "description": "Text 文字内容",
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "x": {
+                    "description": "X 文字X像素坐标",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "y": {
+                    "description": "Y 文字Y像素坐标",
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "sipapi.PTZLock": {
+            "type": "object",
+            "properties": {
+                "channelid": {
+                    "type": "string"
+                },
+                "expire": {
+                    "description": "ExpireAt 锁定到期时间",
+                    "type": "integer"
+                },
+                "moving": {
+                    "description": "Moving 是否处于持续转动中",
+                    "type": "boolean"
+                },
+                "operator": {
+                    "description": "Operator 操作员标识",
+                    "type": "string"
+                },
+                "priority": {
+                    "description": "Priority 控制优先级 1-5，数值越大优先级越高",
+                    "type": "integer"
+                }
+            }
+        },
+        "sipapi.Presets": {
+            "type": "object",
+            "properties": {
+                "addtime": {
+                    "type": "integer"
+                },
+                "channelid": {
+                    "description": "ChannelID 通道编码",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Name 预置位名称",
+                    "type": "string"
+                },
+                "presetid": {
+                    "description": "PresetID 预置位编号 1-255",
+                    "type": "integer"
+                },
+                "uptime": {
+                    "type": "integer"
+                }
+            }
+        },
+        "sipapi.RecordDate": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "description": "日期",
+                    "type": "string"
+                },
+                "items": {
+                    "description": "时间段",
+                    "type": "array",
                     "items": {
-                        "$ref": "#/definitions/sipapi.Streams"
+                        "$ref": "#/definitions/sipapi.RecordInfo"
                     }
+                }
+            }
+        },
+        "sipapi.RecordInfo": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "integer"
                 },
-                "total": {
+                "start": {
                     "type": "integer"
                 }
             }
         },
-        "m.SysInfo": {
+        "sipapi.Records": {
             "type": "object",
             "properties": {
-                "addtime": {
+                "daynum": {
+                    "description": "存在录像的天数",
                     "type": "integer"
                 },
-                "cid": {
-                    "description": "CID 通道id固定头部",
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/sipapi.RecordDate"
+                    }
+                },
+                "timenum": {
+                    "type": "integer"
+                }
+            }
+        },
+        "sipapi.SVACAudioParam": {
+            "type": "object",
+            "properties": {
+                "audiorecognitionflag": {
+                    "description": "AudioRecognitionFlag 声音识别特征参数开关 0关闭 1打开",
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        1
+                    ]
+                }
+            }
+        },
+        "sipapi.SVACDecodeConfig": {
+            "type": "object",
+            "properties": {
+                "surveillanceparam": {
+                    "$ref": "#/definitions/sipapi.SVACSurveillanceParam"
+                },
+                "svcparam": {
+                    "$ref": "#/definitions/sipapi.SVACSVCParam"
+                }
+            }
+        },
+        "sipapi.SVACEncodeConfig": {
+            "type": "object",
+            "properties": {
+                "audioparam": {
+                    "$ref": "#/definitions/sipapi.SVACAudioParam"
+                },
+                "encryptparam": {
+                    "$ref": "#/definitions/sipapi.SVACEncryptParam"
+                },
+                "roiparam": {
+                    "$ref": "#/definitions/sipapi.SVACROIParam"
+                },
+                "surveillanceparam": {
+                    "$ref": "#/definitions/sipapi.SVACSurveillanceParam"
+                },
+                "svcparam": {
+                    "$ref": "#/definitions/sipapi.SVACSVCParam"
+                }
+            }
+        },
+        "sipapi.SVACEncryptParam": {
+            "type": "object",
+            "properties": {
+                "authenticationflag": {
+                    "description": "AuthenticationFlag 认证开关 0关闭 1打开",
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        1
+                    ]
+                },
+                "encryptionflag": {
+                    "description": "EncryptionFlag 加密开关 0关闭 1打开",
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        1
+                    ]
+                }
+            }
+        },
+        "sipapi.SVACROIItem": {
+            "type": "object",
+            "properties": {
+                "bottomright": {
+                    "description": "BottomRight 感兴趣区域右下角坐标",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "roiqp": {
+                    "description": "ROIQP ROI区域编码质量等级 0一般 1较好 2好 3很好",
+                    "type": "integer",
+                    "maximum": 3,
+                    "minimum": 0
+                },
+                "roiseq": {
+                    "description": "ROISeq 感兴趣区域编号 1-16",
+                    "type": "integer",
+                    "maximum": 16,
+                    "minimum": 1
+                },
+                "topleft": {
+                    "description": "TopLeft 感兴趣区域左上角坐标",
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "sipapi.SVACROIParam": {
+            "type": "object",
+            "properties": {
+                "backgroundqp": {
+                    "description": "BackGroundQP 背景区域编码质量等级 0一般 1较好 2好 3很好",
+                    "type": "integer",
+                    "maximum": 3,
+                    "minimum": 0
+                },
+                "backgroundskipflag": {
+                    "description": "BackGroundSkipFlag 背景跳过开关 0关闭 1打开",
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        1
+                    ]
+                },
+                "item": {
+                    "type": "array",
+                    "maxItems": 16,
+                    "items": {
+                        "$ref": "#/definitions/sipapi.SVACROIItem"
+                    }
+                },
+                "roiflag": {
+                    "description": "ROIFlag 感兴趣区域开关 0关闭 1打开",
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        1
+                    ]
+                },
+                "roinumber": {
+                    "description": "ROINumber 感兴趣区域数量 0-16",
+                    "type": "integer",
+                    "maximum": 16,
+                    "minimum": 0
+                }
+            }
+        },
+        "sipapi.SVACSVCParam": {
+            "type": "object",
+            "properties": {
+                "svcspacedomainmode": {
+                    "description": "SVCSpaceDomainMode 空域编码方式 0不使用 1一级增强 2二级增强 3三级增强",
+                    "type": "integer",
+                    "maximum": 3,
+                    "minimum": 0
+                },
+                "svcspacesupportmode": {
+                    "description": "SVCSpaceSupportMode 空域编码能力 解码配置时为SVC流显示模式",
+                    "type": "integer",
+                    "maximum": 3,
+                    "minimum": 0
+                },
+                "svcstmmode": {
+                    "description": "SVCSTMMode 码流显示模式 0基本层 1一级增强层 2二级增强层 3三级增强层，解码配置使用",
+                    "type": "integer",
+                    "maximum": 3,
+                    "minimum": 0
+                },
+                "svctimedomainmode": {
+                    "description": "SVCTimeDomainMode 时域编码方式 0不使用 1一级增强 2二级增强 3三级增强",
+                    "type": "integer",
+                    "maximum": 3,
+                    "minimum": 0
+                },
+                "svctimesupportmode": {
+                    "description": "SVCTimeSupportMode 时域编码能力",
+                    "type": "integer",
+                    "maximum": 3,
+                    "minimum": 0
+                }
+            }
+        },
+        "sipapi.SVACSurveillanceParam": {
+            "type": "object",
+            "properties": {
+                "alershowtflag": {
+                    "description": "AlerShowtFlag 报警信息显示开关，解码配置使用（字段名与标准保持一致）",
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        1
+                    ]
+                },
+                "alertflag": {
+                    "description": "AlertFlag 报警信息开关 0关闭 1打开",
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        1
+                    ]
+                },
+                "eventflag": {
+                    "description": "EventFlag 监控事件信息开关 0关闭 1打开",
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        1
+                    ]
+                },
+                "eventshowflag": {
+                    "description": "EventShowFlag 监控事件信息显示开关，解码配置使用",
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        1
+                    ]
+                },
+                "timeflag": {
+                    "description": "TimeFlag 绝对时间信息开关 0关闭 1打开",
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        1
+                    ]
+                },
+                "timeshowflag": {
+                    "description": "TimeShowFlag 绝对时间信息显示开关，解码配置使用",
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        1
+                    ]
+                }
+            }
+        },
+        "sipapi.SnapshotTask": {
+            "type": "object",
+            "properties": {
+                "channelid": {
+                    "description": "ChannelID 通道编码",
                     "type": "string"
                 },
-                "cnum": {
-                    "description": "CNUM 当前通道数",
+                "interval": {
+                    "description": "Interval 抓拍间隔，单位秒",
                     "type": "integer"
                 },
-                "did": {
-                    "description": "DID 设备id固定头部",
+                "sessionid": {
+                    "description": "SessionID 抓拍会话id",
                     "type": "string"
                 },
-                "dnum": {
-                    "description": "DNUM 当前设备数",
+                "snapnum": {
+                    "description": "SnapNum 抓拍张数",
+                    "type": "integer"
+                }
+            }
+        },
+        "sipapi.Snapshots": {
+            "type": "object",
+            "properties": {
+                "addtime": {
                     "type": "integer"
                 },
+                "channelid": {
+                    "description": "ChannelID 通道编码",
+                    "type": "string"
+                },
+                "deviceid": {
+                    "description": "DeviceID 设备编号",
+                    "type": "string"
+                },
+                "file": {
+                    "description": "File 图片文件相对路径",
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
-                "lid": {
-                    "description": "LID 当前服务id",
+                "sessionid": {
+                    "description": "SessionID 抓拍会话id",
                     "type": "string"
                 },
-                "mediaServer": {
+                "size": {
+                    "description": "Size 图片大小",
+                    "type": "integer"
+                },
+                "uptime": {
+                    "type": "integer"
+                }
+            }
+        },
+        "sipapi.StreamStat": {
+            "type": "object",
+            "properties": {
+                "bytesspeed": {
+                    "description": "收流码率，单位 bytes/s",
+                    "type": "integer"
+                },
+                "fps": {
+                    "description": "视频帧率",
+                    "type": "integer"
+                },
+                "framedelta": {
+                    "description": "距上次采样的视频帧数，媒体服务器未返回帧数时为-1",
+                    "type": "integer"
+                },
+                "frames": {
+                    "description": "视频累计帧数",
+                    "type": "integer"
+                },
+                "loss": {
+                    "description": "视频rtp丢包率 0-1，-1 表示无法统计",
+                    "type": "number"
+                },
+                "peer": {
+                    "description": "设备发送媒体的地址 ip:port",
+                    "type": "string"
+                },
+                "readers": {
+                    "description": "观看人数",
+                    "type": "integer"
+                },
+                "rtpexist": {
+                    "description": "媒体服务器rtp端口是否在收流，拉流通道为false",
                     "type": "boolean"
                 },
-                "region": {
-                    "description": "Region 当前域",
+                "time": {
+                    "type": "integer"
+                }
+            }
+        },
+        "sipapi.StreamStats": {
+            "type": "object",
+            "properties": {
+                "channelid": {
                     "type": "string"
                 },
-                "uptime": {
+                "lastdata": {
+                    "description": "最后一次收到数据的时间",
+                    "type": "integer"
+                },
+                "mediaserverid": {
+                    "type": "string"
+                },
+                "retry": {
+                    "description": "卡死后连续重新点播的次数，恢复收流后清零",
                     "type": "integer"
+                },
+                "samples": {
+                    "description": "采样窗口，按时间正序，最多 stream.stats_window 条",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/sipapi.StreamStat"
+                    }
+                },
+                "stalled": {
+                    "description": "是否卡死，持续没有数据超过 stream.stall_timeout",
+                    "type": "boolean"
+                },
+                "streamid": {
+                    "type": "string"
                 }
             }
         },
-        "sipapi.Channels": {
+        "sipapi.Streams": {
             "type": "object",
             "properties": {
-                "active": {
-                    "description": "Active 最后活跃时间",
+                "addtime": {
+                    "type": "integer"
+                },
+                "callid": {
+                    "description": "header callid",
+                    "type": "string"
+                },
+                "channelid": {
+                    "description": "通道ID",
+                    "type": "string"
+                },
+                "codec": {
+                    "description": "设备应答的视频编码，PS封装时为 PS/实际编码",
+                    "type": "string"
+                },
+                "cseqno": {
                     "type": "integer"
                 },
-                "address": {
-                    "description": "Address ip地址",
+                "deviceid": {
+                    "description": "设备ID",
+                    "type": "string"
+                },
+                "fileid": {
+                    "description": "下载录制文件id，对应Files.FID",
+                    "type": "string"
+                },
+                "flv": {
+                    "description": "http-flv 播放地址",
+                    "type": "string"
+                },
+                "fmp4": {
+                    "description": "http-fmp4 播放地址",
+                    "type": "string"
+                },
+                "hlsfmp4": {
+                    "description": "fmp4 切片的m3u8播放地址",
+                    "type": "string"
+                },
+                "http": {
+                    "description": "m3u8播放地址",
+                    "type": "string"
+                },
+                "https": {
+                    "description": "加密播放地址，媒体节点未配置对应的加密地址时为空",
                     "type": "string"
                 },
-                "addtime": {
+                "httpsflv": {
+                    "type": "string"
+                },
+                "httpsfmp4": {
+                    "type": "string"
+                },
+                "httpshlsfmp4": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "integer"
                 },
-                "channelid": {
-                    "description": "ChannelID 通道编码",
+                "mediaaddr": {
+                    "description": "设备发送媒体的地址 ip:port",
                     "type": "string"
                 },
-                "civilcode": {
+                "mediaformat": {
+                    "description": "设备应答sdp的f字段，媒体参数",
                     "type": "string"
                 },
-                "deviceid": {
-                    "description": "DeviceID 设备编号",
+                "mediaserverid": {
+                    "description": "收流的媒体服务器节点id",
                     "type": "string"
                 },
-                "fps": {
-                    "description": "视频FPS",
-                    "type": "integer"
+                "msg": {
+                    "type": "string"
                 },
-                "height": {
-                    "description": "视频高",
-                    "type": "integer"
+                "paused": {
+                    "description": "回放是否暂停",
+                    "type": "boolean"
                 },
-                "id": {
-                    "type": "integer"
+                "progress": {
+                    "description": "下载进度 0-100",
+                    "type": "number"
                 },
-                "manufacturer": {
+                "proxykey": {
+                    "description": "拉流通道在媒体服务器的拉流代理key",
                     "type": "string"
                 },
-                "memo": {
-                    "description": "Memo 备注（用来标示通道信息）",
+                "rtmp": {
+                    "description": "rtmp 播放地址",
                     "type": "string"
                 },
-                "model": {
+                "rtmps": {
                     "type": "string"
                 },
-                "name": {
-                    "description": "Name 通道名称（设备端设置名称）",
+                "rtsp": {
+                    "description": "rtsp 播放地址",
                     "type": "string"
                 },
-                "owner": {
+                "rtsps": {
                     "type": "string"
                 },
-                "parental": {
-                    "type": "integer"
+                "scale": {
+                    "description": "回放倍速，1为正常速度",
+                    "type": "number"
                 },
-                "registerway": {
-                    "type": "integer"
+                "setup": {
+                    "description": "设备应答的tcp连接方式 active passive",
+                    "type": "string"
                 },
-                "safetyway": {
+                "speed": {
+                    "description": "下载倍速",
                     "type": "integer"
                 },
-                "secrecy": {
-                    "type": "integer"
+                "ssrc": {
+                    "description": "设备实际使用的ssrc，以设备应答sdp的y字段为准",
+                    "type": "string"
                 },
                 "status": {
-                    "description": "Status 状态  on 在线",
+                    "description": "0正常 1关闭 -1 尚未开始",
+                    "type": "integer"
+                },
+                "stop": {
+                    "description": "是否停止",
+                    "type": "boolean"
+                },
+                "stream": {
+                    "description": "zlm是否收到流",
+                    "type": "boolean"
+                },
+                "streamid": {
+                    "description": "视频流ID gb28181的ssrc",
                     "type": "string"
                 },
                 "streamtype": {
                     "description": "pull 媒体服务器主动拉流，push 监控设备主动推流",
                     "type": "string"
                 },
-                "uptime": {
+                "t": {
+                    "description": "0  直播 1 历史 2 下载",
                     "type": "integer"
                 },
-                "uri": {
-                    "type": "string"
-                },
-                "url": {
-                    "description": "streamtype=pull时，拉流地址",
+                "token": {
+                    "description": "播放接口创建的观看会话token，用于心跳和结束观看",
                     "type": "string"
                 },
-                "vf": {
-                    "description": "视频编码格式",
+                "transport": {
+                    "description": "协商后的传输方式 udp/tcp_passive/tcp_active",
                     "type": "string"
                 },
-                "width": {
-                    "description": "视频宽",
-                    "type": "integer"
-                }
-            }
-        },
-        "sipapi.Devices": {
-            "type": "object",
-            "properties": {
-                "active": {
-                    "description": "ActiveAt 最后心跳检测时间",
+                "uptime": {
                     "type": "integer"
                 },
-                "addtime": {
+                "viewers": {
+                    "description": "当前观看会话数量",
                     "type": "integer"
                 },
-                "deviceid": {
-                    "description": "DeviceID 设备id",
+                "webrtc": {
+                    "description": "webrtc 播放地址，zlm webrtc 信令接口",
                     "type": "string"
                 },
-                "devicetype": {
-                    "description": "设备类型DVR，NVR",
+                "webrtcs": {
                     "type": "string"
                 },
-                "firmware": {
-                    "description": "Firmware 固件版本",
+                "wsflv": {
+                    "description": "flv 播放地址",
                     "type": "string"
                 },
-                "host": {
-                    "description": "Host Via 地址",
+                "wsfmp4": {
+                    "description": "ws-fmp4 播放地址",
                     "type": "string"
                 },
-                "id": {
-                    "type": "integer"
-                },
-                "manufacturer": {
-                    "description": "Manufacturer 制造厂商",
+                "wssflv": {
                     "type": "string"
                 },
-                "model": {
-                    "description": "Model 型号",
+                "wssfmp4": {
                     "type": "string"
-                },
-                "name": {
-                    "description": "Name 设备名称",
+                }
+            }
+        },
+        "sipapi.TalkSession": {
+            "type": "object",
+            "properties": {
+                "channelid": {
+                    "description": "ChannelID 通道编码",
                     "type": "string"
                 },
-                "port": {
-                    "description": "Port via 端口",
+                "codec": {
+                    "description": "Codec 音频编码，浏览器推流后以实际推送的音频编码为准",
                     "type": "string"
                 },
-                "proto": {
-                    "description": "Proto 协议",
+                "deviceid": {
+                    "description": "DeviceID 设备编号",
                     "type": "string"
                 },
-                "pwd": {
-                    "description": "PWD 密码",
+                "id": {
+                    "description": "ID 会话id，同时为音频源在 TalkApp 下的stream",
                     "type": "string"
                 },
-                "raddr": {
-                    "description": "RAddr via recevied",
+                "mode": {
+                    "description": "Mode 对讲模式 broadcast/talk",
                     "type": "string"
                 },
-                "region": {
-                    "description": "Region 设备域",
+                "msg": {
+                    "description": "Msg 结束原因",
                     "type": "string"
                 },
-                "regist": {
-                    "description": "Regist 是否注册",
-                    "type": "boolean"
-                },
-                "report": {
-                    "description": "Rport via rport",
+                "playurl": {
+                    "description": "PlayURL 设备音频 WebRTC 播放地址，仅对讲模式有效",
                     "type": "string"
                 },
-                "source": {
-                    "description": "Source",
+                "playurls": {
+                    "description": "PlayURLS 加密的播放地址，媒体节点未配置 https 时为空",
                     "type": "string"
                 },
-                "sysinfo": {
-                    "$ref": "#/definitions/m.SysInfo"
+                "pushurl": {
+                    "description": "PushURL 浏览器麦克风音频 WebRTC 推流地址",
+                    "type": "string"
                 },
-                "transport": {
-                    "description": "TransPort via transport",
+                "pushurls": {
+                    "description": "PushURLS 加密的推流地址，媒体节点未配置 https 时为空",
                     "type": "string"
                 },
-                "uptime": {
+                "start": {
+                    "description": "Start 开始时间",
                     "type": "integer"
                 },
-                "uri": {
+                "status": {
+                    "description": "Status 对讲状态 waiting/connecting/talking/closed",
                     "type": "string"
                 }
             }
         },
-        "sipapi.RecordDate": {
+        "sipapi.UpgradeTasks": {
             "type": "object",
             "properties": {
-                "date": {
-                    "description": "日期",
+                "addtime": {
+                    "type": "integer"
+                },
+                "concurrency": {
+                    "description": "Concurrency 同时升级的设备数",
+                    "type": "integer"
+                },
+                "failed": {
+                    "description": "Failed 升级失败数",
+                    "type": "integer"
+                },
+                "filters": {
+                    "description": "Filters 设备筛选条件",
                     "type": "string"
                 },
-                "items": {
-                    "description": "时间段",
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/sipapi.RecordInfo"
-                    }
-                }
-            }
-        },
-        "sipapi.RecordInfo": {
-            "type": "object",
-            "properties": {
-                "end": {
+                "firmware": {
+                    "description": "Firmware 固件版本",
+                    "type": "string"
+                },
+                "firmwareid": {
+                    "description": "FirmwareID 固件id",
                     "type": "integer"
                 },
-                "start": {
+                "id": {
                     "type": "integer"
-                }
-            }
-        },
-        "sipapi.Records": {
-            "type": "object",
-            "properties": {
-                "daynum": {
-                    "description": "存在录像的天数",
+                },
+                "status": {
+                    "description": "Status 任务状态 running/finished/canceled",
+                    "type": "string"
+                },
+                "success": {
+                    "description": "Success 升级成功数",
                     "type": "integer"
                 },
-                "list": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/sipapi.RecordDate"
-                    }
+                "total": {
+                    "description": "Total 设备总数",
+                    "type": "integer"
                 },
-                "timenum": {
+                "uptime": {
                     "type": "integer"
                 }
             }
         },
-        "sipapi.Streams": {
+        "sipapi.Upgrades": {
             "type": "object",
             "properties": {
                 "addtime": {
                     "type": "integer"
                 },
-                "callid": {
-                    "description": "header callid",
-                    "type": "string"
-                },
-                "channelid": {
-                    "description": "通道ID",
+                "deviceid": {
+                    "description": "DeviceID 设备编号",
                     "type": "string"
                 },
-                "cseqno": {
+                "end": {
+                    "description": "EndAt 结束升级时间",
                     "type": "integer"
                 },
-                "deviceid": {
-                    "description": "设备ID",
-                    "type": "string"
-                },
-                "http": {
-                    "description": "m3u8播放地址",
+                "firmware": {
+                    "description": "Firmware 升级的固件版本",
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "msg": {
+                "reason": {
+                    "description": "Reason 失败原因",
                     "type": "string"
                 },
-                "rtmp": {
-                    "description": "rtmp 播放地址",
+                "sessionid": {
+                    "description": "SessionID 升级会话id",
                     "type": "string"
                 },
-                "rtsp": {
-                    "description": "rtsp 播放地址",
-                    "type": "string"
+                "start": {
+                    "description": "StartAt 开始升级时间",
+                    "type": "integer"
                 },
                 "status": {
-                    "description": "0正常 1关闭 -1 尚未开始",
-                    "type": "integer"
+                    "description": "Status 升级状态 pending/upgrading/success/failed/canceled",
+                    "type": "string"
                 },
-                "stop": {
-                    "description": "是否停止",
-                    "type": "boolean"
+                "taskid": {
+                    "description": "TaskID 升级任务id",
+                    "type": "integer"
                 },
-                "stream": {
-                    "description": "zlm是否收到流",
-                    "type": "boolean"
+                "uptime": {
+                    "type": "integer"
+                }
+            }
+        },
+        "sipapi.VideoParamOpt": {
+            "type": "object",
+            "properties": {
+                "downloadspeed": {
+                    "description": "DownloadSpeed 下载倍速范围，各可选参数以/分隔，如 1/2/4",
+                    "type": "string",
+                    "maxLength": 32
                 },
-                "streamid": {
-                    "description": "视频流ID gb28181的ssrc",
-                    "type": "string"
+                "resolution": {
+                    "description": "Resolution 摄像机支持的分辨率，各可选参数以/分隔，如 5/6",
+                    "type": "string",
+                    "maxLength": 64
+                }
+            }
+        },
+        "sipapi.ViewerSession": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "最后心跳时间",
+                    "type": "integer"
                 },
-                "streamtype": {
-                    "description": "pull 媒体服务器主动拉流，push 监控设备主动推流",
+                "channelid": {
                     "type": "string"
                 },
-                "t": {
-                    "description": "0  直播 1 历史",
+                "created": {
+                    "description": "创建时间",
                     "type": "integer"
                 },
-                "uptime": {
-                    "type": "integer"
+                "streamid": {
+                    "type": "string"
                 },
-                "wsflv": {
-                    "description": "flv 播放地址",
+                "token": {
                     "type": "string"
                 }
             }
@@ -12,19 +12,28 @@ import (
 
 // Config Config
 type Config struct {
-	MOD       string            `json:"mod" yaml:"mod" mapstructure:"mod"`
-	DB        db.Config         `json:"database" yaml:"database" mapstructure:"database"`
-	LogLevel  string            `json:"logger" yaml:"logger" mapstructure:"logger"`
-	UDP       string            `json:"udp" yaml:"udp" mapstructure:"udp"`
-	TCP       string            `json:"tcp" yaml:"tcp" mapstructure:"tcp"`
-	API       string            `json:"api" yaml:"api" mapstructure:"api"`
-	Secret    string            `json:"secret" yaml:"secret" mapstructure:"secret"`
-	Media     MediaServer       `json:"media" yaml:"media" mapstructure:"media"`
-	Stream    Stream            `json:"stream" yaml:"stream" mapstructure:"stream"`
-	Record    RecordCfg         `json:"record" yaml:"record" mapstructure:"record"`
-	GB28181   *SysInfo          `json:"gb28181" yaml:"gb28181" mapstructure:"gb28181"`
-	Notify    map[string]string `json:"notify" yaml:"notify" mapstructure:"notify"`
-	NotifyMap map[string]string
+	MOD      string      `json:"mod" yaml:"mod" mapstructure:"mod"`
+	DB       db.Config   `json:"database" yaml:"database" mapstructure:"database"`
+	LogLevel string      `json:"logger" yaml:"logger" mapstructure:"logger"`
+	UDP      string      `json:"udp" yaml:"udp" mapstructure:"udp"`
+	TCP      string      `json:"tcp" yaml:"tcp" mapstructure:"tcp"`
+	API      string      `json:"api" yaml:"api" mapstructure:"api"`
+	Secret   string      `json:"secret" yaml:"secret" mapstructure:"secret"`
+	Media    MediaServer `json:"media" yaml:"media" mapstructure:"media"`
+	// MediaNodes 除 media 外的其他媒体服务器节点
+	MediaNodes []MediaServer     `json:"media_nodes" yaml:"media_nodes" mapstructure:"media_nodes"`
+	Stream     Stream            `json:"stream" yaml:"stream" mapstructure:"stream"`
+	Record     RecordCfg         `json:"record" yaml:"record" mapstructure:"record"`
+	Snapshot   SnapshotCfg       `json:"snapshot" yaml:"snapshot" mapstructure:"snapshot"`
+	Upgrade    UpgradeCfg        `json:"upgrade" yaml:"upgrade" mapstructure:"upgrade"`
+	Talk       TalkCfg           `json:"talk" yaml:"talk" mapstructure:"talk"`
+	Onvif      OnvifCfg          `json:"onvif" yaml:"onvif" mapstructure:"onvif"`
+	PlayAuth   PlayAuthCfg       `json:"play_auth" yaml:"play_auth" mapstructure:"play_auth"`
+	PTZ        PTZCfg            `json:"ptz" yaml:"ptz" mapstructure:"ptz"`
+	Keepalive  int               `json:"keepalive_timeout" yaml:"keepalive_timeout" mapstructure:"keepalive_timeout"` // 设备心跳超时时间(秒)，设备未上报心跳间隔和次数时使用
+	GB28181    *SysInfo          `json:"gb28181" yaml:"gb28181" mapstructure:"gb28181"`
+	Notify     map[string]string `json:"notify" yaml:"notify" mapstructure:"notify"`
+	NotifyMap  map[string]string
 }
 
 type RecordCfg struct {
@@ -33,21 +42,116 @@ type RecordCfg struct {
 	Recordmax int    `json:"recordmax" yaml:"recordmax"  mapstructure:"recordmax"`
 }
 
+// SnapshotCfg 设备抓拍配置
+type SnapshotCfg struct {
+	// FilePath 抓拍图片保存路径
+	FilePath string `json:"filepath" yaml:"filepath" mapstructure:"filepath"`
+	// UploadURL 设备上传抓拍图片使用的本服务地址，如 http://192.168.1.10:8090
+	UploadURL string `json:"uploadurl" yaml:"uploadurl" mapstructure:"uploadurl"`
+}
+
+// UpgradeCfg 设备固件升级配置
+type UpgradeCfg struct {
+	// FilePath 固件文件保存路径
+	FilePath string `json:"filepath" yaml:"filepath" mapstructure:"filepath"`
+	// FileURL 设备下载固件使用的本服务地址，如 http://192.168.1.10:8090
+	FileURL string `json:"fileurl" yaml:"fileurl" mapstructure:"fileurl"`
+	// Concurrency 默认同时升级的设备数
+	Concurrency int `json:"concurrency" yaml:"concurrency" mapstructure:"concurrency"`
+	// Timeout 单台设备等待升级结果的超时时间，单位分钟
+	Timeout int `json:"timeout" yaml:"timeout" mapstructure:"timeout"`
+}
+
+// TalkCfg 语音对讲配置
+type TalkCfg struct {
+	// Codec 未指定时浏览器推送的默认音频编码 PCMA/PCMU/AAC
+	Codec string `json:"codec" yaml:"codec" mapstructure:"codec"`
+	// IdleTimeout 对讲会话没有音频数据时自动关闭的时间，单位秒
+	IdleTimeout int `json:"idle_timeout" yaml:"idle_timeout" mapstructure:"idle_timeout"`
+}
+
+// PlayAuthCfg 播放地址签名配置
+type PlayAuthCfg struct {
+	// Enable 是否校验播放地址签名，开启后播放地址需携带播放接口返回的签名参数
+	Enable bool `json:"enable" yaml:"enable" mapstructure:"enable"`
+	// Secret 签名密钥，为空时使用 secret
+	Secret string `json:"secret" yaml:"secret" mapstructure:"secret"`
+	// Expire 播放地址有效期，单位秒
+	Expire int `json:"expire" yaml:"expire" mapstructure:"expire"`
+}
+
+// PTZCfg 云台控制配置
+type PTZCfg struct {
+	// Priorities 请求方的云台控制优先级，未配置的请求方使用默认优先级
+	Priorities []PTZPriority `json:"priorities" yaml:"priorities" mapstructure:"priorities"`
+}
+
+// PTZPriority 请求方云台控制优先级
+type PTZPriority struct {
+	// Principal 请求方身份，接口签名鉴权实现前为请求方ip
+	Principal string `json:"principal" yaml:"principal" mapstructure:"principal"`
+	// Priority 控制优先级 1-5，数值越大优先级越高
+	Priority int `json:"priority" yaml:"priority" mapstructure:"priority"`
+}
+
+// OnvifCfg onvif设备发现配置
+type OnvifCfg struct {
+	// Interfaces 发送 WS-Discovery 探测的网卡，为空时由系统选择
+	Interfaces []string `json:"interfaces" yaml:"interfaces" mapstructure:"interfaces"`
+	// Timeout 等待设备应答的时间，单位秒，同时作为请求设备接口的超时时间
+	Timeout int `json:"timeout" yaml:"timeout" mapstructure:"timeout"`
+}
+
 // Stream Stream
 type Stream struct {
-	HLS  bool `json:"hls" yaml:"hls" mapstructure:"hls"`
+	HLS bool `json:"hls" yaml:"hls" mapstructure:"hls"`
+	// RTMP 开启rtmp/rtsp转协议，http-flv、ws-flv、webrtc 依赖此项
 	RTMP bool `json:"rtmp" yaml:"rtmp" mapstructure:"rtmp"`
+	// FMP4 开启 http-fmp4、ws-fmp4
+	FMP4 bool `json:"fmp4" yaml:"fmp4" mapstructure:"fmp4"`
+	// HLSFMP4 开启 fmp4 切片的hls
+	HLSFMP4 bool `json:"hls_fmp4" yaml:"hls_fmp4" mapstructure:"hls_fmp4"`
+	// WebRTC 返回webrtc播放地址
+	WebRTC bool `json:"webrtc" yaml:"webrtc" mapstructure:"webrtc"`
+	// Transport 通道未设置时默认的媒体流传输方式 udp/tcp_passive/tcp_active
+	Transport string `json:"transport" yaml:"transport" mapstructure:"transport"`
+	// Linger 最后一个观看会话结束后流保留的时间，单位秒
+	Linger int `json:"linger" yaml:"linger" mapstructure:"linger"`
+	// ViewerTimeout 观看会话未心跳的超时时间，单位秒
+	ViewerTimeout int `json:"viewer_timeout" yaml:"viewer_timeout" mapstructure:"viewer_timeout"`
+	// StatsWindow 每个流保留的统计采样数量，每5秒采样一次
+	StatsWindow int `json:"stats_window" yaml:"stats_window" mapstructure:"stats_window"`
+	// StallTimeout 直播流持续没有数据超过此时间视为卡死，重新向设备点播，单位秒
+	StallTimeout int `json:"stall_timeout" yaml:"stall_timeout" mapstructure:"stall_timeout"`
+	// StallRetry 卡死后连续重新点播的最大次数，超过后关闭流
+	StallRetry int `json:"stall_retry" yaml:"stall_retry" mapstructure:"stall_retry"`
 }
 
+// DefaultMediaNodeID media 节点未配置id时使用的id
+const DefaultMediaNodeID = "default"
+
 // MediaServer MediaServer
 type MediaServer struct {
+	// ID 节点id，与zlm配置 general.mediaServerId 一致
+	ID string `json:"id" yaml:"id" mapstructure:"id"`
+	// Zone 节点分区，通道设置分区时优先使用同分区的节点
+	Zone    string `json:"zone" yaml:"zone" mapstructure:"zone"`
 	RESTFUL string `json:"restful" yaml:"restful" mapstructure:"restful"`
 	HTTP    string `json:"http" yaml:"http" mapstructure:"http"`
 	WS      string `json:"ws" yaml:"ws" mapstructure:"ws"`
 	RTMP    string `json:"rtmp" yaml:"rtmp" mapstructure:"rtmp"`
 	RTSP    string `json:"rtsp" yaml:"rtsp" mapstructure:"rtsp"`
-	RTP     string `json:"rtp" yaml:"rtp" mapstructure:"rtp"`
-	Secret  string `json:"secret" yaml:"secret" mapstructure:"secret"`
+	// HTTPS、WSS、RTMPS、RTSPS 加密播放地址前缀，为空时不返回对应的加密地址
+	HTTPS string `json:"https" yaml:"https" mapstructure:"https"`
+	WSS   string `json:"wss" yaml:"wss" mapstructure:"wss"`
+	RTMPS string `json:"rtmps" yaml:"rtmps" mapstructure:"rtmps"`
+	RTSPS string `json:"rtsps" yaml:"rtsps" mapstructure:"rtsps"`
+	// PublicHost 播放地址使用的对外域名或ip，替换播放地址前缀中的主机，端口不变
+	PublicHost string `json:"public_host" yaml:"public_host" mapstructure:"public_host"`
+	RTP        string `json:"rtp" yaml:"rtp" mapstructure:"rtp"`
+	Secret     string `json:"secret" yaml:"secret" mapstructure:"secret"`
+	// Timeout 请求media服务器接口的超时时间，单位秒
+	Timeout int `json:"timeout" yaml:"timeout" mapstructure:"timeout"`
 }
 
 type SysInfo struct {
@@ -119,7 +223,8 @@ func LoadConfig() {
 	if MConfig.Notify != nil {
 		for k, v := range MConfig.Notify {
 			if v != "" {
-				notifyMap[strings.ReplaceAll(k, "_", ".")] = v
+				// 只替换第一个下划线，如 records_download_done 对应 records.download_done
+				notifyMap[strings.Replace(k, "_", ".", 1)] = v
 			}
 		}
 	}
@@ -131,4 +236,70 @@ func LoadConfig() {
 	if MConfig.Record.Recordmax <= 0 {
 		MConfig.Record.Recordmax = 600
 	}
+
+	if MConfig.Snapshot.FilePath == "" {
+		MConfig.Snapshot.FilePath = "./snapshots"
+	}
+
+	if MConfig.Upgrade.FilePath == "" {
+		MConfig.Upgrade.FilePath = "./firmwares"
+	}
+	if MConfig.Upgrade.Concurrency <= 0 {
+		MConfig.Upgrade.Concurrency = 5
+	}
+	if MConfig.Upgrade.Timeout <= 0 {
+		MConfig.Upgrade.Timeout = 30
+	}
+
+	if MConfig.Talk.Codec == "" {
+		MConfig.Talk.Codec = "PCMA"
+	}
+	if MConfig.Talk.IdleTimeout <= 0 {
+		MConfig.Talk.IdleTimeout = 60
+	}
+
+	if MConfig.PlayAuth.Secret == "" {
+		MConfig.PlayAuth.Secret = MConfig.Secret
+	}
+	if MConfig.PlayAuth.Expire <= 0 {
+		MConfig.PlayAuth.Expire = 3600
+	}
+
+	if MConfig.Onvif.Timeout <= 0 {
+		MConfig.Onvif.Timeout = 3
+	}
+	if MConfig.Keepalive <= 0 {
+		MConfig.Keepalive = 3 * 60
+	}
+
+	if MConfig.Media.Timeout <= 0 {
+		MConfig.Media.Timeout = 5
+	}
+	if MConfig.Media.ID == "" {
+		MConfig.Media.ID = DefaultMediaNodeID
+	}
+	for i := range MConfig.MediaNodes {
+		if MConfig.MediaNodes[i].Timeout <= 0 {
+			MConfig.MediaNodes[i].Timeout = MConfig.Media.Timeout
+		}
+	}
+
+	if !CheckTransport(MConfig.Stream.Transport) {
+		MConfig.Stream.Transport = TransportTCPPassive
+	}
+	if MConfig.Stream.Linger <= 0 {
+		MConfig.Stream.Linger = 30
+	}
+	if MConfig.Stream.ViewerTimeout <= 0 {
+		MConfig.Stream.ViewerTimeout = 60
+	}
+	if MConfig.Stream.StatsWindow <= 0 {
+		MConfig.Stream.StatsWindow = 60
+	}
+	if MConfig.Stream.StallTimeout <= 0 {
+		MConfig.Stream.StallTimeout = 15
+	}
+	if MConfig.Stream.StallRetry <= 0 {
+		MConfig.Stream.StallRetry = 3
+	}
 }
@@ -16,8 +16,29 @@ const (
 
 	StreamTypePull = "pull"
 	StreamTypePush = "push"
+
+	// 媒体流传输方式
+	// TransportUDP udp传输
+	TransportUDP = "udp"
+	// TransportTCPPassive tcp被动，媒体服务器监听，设备主动连接
+	TransportTCPPassive = "tcp_passive"
+	// TransportTCPActive tcp主动，媒体服务器主动连接设备
+	TransportTCPActive = "tcp_active"
 )
 
+// Transports 支持的传输方式，设备拒绝时按此顺序回退
+var Transports = []string{TransportTCPPassive, TransportUDP, TransportTCPActive}
+
+// CheckTransport 校验传输方式
+func CheckTransport(transport string) bool {
+	for _, v := range Transports {
+		if v == transport {
+			return true
+		}
+	}
+	return false
+}
+
 var CC = map[string]int{
 	StatusSucc:      http.StatusOK,
 	StatusDBERR:     http.StatusServiceUnavailable,
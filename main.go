@@ -70,9 +70,14 @@ func init() {
 }
 
 func _cron() {
-	c := cron.New()                                 // 新建一个定时任务对象
-	c.AddFunc("0 */5 * * * *", sipapi.CheckStreams) // 定时关闭推送流
-	c.AddFunc("0 */5 * * * *", sipapi.ClearFiles)   // 定时清理录制文件
+	c := cron.New()                                       // 新建一个定时任务对象
+	c.AddFunc("0 */5 * * * *", sipapi.CheckStreams)       // 定时关闭推送流
+	c.AddFunc("0 */5 * * * *", sipapi.ClearFiles)         // 定时清理录制文件
+	c.AddFunc("0 */1 * * * *", sipapi.CheckDevices)       // 定时检查设备在线状态
+	c.AddFunc("*/30 * * * * *", sipapi.RefreshMediaNodes) // 定时更新媒体节点负载
+	c.AddFunc("*/10 * * * * *", sipapi.CheckMediaNodes)   // 定时检查媒体节点存活
+	c.AddFunc("*/10 * * * * *", sipapi.CheckViewers)      // 定时清理观看会话及关闭无人观看的流
+	c.AddFunc("*/5 * * * * *", sipapi.CollectStreamStats) // 定时采集流统计及检测卡死的直播流
 	c.Start()
 }
 
@@ -0,0 +1,273 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Fake 内存实现的媒体服务器，用于测试时替代真实的媒体服务器
+// 通过 Publish 模拟推流注册，通过 SetError 模拟接口失败
+type Fake struct {
+	mu       sync.Mutex
+	nextPort int
+	// RtpServers 已开启的rtp收流端口 key=streamid
+	RtpServers map[string]OpenRtpServerReq
+	// Medias 当前存在的流 key=app/stream/schema
+	Medias map[string]MediaInfo
+	// Records 录制中的流 key=app/stream
+	Records map[string]RecordReq
+	// Sends 发送中的rtp流 key=app/stream/ssrc
+	Sends map[string]SendRtpReq
+	// Proxies 拉流代理 key=代理key
+	Proxies map[string]StreamProxyReq
+	// Snap Snapshot 返回的图片
+	Snap []byte
+	// Config GetServerConfig 返回的配置
+	Config map[string]string
+	errs   map[string]error
+}
+
+// NewFake 创建内存媒体服务器，rtp端口从 basePort 开始分配
+func NewFake(basePort int) *Fake {
+	return &Fake{
+		nextPort:   basePort,
+		RtpServers: map[string]OpenRtpServerReq{},
+		Medias:     map[string]MediaInfo{},
+		Records:    map[string]RecordReq{},
+		Sends:      map[string]SendRtpReq{},
+		Proxies:    map[string]StreamProxyReq{},
+		Config:     map[string]string{},
+		errs:       map[string]error{},
+	}
+}
+
+// SetError 指定方法返回错误，err 为 nil 时恢复
+func (f *Fake) SetError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.errs, method)
+		return
+	}
+	f.errs[method] = err
+}
+
+// Publish 模拟流注册
+func (f *Fake) Publish(info MediaInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Medias[info.App+"/"+info.Stream+"/"+info.Schema] = info
+}
+
+func (f *Fake) err(method string) error {
+	return f.errs[method]
+}
+
+func (f *Fake) OpenRtpServer(ctx context.Context, req OpenRtpServerReq) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("OpenRtpServer"); err != nil {
+		return 0, err
+	}
+	if _, ok := f.RtpServers[req.StreamID]; ok {
+		return 0, &Error{API: "openRtpServer", Msg: "stream_id exists", Err: ErrFailed}
+	}
+	if req.Port == 0 {
+		req.Port = f.nextPort
+		f.nextPort++
+	}
+	f.RtpServers[req.StreamID] = req
+	return req.Port, nil
+}
+
+func (f *Fake) CloseRtpServer(ctx context.Context, streamID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("CloseRtpServer"); err != nil {
+		return err
+	}
+	if _, ok := f.RtpServers[streamID]; !ok {
+		return &Error{API: "closeRtpServer", Msg: streamID, Err: ErrNotFound}
+	}
+	delete(f.RtpServers, streamID)
+	return nil
+}
+
+func (f *Fake) UpdateRtpServerSSRC(ctx context.Context, streamID, ssrc string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("UpdateRtpServerSSRC"); err != nil {
+		return err
+	}
+	req, ok := f.RtpServers[streamID]
+	if !ok {
+		return &Error{API: "updateRtpServerSSRC", Msg: streamID, Err: ErrNotFound}
+	}
+	req.SSRC = ssrc
+	f.RtpServers[streamID] = req
+	return nil
+}
+
+func (f *Fake) ConnectRtpServer(ctx context.Context, streamID, dstURL string, dstPort int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("ConnectRtpServer"); err != nil {
+		return err
+	}
+	if req, ok := f.RtpServers[streamID]; !ok || req.TCPMode != TCPModeActive {
+		return &Error{API: "connectRtpServer", Msg: streamID, Err: ErrNotFound}
+	}
+	return nil
+}
+
+func (f *Fake) GetRtpInfo(ctx context.Context, streamID string) (RtpInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("GetRtpInfo"); err != nil {
+		return RtpInfo{}, err
+	}
+	req, ok := f.RtpServers[streamID]
+	if !ok {
+		return RtpInfo{}, nil
+	}
+	for _, m := range f.Medias {
+		if m.Stream == streamID {
+			return RtpInfo{Exist: true, LocalPort: req.Port}, nil
+		}
+	}
+	return RtpInfo{}, nil
+}
+
+func (f *Fake) GetMediaList(ctx context.Context, req MediaListReq) ([]MediaInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("GetMediaList"); err != nil {
+		return nil, err
+	}
+	res := []MediaInfo{}
+	for _, m := range f.Medias {
+		if (req.App == "" || req.App == m.App) && (req.Stream == "" || req.Stream == m.Stream) && (req.Schema == "" || req.Schema == m.Schema) {
+			res = append(res, m)
+		}
+	}
+	return res, nil
+}
+
+func (f *Fake) CloseStreams(ctx context.Context, app, stream string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("CloseStreams"); err != nil {
+		return err
+	}
+	for k, m := range f.Medias {
+		if (app == "" || app == m.App) && stream == m.Stream {
+			delete(f.Medias, k)
+		}
+	}
+	return nil
+}
+
+func (f *Fake) StartRecord(ctx context.Context, req RecordReq) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("StartRecord"); err != nil {
+		return err
+	}
+	f.Records[req.App+"/"+req.Stream] = req
+	return nil
+}
+
+func (f *Fake) StopRecord(ctx context.Context, req RecordReq) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("StopRecord"); err != nil {
+		return err
+	}
+	key := req.App + "/" + req.Stream
+	if _, ok := f.Records[key]; !ok {
+		return &Error{API: "stopRecord", Msg: key, Err: ErrNotFound}
+	}
+	delete(f.Records, key)
+	return nil
+}
+
+func (f *Fake) startSend(method string, req SendRtpReq) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err(method); err != nil {
+		return 0, err
+	}
+	f.Sends[req.App+"/"+req.Stream+"/"+req.SSRC] = req
+	port := f.nextPort
+	f.nextPort++
+	return port, nil
+}
+
+func (f *Fake) StartSendRtp(ctx context.Context, req SendRtpReq) (int, error) {
+	return f.startSend("StartSendRtp", req)
+}
+
+func (f *Fake) StartSendRtpPassive(ctx context.Context, req SendRtpReq) (int, error) {
+	return f.startSend("StartSendRtpPassive", req)
+}
+
+func (f *Fake) StopSendRtp(ctx context.Context, app, stream, ssrc string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("StopSendRtp"); err != nil {
+		return err
+	}
+	for k, req := range f.Sends {
+		if req.App == app && req.Stream == stream && (ssrc == "" || req.SSRC == ssrc) {
+			delete(f.Sends, k)
+		}
+	}
+	return nil
+}
+
+func (f *Fake) Snapshot(ctx context.Context, url string, timeoutSec, expireSec int) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("Snapshot"); err != nil {
+		return nil, err
+	}
+	return f.Snap, nil
+}
+
+func (f *Fake) GetServerConfig(ctx context.Context) (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("GetServerConfig"); err != nil {
+		return nil, err
+	}
+	res := map[string]string{}
+	for k, v := range f.Config {
+		res[k] = v
+	}
+	return res, nil
+}
+
+func (f *Fake) AddStreamProxy(ctx context.Context, req StreamProxyReq) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("AddStreamProxy"); err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("%s/%s/%s", DefaultVhost, req.App, req.Stream)
+	f.Proxies[key] = req
+	return key, nil
+}
+
+func (f *Fake) DelStreamProxy(ctx context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.err("DelStreamProxy"); err != nil {
+		return err
+	}
+	if _, ok := f.Proxies[key]; !ok {
+		return &Error{API: "delStreamProxy", Msg: key, Err: ErrNotFound}
+	}
+	delete(f.Proxies, key)
+	return nil
+}
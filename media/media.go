@@ -0,0 +1,201 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// MediaServer 流媒体服务器驱动，封装收流、转发、录制、截图、拉流代理等操作
+type MediaServer interface {
+	// OpenRtpServer 开启rtp收流端口，返回实际监听的端口
+	OpenRtpServer(ctx context.Context, req OpenRtpServerReq) (int, error)
+	// CloseRtpServer 关闭rtp收流端口
+	CloseRtpServer(ctx context.Context, streamID string) error
+	// UpdateRtpServerSSRC 更新rtp收流端口过滤的ssrc
+	UpdateRtpServerSSRC(ctx context.Context, streamID, ssrc string) error
+	// ConnectRtpServer tcp主动模式下连接对端rtp端口收流
+	ConnectRtpServer(ctx context.Context, streamID, dstURL string, dstPort int) error
+	// GetRtpInfo 获取rtp收流信息
+	GetRtpInfo(ctx context.Context, streamID string) (RtpInfo, error)
+
+	// GetMediaList 获取流列表，参数为空的条件不过滤
+	GetMediaList(ctx context.Context, req MediaListReq) ([]MediaInfo, error)
+	// CloseStreams 关闭流，app为空时关闭所有app下的同名流
+	CloseStreams(ctx context.Context, app, stream string) error
+
+	// StartRecord 开始录制
+	StartRecord(ctx context.Context, req RecordReq) error
+	// StopRecord 停止录制
+	StopRecord(ctx context.Context, req RecordReq) error
+
+	// StartSendRtp 主动向目标地址发送rtp流，返回本地端口
+	StartSendRtp(ctx context.Context, req SendRtpReq) (int, error)
+	// StartSendRtpPassive 被动等待对端连接后发送rtp流，返回本地监听端口
+	StartSendRtpPassive(ctx context.Context, req SendRtpReq) (int, error)
+	// StopSendRtp 停止发送rtp流，ssrc为空时停止该流的所有发送
+	StopSendRtp(ctx context.Context, app, stream, ssrc string) error
+
+	// Snapshot 截取播放地址的图片，返回jpeg数据
+	Snapshot(ctx context.Context, url string, timeoutSec, expireSec int) ([]byte, error)
+
+	// GetServerConfig 获取媒体服务器配置，可用于检查服务是否存活
+	GetServerConfig(ctx context.Context) (map[string]string, error)
+
+	// AddStreamProxy 添加拉流代理，返回代理key
+	AddStreamProxy(ctx context.Context, req StreamProxyReq) (string, error)
+	// DelStreamProxy 删除拉流代理
+	DelStreamProxy(ctx context.Context, key string) error
+}
+
+// DefaultVhost 默认虚拟主机
+const DefaultVhost = "__defaultVhost__"
+
+// rtp 收流 tcp 模式
+const (
+	// TCPModeNone 只开启udp
+	TCPModeNone = 0
+	// TCPModePassive tcp被动，同时开启udp
+	TCPModePassive = 1
+	// TCPModeActive tcp主动
+	TCPModeActive = 2
+)
+
+// OpenRtpServerReq 开启rtp收流端口参数
+type OpenRtpServerReq struct {
+	// Port 为0时由媒体服务器分配
+	Port     int
+	StreamID string
+	TCPMode  int
+	// SSRC 只接收指定ssrc的流，为空时不过滤
+	SSRC string
+}
+
+// RtpInfo rtp收流信息
+type RtpInfo struct {
+	Exist     bool   `json:"exist"`
+	PeerIP    string `json:"peer_ip"`
+	PeerPort  int    `json:"peer_port"`
+	LocalIP   string `json:"local_ip"`
+	LocalPort int    `json:"local_port"`
+}
+
+// MediaListReq 流列表查询条件
+type MediaListReq struct {
+	Vhost  string
+	Schema string
+	App    string
+	Stream string
+}
+
+// MediaInfo 流信息
+type MediaInfo struct {
+	App         string  `json:"app"`
+	Stream      string  `json:"stream"`
+	Schema      string  `json:"schema"`
+	Vhost       string  `json:"vhost"`
+	OriginType  int     `json:"originType"`
+	BytesSpeed  int     `json:"bytesSpeed"`
+	ReaderCount int     `json:"totalReaderCount"`
+	AliveSecond int64   `json:"aliveSecond"`
+	Tracks      []Track `json:"tracks"`
+}
+
+// Track 流的音视频轨道
+type Track struct {
+	// Type 0 视频 1 音频
+	Type    int `json:"codec_type"`
+	CodecID int `json:"codec_id"`
+	Height  int `json:"height"`
+	Width   int `json:"width"`
+	FPS     int `json:"fps"`
+	// Duration 轨道时长，单位毫秒
+	Duration int64 `json:"duration"`
+	// Frames 累计帧数
+	Frames int64 `json:"frames"`
+	// Loss rtp丢包率，0-1，-1 表示无法统计
+	Loss float64 `json:"loss"`
+}
+
+// 录制类型
+const (
+	RecordTypeHLS = 0
+	RecordTypeMP4 = 1
+)
+
+// RecordReq 录制参数
+type RecordReq struct {
+	Type   int
+	Vhost  string
+	App    string
+	Stream string
+	// MaxSecond mp4切片时长，单位秒，0使用媒体服务器配置
+	MaxSecond int64
+}
+
+// SendRtpReq 发送rtp流参数
+type SendRtpReq struct {
+	Vhost  string
+	App    string
+	Stream string
+	SSRC   string
+	// DstURL DstPort 主动发送时的目标地址
+	DstURL  string
+	DstPort int
+	IsUDP   bool
+	// PT rtp payload type，为空时使用媒体服务器默认值
+	PT string
+	// UsePS 是否使用ps封装，false 直接发送es流
+	UsePS bool
+	// OnlyAudio 是否只发送音频
+	OnlyAudio bool
+	// RecvStreamID 被动发送的同时接收对端的rtp流，为空时不接收
+	RecvStreamID string
+}
+
+// StreamProxyReq 拉流代理参数
+type StreamProxyReq struct {
+	Vhost  string
+	App    string
+	Stream string
+	URL    string
+	// RetryCount 拉流失败重试次数，-1 无限重试
+	RetryCount int
+	// RTPType rtsp拉流方式 0 tcp 1 udp 2 组播
+	RTPType int
+	// TimeoutSec 拉流超时时间，单位秒
+	TimeoutSec int
+}
+
+var (
+	// ErrUnavailable 媒体服务器无法访问
+	ErrUnavailable = errors.New("媒体服务器无法访问")
+	// ErrTimeout 请求媒体服务器超时
+	ErrTimeout = errors.New("媒体服务器请求超时")
+	// ErrAuth 媒体服务器鉴权失败
+	ErrAuth = errors.New("媒体服务器鉴权失败")
+	// ErrInvalidArgs 请求参数错误
+	ErrInvalidArgs = errors.New("媒体服务器参数错误")
+	// ErrNotFound 流、端口或代理不存在
+	ErrNotFound = errors.New("媒体服务器资源不存在")
+	// ErrFailed 媒体服务器执行失败
+	ErrFailed = errors.New("媒体服务器执行失败")
+)
+
+// Error 媒体服务器接口错误，可使用 errors.Is 判断错误类型
+type Error struct {
+	// API 请求的接口
+	API string
+	// Code 媒体服务器返回的错误码
+	Code int
+	Msg  string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %s:%s(code:%d)", e.API, e.Err, e.Msg, e.Code)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
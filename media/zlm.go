@@ -0,0 +1,356 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// zlm 接口返回码
+const (
+	zlmCodeSuccess     = 0
+	zlmCodeOtherFailed = -1
+	zlmCodeAuthFailed  = -100
+	zlmCodeInvalidArgs = -300
+	zlmCodeNotFound    = -500
+)
+
+// ZLM ZLMediaKit 驱动
+type ZLM struct {
+	// URL restful 接口地址
+	URL    string
+	Secret string
+	// Timeout ctx 未设置截止时间时单次请求的超时时间
+	Timeout time.Duration
+	client  *http.Client
+}
+
+// NewZLM 创建 ZLMediaKit 驱动
+func NewZLM(restful, secret string, timeout time.Duration) *ZLM {
+	return &ZLM{
+		URL:     strings.TrimRight(restful, "/"),
+		Secret:  secret,
+		Timeout: timeout,
+		client:  &http.Client{},
+	}
+}
+
+type zlmResp struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// 请求zlm接口，检查返回码后将结果解析到 out
+func (z *ZLM) call(ctx context.Context, api string, params url.Values, out any) error {
+	body, err := z.get(ctx, api, params)
+	if err != nil {
+		return err
+	}
+	res := zlmResp{}
+	if err = json.Unmarshal(body, &res); err != nil {
+		return &Error{API: api, Msg: err.Error(), Err: ErrFailed}
+	}
+	if res.Code != zlmCodeSuccess {
+		return zlmError(api, res.Code, res.Msg)
+	}
+	if out != nil {
+		if err = json.Unmarshal(body, out); err != nil {
+			return &Error{API: api, Msg: err.Error(), Err: ErrFailed}
+		}
+	}
+	logrus.Traceln("zlm", api, "success", string(body))
+	return nil
+}
+
+func (z *ZLM) get(ctx context.Context, api string, params url.Values) ([]byte, error) {
+	if _, ok := ctx.Deadline(); !ok && z.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, z.Timeout)
+		defer cancel()
+	}
+	params.Set("secret", z.Secret)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, z.URL+"/index/api/"+api+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, &Error{API: api, Msg: err.Error(), Err: ErrInvalidArgs}
+	}
+	resp, err := z.client.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, &Error{API: api, Msg: err.Error(), Err: ErrTimeout}
+		}
+		return nil, &Error{API: api, Msg: err.Error(), Err: ErrUnavailable}
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &Error{API: api, Msg: err.Error(), Err: ErrUnavailable}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &Error{API: api, Code: resp.StatusCode, Msg: string(body), Err: ErrUnavailable}
+	}
+	return body, nil
+}
+
+func zlmError(api string, code int, msg string) error {
+	e := &Error{API: api, Code: code, Msg: msg, Err: ErrFailed}
+	switch code {
+	case zlmCodeAuthFailed:
+		e.Err = ErrAuth
+	case zlmCodeInvalidArgs:
+		e.Err = ErrInvalidArgs
+	case zlmCodeNotFound:
+		e.Err = ErrNotFound
+	}
+	return e
+}
+
+func boolParam(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
+
+func (z *ZLM) OpenRtpServer(ctx context.Context, req OpenRtpServerReq) (int, error) {
+	params := url.Values{}
+	params.Set("port", strconv.Itoa(req.Port))
+	params.Set("stream_id", req.StreamID)
+	params.Set("tcp_mode", strconv.Itoa(req.TCPMode))
+	if req.SSRC != "" {
+		params.Set("ssrc", req.SSRC)
+	}
+	res := struct {
+		Port int `json:"port"`
+	}{}
+	if err := z.call(ctx, "openRtpServer", params, &res); err != nil {
+		return 0, err
+	}
+	return res.Port, nil
+}
+
+func (z *ZLM) CloseRtpServer(ctx context.Context, streamID string) error {
+	params := url.Values{}
+	params.Set("stream_id", streamID)
+	res := struct {
+		Hit int `json:"hit"`
+	}{}
+	if err := z.call(ctx, "closeRtpServer", params, &res); err != nil {
+		return err
+	}
+	if res.Hit == 0 {
+		return &Error{API: "closeRtpServer", Msg: streamID, Err: ErrNotFound}
+	}
+	return nil
+}
+
+func (z *ZLM) UpdateRtpServerSSRC(ctx context.Context, streamID, ssrc string) error {
+	params := url.Values{}
+	params.Set("stream_id", streamID)
+	params.Set("ssrc", ssrc)
+	return z.call(ctx, "updateRtpServerSSRC", params, nil)
+}
+
+func (z *ZLM) ConnectRtpServer(ctx context.Context, streamID, dstURL string, dstPort int) error {
+	params := url.Values{}
+	params.Set("stream_id", streamID)
+	params.Set("dst_url", dstURL)
+	params.Set("dst_port", strconv.Itoa(dstPort))
+	return z.call(ctx, "connectRtpServer", params, nil)
+}
+
+func (z *ZLM) GetRtpInfo(ctx context.Context, streamID string) (RtpInfo, error) {
+	params := url.Values{}
+	params.Set("stream_id", streamID)
+	res := RtpInfo{}
+	err := z.call(ctx, "getRtpInfo", params, &res)
+	return res, err
+}
+
+func (z *ZLM) GetMediaList(ctx context.Context, req MediaListReq) ([]MediaInfo, error) {
+	params := url.Values{}
+	if req.Vhost != "" {
+		params.Set("vhost", req.Vhost)
+	}
+	if req.Schema != "" {
+		params.Set("schema", req.Schema)
+	}
+	if req.App != "" {
+		params.Set("app", req.App)
+	}
+	if req.Stream != "" {
+		params.Set("stream", req.Stream)
+	}
+	res := struct {
+		Data []MediaInfo `json:"data"`
+	}{}
+	err := z.call(ctx, "getMediaList", params, &res)
+	return res.Data, err
+}
+
+func (z *ZLM) CloseStreams(ctx context.Context, app, stream string) error {
+	params := url.Values{}
+	if app != "" {
+		params.Set("app", app)
+	}
+	params.Set("stream", stream)
+	params.Set("force", "1")
+	return z.call(ctx, "close_streams", params, nil)
+}
+
+func recordParams(req RecordReq) url.Values {
+	params := url.Values{}
+	params.Set("type", strconv.Itoa(req.Type))
+	params.Set("vhost", req.Vhost)
+	if req.Vhost == "" {
+		params.Set("vhost", DefaultVhost)
+	}
+	params.Set("app", req.App)
+	params.Set("stream", req.Stream)
+	return params
+}
+
+func (z *ZLM) StartRecord(ctx context.Context, req RecordReq) error {
+	params := recordParams(req)
+	if req.MaxSecond > 0 {
+		params.Set("max_second", strconv.FormatInt(req.MaxSecond, 10))
+	}
+	return z.call(ctx, "startRecord", params, nil)
+}
+
+func (z *ZLM) StopRecord(ctx context.Context, req RecordReq) error {
+	return z.call(ctx, "stopRecord", recordParams(req), nil)
+}
+
+func sendRtpParams(req SendRtpReq) url.Values {
+	params := url.Values{}
+	params.Set("vhost", req.Vhost)
+	if req.Vhost == "" {
+		params.Set("vhost", DefaultVhost)
+	}
+	params.Set("app", req.App)
+	params.Set("stream", req.Stream)
+	params.Set("ssrc", req.SSRC)
+	if req.PT != "" {
+		params.Set("pt", req.PT)
+	}
+	params.Set("use_ps", boolParam(req.UsePS))
+	params.Set("only_audio", boolParam(req.OnlyAudio))
+	return params
+}
+
+type sendRtpResp struct {
+	LocalPort int `json:"local_port"`
+}
+
+func (z *ZLM) StartSendRtp(ctx context.Context, req SendRtpReq) (int, error) {
+	params := sendRtpParams(req)
+	params.Set("dst_url", req.DstURL)
+	params.Set("dst_port", strconv.Itoa(req.DstPort))
+	params.Set("is_udp", boolParam(req.IsUDP))
+	res := sendRtpResp{}
+	err := z.call(ctx, "startSendRtp", params, &res)
+	return res.LocalPort, err
+}
+
+func (z *ZLM) StartSendRtpPassive(ctx context.Context, req SendRtpReq) (int, error) {
+	params := sendRtpParams(req)
+	if req.RecvStreamID != "" {
+		params.Set("recv_stream_id", req.RecvStreamID)
+	}
+	res := sendRtpResp{}
+	err := z.call(ctx, "startSendRtpPassive", params, &res)
+	return res.LocalPort, err
+}
+
+func (z *ZLM) StopSendRtp(ctx context.Context, app, stream, ssrc string) error {
+	params := url.Values{}
+	params.Set("vhost", DefaultVhost)
+	params.Set("app", app)
+	params.Set("stream", stream)
+	if ssrc != "" {
+		params.Set("ssrc", ssrc)
+	}
+	return z.call(ctx, "stopSendRtp", params, nil)
+}
+
+func (z *ZLM) Snapshot(ctx context.Context, playURL string, timeoutSec, expireSec int) ([]byte, error) {
+	params := url.Values{}
+	params.Set("url", playURL)
+	params.Set("timeout_sec", strconv.Itoa(timeoutSec))
+	params.Set("expire_sec", strconv.Itoa(expireSec))
+	body, err := z.get(ctx, "getSnap", params)
+	if err != nil {
+		return nil, err
+	}
+	// 截图失败时返回json
+	if len(body) > 0 && body[0] == '{' {
+		res := zlmResp{}
+		if err := json.Unmarshal(body, &res); err == nil && res.Code != zlmCodeSuccess {
+			return nil, zlmError("getSnap", res.Code, res.Msg)
+		}
+	}
+	return body, nil
+}
+
+func (z *ZLM) GetServerConfig(ctx context.Context) (map[string]string, error) {
+	res := struct {
+		Data []map[string]string `json:"data"`
+	}{}
+	if err := z.call(ctx, "getServerConfig", url.Values{}, &res); err != nil {
+		return nil, err
+	}
+	if len(res.Data) == 0 {
+		return map[string]string{}, nil
+	}
+	return res.Data[0], nil
+}
+
+func (z *ZLM) AddStreamProxy(ctx context.Context, req StreamProxyReq) (string, error) {
+	params := url.Values{}
+	params.Set("vhost", req.Vhost)
+	if req.Vhost == "" {
+		params.Set("vhost", DefaultVhost)
+	}
+	params.Set("app", req.App)
+	params.Set("stream", req.Stream)
+	params.Set("url", req.URL)
+	params.Set("retry_count", strconv.Itoa(req.RetryCount))
+	params.Set("rtp_type", strconv.Itoa(req.RTPType))
+	if req.TimeoutSec > 0 {
+		params.Set("timeout_sec", strconv.Itoa(req.TimeoutSec))
+	}
+	res := struct {
+		Data struct {
+			Key string `json:"key"`
+		} `json:"data"`
+	}{}
+	if err := z.call(ctx, "addStreamProxy", params, &res); err != nil {
+		return "", err
+	}
+	return res.Data.Key, nil
+}
+
+func (z *ZLM) DelStreamProxy(ctx context.Context, key string) error {
+	params := url.Values{}
+	params.Set("key", key)
+	res := struct {
+		Data struct {
+			Flag bool `json:"flag"`
+		} `json:"data"`
+	}{}
+	if err := z.call(ctx, "delStreamProxy", params, &res); err != nil {
+		return err
+	}
+	if !res.Data.Flag {
+		return &Error{API: "delStreamProxy", Msg: key, Err: ErrNotFound}
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package onvif
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUnavailable 设备无法访问
+	ErrUnavailable = errors.New("onvif设备无法访问")
+	// ErrAuth 账号密码错误
+	ErrAuth = errors.New("onvif设备认证失败")
+	// ErrFault 设备返回错误
+	ErrFault = errors.New("onvif设备返回错误")
+)
+
+// Error 请求onvif设备的错误
+type Error struct {
+	Action string
+	Msg    string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("onvif %s: %v: %s", e.Action, e.Err, e.Msg)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Client onvif 设备客户端，使用 WS-Security UsernameToken 认证
+type Client struct {
+	// XAddr 设备服务地址，如 http://192.168.1.64/onvif/device_service
+	XAddr    string
+	Username string
+	Password string
+	client   *http.Client
+}
+
+// NewClient 创建onvif客户端
+func NewClient(xaddr, username, password string, timeout time.Duration) *Client {
+	return &Client{
+		XAddr:    xaddr,
+		Username: username,
+		Password: password,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// DeviceInfo 设备信息
+type DeviceInfo struct {
+	Manufacturer    string `xml:"Manufacturer" json:"manufacturer"`
+	Model           string `xml:"Model" json:"model"`
+	FirmwareVersion string `xml:"FirmwareVersion" json:"firmware"`
+	SerialNumber    string `xml:"SerialNumber" json:"serial"`
+	HardwareID      string `xml:"HardwareId" json:"hardware"`
+}
+
+// Profile 媒体配置
+type Profile struct {
+	Token string `xml:"token,attr" json:"token"`
+	Name  string `xml:"Name" json:"name"`
+	// Encoding 视频编码 H264/H265/JPEG
+	Encoding string `xml:"VideoEncoderConfiguration>Encoding" json:"encoding"`
+	Width    int    `xml:"VideoEncoderConfiguration>Resolution>Width" json:"width"`
+	Height   int    `xml:"VideoEncoderConfiguration>Resolution>Height" json:"height"`
+	FPS      int    `xml:"VideoEncoderConfiguration>RateControl>FrameRateLimit" json:"fps"`
+}
+
+const envelopeTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">
+<s:Header>%s</s:Header>
+<s:Body>%s</s:Body>
+</s:Envelope>`
+
+const securityTemplate = `<Security s:mustUnderstand="1" xmlns="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd">
+<UsernameToken>
+<Username>%s</Username>
+<Password Type="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordDigest">%s</Password>
+<Nonce EncodingType="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-soap-message-security-1.0#Base64Binary">%s</Nonce>
+<Created xmlns="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd">%s</Created>
+</UsernameToken>
+</Security>`
+
+type soapEnvelope struct {
+	Body struct {
+		Fault *struct {
+			Code    string `xml:"Code>Value"`
+			Subcode string `xml:"Code>Subcode>Value"`
+			Reason  string `xml:"Reason>Text"`
+		} `xml:"Fault"`
+		Inner []byte `xml:",innerxml"`
+	} `xml:"Body"`
+}
+
+func escape(s string) string {
+	b := bytes.Buffer{}
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// 认证头 PasswordDigest = Base64(SHA1(nonce + created + password))
+func (c *Client) security() string {
+	if c.Username == "" {
+		return ""
+	}
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	created := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	h := sha1.New()
+	h.Write(nonce)
+	h.Write([]byte(created))
+	h.Write([]byte(c.Password))
+	digest := base64.StdEncoding.EncodeToString(h.Sum(nil))
+	return fmt.Sprintf(securityTemplate, escape(c.Username), digest, base64.StdEncoding.EncodeToString(nonce), created)
+}
+
+// 发送soap请求，将 Body 下的应答元素解析到 out
+func (c *Client) call(ctx context.Context, xaddr, action, body string, out any) error {
+	data := fmt.Sprintf(envelopeTemplate, c.security(), body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, xaddr, strings.NewReader(data))
+	if err != nil {
+		return &Error{Action: action, Msg: err.Error(), Err: ErrUnavailable}
+	}
+	req.Header.Set("Content-Type", "application/soap+xml; charset=utf-8")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return &Error{Action: action, Msg: err.Error(), Err: ErrUnavailable}
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &Error{Action: action, Msg: err.Error(), Err: ErrUnavailable}
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return &Error{Action: action, Msg: resp.Status, Err: ErrAuth}
+	}
+	env := soapEnvelope{}
+	if err := xml.Unmarshal(raw, &env); err != nil {
+		return &Error{Action: action, Msg: fmt.Sprintf("http %d:%v", resp.StatusCode, err), Err: ErrFault}
+	}
+	if f := env.Body.Fault; f != nil {
+		e := &Error{Action: action, Msg: strings.TrimSpace(f.Subcode + " " + f.Reason), Err: ErrFault}
+		if strings.Contains(f.Subcode, "NotAuthorized") || strings.Contains(f.Subcode, "FailedAuthentication") {
+			e.Err = ErrAuth
+		}
+		return e
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &Error{Action: action, Msg: resp.Status, Err: ErrFault}
+	}
+	if out != nil {
+		if err := xml.Unmarshal(env.Body.Inner, out); err != nil {
+			return &Error{Action: action, Msg: err.Error(), Err: ErrFault}
+		}
+	}
+	return nil
+}
+
+// GetDeviceInformation 获取设备厂商、型号等信息
+func (c *Client) GetDeviceInformation(ctx context.Context) (DeviceInfo, error) {
+	res := DeviceInfo{}
+	err := c.call(ctx, c.XAddr, "GetDeviceInformation", `<GetDeviceInformation xmlns="http://www.onvif.org/ver10/device/wsdl"/>`, &res)
+	return res, err
+}
+
+// MediaXAddr 获取媒体服务地址，设备未返回时使用设备服务地址
+func (c *Client) MediaXAddr(ctx context.Context) (string, error) {
+	res := struct {
+		XAddr string `xml:"Capabilities>Media>XAddr"`
+	}{}
+	err := c.call(ctx, c.XAddr, "GetCapabilities", `<GetCapabilities xmlns="http://www.onvif.org/ver10/device/wsdl"><Category>Media</Category></GetCapabilities>`, &res)
+	if err != nil {
+		return "", err
+	}
+	if res.XAddr == "" {
+		return c.XAddr, nil
+	}
+	return strings.TrimSpace(res.XAddr), nil
+}
+
+// GetProfiles 获取媒体配置列表
+func (c *Client) GetProfiles(ctx context.Context, mediaXAddr string) ([]Profile, error) {
+	res := struct {
+		Profiles []Profile `xml:"Profiles"`
+	}{}
+	err := c.call(ctx, mediaXAddr, "GetProfiles", `<GetProfiles xmlns="http://www.onvif.org/ver10/media/wsdl"/>`, &res)
+	return res.Profiles, err
+}
+
+// GetStreamUri 获取媒体配置的 rtsp 单播地址
+func (c *Client) GetStreamUri(ctx context.Context, mediaXAddr, profileToken string) (string, error) {
+	body := fmt.Sprintf(`<GetStreamUri xmlns="http://www.onvif.org/ver10/media/wsdl">
+<StreamSetup>
+<Stream xmlns="http://www.onvif.org/ver10/schema">RTP-Unicast</Stream>
+<Transport xmlns="http://www.onvif.org/ver10/schema"><Protocol>RTSP</Protocol></Transport>
+</StreamSetup>
+<ProfileToken>%s</ProfileToken>
+</GetStreamUri>`, escape(profileToken))
+	res := struct {
+		URI string `xml:"MediaUri>Uri"`
+	}{}
+	if err := c.call(ctx, mediaXAddr, "GetStreamUri", body, &res); err != nil {
+		return "", err
+	}
+	if res.URI == "" {
+		return "", &Error{Action: "GetStreamUri", Msg: "empty uri", Err: ErrFault}
+	}
+	return strings.TrimSpace(res.URI), nil
+}
@@ -0,0 +1,179 @@
+package onvif
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testEnvelope = `<?xml version="1.0" encoding="UTF-8"?>
+<SOAP-ENV:Envelope xmlns:SOAP-ENV="http://www.w3.org/2003/05/soap-envelope" xmlns:tds="http://www.onvif.org/ver10/device/wsdl" xmlns:trt="http://www.onvif.org/ver10/media/wsdl" xmlns:tt="http://www.onvif.org/ver10/schema">
+<SOAP-ENV:Body>%s</SOAP-ENV:Body>
+</SOAP-ENV:Envelope>`
+
+const testAuthFault = `<SOAP-ENV:Fault>
+<SOAP-ENV:Code><SOAP-ENV:Value>SOAP-ENV:Sender</SOAP-ENV:Value>
+<SOAP-ENV:Subcode><SOAP-ENV:Value>ter:NotAuthorized</SOAP-ENV:Value></SOAP-ENV:Subcode></SOAP-ENV:Code>
+<SOAP-ENV:Reason><SOAP-ENV:Text xml:lang="en">Sender not Authorized</SOAP-ENV:Text></SOAP-ENV:Reason>
+</SOAP-ENV:Fault>`
+
+var testResponses = map[string]string{
+	"GetDeviceInformation": `<tds:GetDeviceInformationResponse>
+<tds:Manufacturer>HIKVISION</tds:Manufacturer><tds:Model>DS-2CD2T47</tds:Model>
+<tds:FirmwareVersion>V5.5.0</tds:FirmwareVersion><tds:SerialNumber>SN001</tds:SerialNumber><tds:HardwareId>88</tds:HardwareId>
+</tds:GetDeviceInformationResponse>`,
+	"GetCapabilities": `<tds:GetCapabilitiesResponse><tds:Capabilities>
+<tt:Media><tt:XAddr>%s/onvif/media_service</tt:XAddr></tt:Media>
+</tds:Capabilities></tds:GetCapabilitiesResponse>`,
+	"GetProfiles": `<trt:GetProfilesResponse>
+<trt:Profiles token="Profile_1"><tt:Name>mainStream</tt:Name>
+<tt:VideoEncoderConfiguration token="VE1"><tt:Encoding>H264</tt:Encoding>
+<tt:Resolution><tt:Width>2560</tt:Width><tt:Height>1440</tt:Height></tt:Resolution>
+<tt:RateControl><tt:FrameRateLimit>25</tt:FrameRateLimit></tt:RateControl></tt:VideoEncoderConfiguration>
+</trt:Profiles>
+<trt:Profiles token="Profile_2"><tt:Name>subStream</tt:Name>
+<tt:VideoEncoderConfiguration token="VE2"><tt:Encoding>H265</tt:Encoding>
+<tt:Resolution><tt:Width>640</tt:Width><tt:Height>360</tt:Height></tt:Resolution>
+<tt:RateControl><tt:FrameRateLimit>15</tt:FrameRateLimit></tt:RateControl></tt:VideoEncoderConfiguration>
+</trt:Profiles>
+</trt:GetProfilesResponse>`,
+	"GetStreamUri": `<trt:GetStreamUriResponse><trt:MediaUri>
+<tt:Uri>rtsp://192.168.1.64:554/Streaming/Channels/%s</tt:Uri>
+</trt:MediaUri></trt:GetStreamUriResponse>`,
+}
+
+type testRequest struct {
+	Header struct {
+		Security struct {
+			Username string `xml:"UsernameToken>Username"`
+			Password string `xml:"UsernameToken>Password"`
+			Nonce    string `xml:"UsernameToken>Nonce"`
+			Created  string `xml:"UsernameToken>Created"`
+		} `xml:"Security"`
+	} `xml:"Header"`
+	Body struct {
+		Inner []byte `xml:",innerxml"`
+	} `xml:"Body"`
+}
+
+// 模拟onvif设备，校验 PasswordDigest 认证
+func testDevice(t *testing.T, username, password string) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		req := testRequest{}
+		if err := xml.Unmarshal(data, &req); err != nil {
+			t.Errorf("invalid soap request: %v\n%s", err, data)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		sec := req.Header.Security
+		nonce, _ := base64.StdEncoding.DecodeString(sec.Nonce)
+		h := sha1.New()
+		h.Write(nonce)
+		h.Write([]byte(sec.Created))
+		h.Write([]byte(password))
+		w.Header().Set("Content-Type", "application/soap+xml; charset=utf-8")
+		if sec.Username != username || sec.Password != base64.StdEncoding.EncodeToString(h.Sum(nil)) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, testEnvelope, testAuthFault)
+			return
+		}
+		body := string(req.Body.Inner)
+		for action, resp := range testResponses {
+			if !strings.Contains(body, "<"+action) {
+				continue
+			}
+			switch action {
+			case "GetCapabilities":
+				resp = fmt.Sprintf(resp, srv.URL)
+			case "GetStreamUri":
+				token := "101"
+				if strings.Contains(body, "Profile_2") {
+					token = "102"
+				}
+				resp = fmt.Sprintf(resp, token)
+			}
+			fmt.Fprintf(w, testEnvelope, resp)
+			return
+		}
+		t.Errorf("unexpected request: %s", body)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient(t *testing.T) {
+	srv := testDevice(t, "admin<&>", "12345")
+	c := NewClient(srv.URL+"/onvif/device_service", "admin<&>", "12345", time.Second)
+	ctx := context.Background()
+
+	info, err := c.GetDeviceInformation(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Manufacturer != "HIKVISION" || info.Model != "DS-2CD2T47" || info.FirmwareVersion != "V5.5.0" {
+		t.Fatalf("device info = %+v", info)
+	}
+
+	mediaXAddr, err := c.MediaXAddr(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mediaXAddr != srv.URL+"/onvif/media_service" {
+		t.Fatalf("media xaddr = %s", mediaXAddr)
+	}
+
+	profiles, err := c.GetProfiles(ctx, mediaXAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Profile{
+		{Token: "Profile_1", Name: "mainStream", Encoding: "H264", Width: 2560, Height: 1440, FPS: 25},
+		{Token: "Profile_2", Name: "subStream", Encoding: "H265", Width: 640, Height: 360, FPS: 15},
+	}
+	if fmt.Sprint(profiles) != fmt.Sprint(want) {
+		t.Fatalf("profiles = %+v, want %+v", profiles, want)
+	}
+
+	uri, err := c.GetStreamUri(ctx, mediaXAddr, "Profile_2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri != "rtsp://192.168.1.64:554/Streaming/Channels/102" {
+		t.Fatalf("stream uri = %s", uri)
+	}
+}
+
+func TestClientAuthFail(t *testing.T) {
+	srv := testDevice(t, "admin", "12345")
+	c := NewClient(srv.URL+"/onvif/device_service", "admin", "wrong", time.Second)
+	_, err := c.GetDeviceInformation(context.Background())
+	if !errors.Is(err, ErrAuth) {
+		t.Fatalf("err = %v, want ErrAuth", err)
+	}
+	e := &Error{}
+	if !errors.As(err, &e) || e.Action != "GetDeviceInformation" {
+		t.Fatalf("err = %#v", err)
+	}
+}
+
+func TestClientUnavailable(t *testing.T) {
+	srv := testDevice(t, "admin", "12345")
+	srv.Close()
+	c := NewClient(srv.URL+"/onvif/device_service", "admin", "12345", time.Second)
+	if _, err := c.GetDeviceInformation(context.Background()); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("err = %v, want ErrUnavailable", err)
+	}
+}
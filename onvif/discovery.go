@@ -0,0 +1,201 @@
+package onvif
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// DiscoveryAddr WS-Discovery 组播地址
+const DiscoveryAddr = "239.255.255.250:3702"
+
+// Device WS-Discovery 发现的设备
+type Device struct {
+	// UUID 设备端点地址，如 urn:uuid:xxx
+	UUID string `json:"uuid"`
+	// XAddrs 设备服务地址
+	XAddrs []string `json:"xaddrs"`
+	// IP 设备服务地址中的ip
+	IP string `json:"ip"`
+	// Types 设备类型
+	Types  string   `json:"types"`
+	Scopes []string `json:"scopes"`
+	// Name 设备名称，取自 scope onvif://www.onvif.org/name/
+	Name string `json:"name"`
+	// Hardware 设备型号，取自 scope onvif://www.onvif.org/hardware/
+	Hardware string `json:"hardware"`
+	// Location 设备位置，取自 scope onvif://www.onvif.org/location/
+	Location string `json:"location"`
+}
+
+const probeTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<e:Envelope xmlns:e="http://www.w3.org/2003/05/soap-envelope" xmlns:w="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:d="http://schemas.xmlsoap.org/ws/2005/04/discovery" xmlns:dn="http://www.onvif.org/ver10/network/wsdl">
+<e:Header>
+<w:MessageID>uuid:%s</w:MessageID>
+<w:To e:mustUnderstand="true">urn:schemas-xmlsoap-org:ws:2005:04:discovery</w:To>
+<w:Action e:mustUnderstand="true">http://schemas.xmlsoap.org/ws/2005/04/discovery/Probe</w:Action>
+</e:Header>
+<e:Body>
+<d:Probe><d:Types>dn:NetworkVideoTransmitter</d:Types></d:Probe>
+</e:Body>
+</e:Envelope>`
+
+type probeMatches struct {
+	Body struct {
+		ProbeMatches struct {
+			ProbeMatch []struct {
+				Address string `xml:"EndpointReference>Address"`
+				Types   string `xml:"Types"`
+				Scopes  string `xml:"Scopes"`
+				XAddrs  string `xml:"XAddrs"`
+			} `xml:"ProbeMatch"`
+		} `xml:"ProbeMatches"`
+	} `xml:"Body"`
+}
+
+// Discover 在指定网卡上发送 WS-Discovery Probe，等待 timeout 后返回收到应答的设备
+// ifaces 为空时由系统选择网卡
+func Discover(ifaces []string, timeout time.Duration) ([]Device, error) {
+	dst, _ := net.ResolveUDPAddr("udp4", DiscoveryAddr)
+	laddrs := []*net.UDPAddr{{IP: net.IPv4zero}}
+	if len(ifaces) > 0 {
+		laddrs = laddrs[:0]
+		for _, name := range ifaces {
+			ip, err := interfaceIPv4(name)
+			if err != nil {
+				return nil, err
+			}
+			laddrs = append(laddrs, &net.UDPAddr{IP: ip})
+		}
+	}
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		res  = []Device{}
+		seen = map[string]bool{}
+		errs []error
+	)
+	for _, laddr := range laddrs {
+		wg.Add(1)
+		go func(laddr *net.UDPAddr) {
+			defer wg.Done()
+			devices, err := Probe(laddr, dst, timeout)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			for _, d := range devices {
+				if !seen[d.UUID] {
+					seen[d.UUID] = true
+					res = append(res, d)
+				}
+			}
+		}(laddr)
+	}
+	wg.Wait()
+	if len(errs) == len(laddrs) {
+		return nil, errs[0]
+	}
+	return res, nil
+}
+
+// Probe 从本地地址 laddr 向 dst 发送一次 Probe，收集 timeout 内的应答
+func Probe(laddr, dst *net.UDPAddr, timeout time.Duration) ([]Device, error) {
+	conn, err := net.ListenUDP("udp4", laddr)
+	if err != nil {
+		return nil, fmt.Errorf("onvif discovery listen %s fail:%v", laddr, err)
+	}
+	defer conn.Close()
+	id, _ := uuid.NewV4()
+	if _, err := conn.WriteToUDP([]byte(fmt.Sprintf(probeTemplate, id.String())), dst); err != nil {
+		return nil, fmt.Errorf("onvif discovery probe %s fail:%v", dst, err)
+	}
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	res := []Device{}
+	seen := map[string]bool{}
+	buf := make([]byte, 64*1024)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			// 超时结束
+			break
+		}
+		devices, err := parseProbeMatches(buf[:n])
+		if err != nil {
+			logrus.Debugln("onvif discovery parse fail,", from, err)
+			continue
+		}
+		for _, d := range devices {
+			if !seen[d.UUID] {
+				seen[d.UUID] = true
+				res = append(res, d)
+			}
+		}
+	}
+	return res, nil
+}
+
+func parseProbeMatches(data []byte) ([]Device, error) {
+	msg := probeMatches{}
+	if err := xml.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	res := []Device{}
+	for _, match := range msg.Body.ProbeMatches.ProbeMatch {
+		d := Device{
+			UUID:   strings.TrimSpace(match.Address),
+			XAddrs: strings.Fields(match.XAddrs),
+			Types:  strings.TrimSpace(match.Types),
+			Scopes: strings.Fields(match.Scopes),
+		}
+		if len(d.XAddrs) == 0 {
+			continue
+		}
+		if u, err := url.Parse(d.XAddrs[0]); err == nil {
+			d.IP = u.Hostname()
+		}
+		for _, scope := range d.Scopes {
+			value := func(prefix string) string {
+				v, _ := url.PathUnescape(strings.TrimPrefix(scope, prefix))
+				return v
+			}
+			switch {
+			case strings.HasPrefix(scope, "onvif://www.onvif.org/name/"):
+				d.Name = value("onvif://www.onvif.org/name/")
+			case strings.HasPrefix(scope, "onvif://www.onvif.org/hardware/"):
+				d.Hardware = value("onvif://www.onvif.org/hardware/")
+			case strings.HasPrefix(scope, "onvif://www.onvif.org/location/"):
+				d.Location = value("onvif://www.onvif.org/location/")
+			}
+		}
+		res = append(res, d)
+	}
+	return res, nil
+}
+
+// 网卡的第一个ipv4地址
+func interfaceIPv4(name string) (net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("onvif discovery interface %s error:%v", name, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("onvif discovery interface %s error:%v", name, err)
+	}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+			return ipnet.IP.To4(), nil
+		}
+	}
+	return nil, fmt.Errorf("onvif discovery interface %s has no ipv4 address", name)
+}
@@ -0,0 +1,112 @@
+package onvif
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testProbeMatch = `<?xml version="1.0" encoding="UTF-8"?>
+<SOAP-ENV:Envelope xmlns:SOAP-ENV="http://www.w3.org/2003/05/soap-envelope" xmlns:wsa="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:d="http://schemas.xmlsoap.org/ws/2005/04/discovery" xmlns:dn="http://www.onvif.org/ver10/network/wsdl">
+<SOAP-ENV:Body><d:ProbeMatches>%s</d:ProbeMatches></SOAP-ENV:Body>
+</SOAP-ENV:Envelope>`
+
+const testMatch = `<d:ProbeMatch>
+<wsa:EndpointReference><wsa:Address>%s</wsa:Address></wsa:EndpointReference>
+<d:Types>dn:NetworkVideoTransmitter</d:Types>
+<d:Scopes>%s</d:Scopes>
+<d:XAddrs>%s</d:XAddrs>
+<d:MetadataVersion>1</d:MetadataVersion>
+</d:ProbeMatch>`
+
+func TestParseProbeMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []Device
+		err  bool
+	}{
+		{
+			name: "single",
+			data: fmt.Sprintf(testProbeMatch, fmt.Sprintf(testMatch, "urn:uuid:1", "onvif://www.onvif.org/name/IPC%20Front onvif://www.onvif.org/hardware/DS-2CD onvif://www.onvif.org/location/gate", "http://192.168.1.64/onvif/device_service")),
+			want: []Device{{UUID: "urn:uuid:1", IP: "192.168.1.64", Name: "IPC Front", Hardware: "DS-2CD", Location: "gate"}},
+		},
+		{
+			name: "multiple xaddrs use first",
+			data: fmt.Sprintf(testProbeMatch, fmt.Sprintf(testMatch, "urn:uuid:2", "", "http://10.0.0.2:8000/onvif/device_service http://[fe80::1]/onvif/device_service")),
+			want: []Device{{UUID: "urn:uuid:2", IP: "10.0.0.2"}},
+		},
+		{
+			name: "skip without xaddrs",
+			data: fmt.Sprintf(testProbeMatch, fmt.Sprintf(testMatch, "urn:uuid:3", "", "")+fmt.Sprintf(testMatch, "urn:uuid:4", "", "http://10.0.0.4/onvif/device_service")),
+			want: []Device{{UUID: "urn:uuid:4", IP: "10.0.0.4"}},
+		},
+		{
+			name: "no matches",
+			data: fmt.Sprintf(testProbeMatch, ""),
+			want: []Device{},
+		},
+		{
+			name: "invalid xml",
+			data: "<Envelope>",
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProbeMatches([]byte(tt.data))
+			if (err != nil) != tt.err {
+				t.Fatalf("err = %v, want err %v", err, tt.err)
+			}
+			if tt.err {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d devices, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, d := range got {
+				w := tt.want[i]
+				if d.UUID != w.UUID || d.IP != w.IP || d.Name != w.Name || d.Hardware != w.Hardware || d.Location != w.Location {
+					t.Fatalf("device %d = %+v, want %+v", i, d, w)
+				}
+				if d.Types != "dn:NetworkVideoTransmitter" || len(d.XAddrs) == 0 {
+					t.Fatalf("device %d = %+v", i, d)
+				}
+			}
+		})
+	}
+}
+
+// 本地模拟设备应答Probe，重复应答只返回一次
+func TestProbe(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	go func() {
+		buf := make([]byte, 64*1024)
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		if !strings.Contains(string(buf[:n]), "NetworkVideoTransmitter") {
+			t.Errorf("unexpected probe: %s", buf[:n])
+			return
+		}
+		resp := fmt.Sprintf(testProbeMatch, fmt.Sprintf(testMatch, "urn:uuid:1", "", "http://127.0.0.1/onvif/device_service"))
+		for i := 0; i < 2; i++ {
+			conn.WriteToUDP([]byte(resp), from)
+		}
+	}()
+
+	devices, err := Probe(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, conn.LocalAddr().(*net.UDPAddr), 300*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 1 || devices[0].UUID != "urn:uuid:1" || devices[0].IP != "127.0.0.1" {
+		t.Fatalf("devices = %+v", devices)
+	}
+}
@@ -0,0 +1,489 @@
+package sipapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	sdp "github.com/panjjo/gosdp"
+	"github.com/panjjo/gosip/media"
+	sip "github.com/panjjo/gosip/sip/s"
+	"github.com/panjjo/gosip/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// BroadcastApp 广播音频源在媒体服务器中的app，客户端将音频推到该app下
+const BroadcastApp = "broadcast"
+
+// 等待设备发起广播INVITE的超时时间
+const broadcastInviteTimeout = 10 * time.Second
+
+// 未指定音频编码时广播支持的音频编码
+var broadcastCodecs = []string{AudioCodecPCMA, AudioCodecPCMU}
+
+// 广播状态
+const (
+	// BroadcastStatusWaiting 已发送广播通知，等待设备发起INVITE
+	BroadcastStatusWaiting = "waiting"
+	// BroadcastStatusPlaying 广播中
+	BroadcastStatusPlaying = "playing"
+	// BroadcastStatusStopped 广播已结束
+	BroadcastStatusStopped = "stopped"
+	// BroadcastStatusFailed 广播失败
+	BroadcastStatusFailed = "failed"
+)
+
+// Broadcast 语音广播会话
+type Broadcast struct {
+	// ChannelID 语音输出通道编码
+	ChannelID string `json:"channelid"`
+	// DeviceID 设备编号
+	DeviceID string `json:"deviceid"`
+	// App 音频源app
+	App string `json:"app"`
+	// Stream 音频源stream
+	Stream string `json:"stream"`
+	// Codec 音频编码
+	Codec string `json:"codec"`
+	// SSRC 设备指定的ssrc
+	SSRC string `json:"ssrc"`
+	// Transport 媒体传输方式 udp/tcp
+	Transport string `json:"transport"`
+	// Port 媒体服务器发送端口
+	Port int `json:"port"`
+	// Status 广播状态 waiting/playing/stopped/failed
+	Status string `json:"status"`
+	// Msg 失败原因
+	Msg string `json:"msg"`
+	// Start 开始时间
+	Start int64 `json:"start"`
+
+	mu      sync.Mutex
+	codecs  []string
+	invited chan error
+	// 设备发起的INVITE会话信息，用于结束广播时发送BYE
+	callID  string
+	local   *sip.Address
+	remote  *sip.Address
+	contact *sip.URI
+	sending bool
+}
+
+// 广播会话 key=channelid value=*Broadcast
+var _broadcasts sync.Map
+
+// SipBroadcast 向一个或多个通道发起语音广播，stream 为 BroadcastApp 下的音频源
+// 每个通道独立返回广播结果，部分通道失败不影响其他通道
+func SipBroadcast(channelIDs []string, stream string) []*Broadcast {
+	list := make([]*Broadcast, len(channelIDs))
+	wg := sync.WaitGroup{}
+	for i, channelID := range channelIDs {
+		wg.Add(1)
+		go func(i int, channelID string) {
+			defer wg.Done()
+			list[i] = sipBroadcast(channelID, BroadcastApp, stream, broadcastCodecs)
+		}(i, channelID)
+	}
+	wg.Wait()
+	return list
+}
+
+// sipBroadcast 向通道发起广播，codecs 为音频源可以发送的音频编码
+func sipBroadcast(channelID, app, stream string, codecs []string) *Broadcast {
+	b := &Broadcast{
+		ChannelID: channelID,
+		App:       app,
+		Stream:    stream,
+		Status:    BroadcastStatusWaiting,
+		Start:     time.Now().Unix(),
+		codecs:    codecs,
+		invited:   make(chan error, 1),
+	}
+	channel, device, err := channelDevice(channelID)
+	if err != nil {
+		return b.fail(err)
+	}
+	b.DeviceID = channel.DeviceID
+	if _, loaded := _broadcasts.LoadOrStore(channel.ChannelID, b); loaded {
+		b.Status = BroadcastStatusFailed
+		b.Msg = "通道正在广播"
+		return b
+	}
+	sn := utils.RandInt(100000, 999999)
+	body, err := sipMessageQuery(device, nil, "Broadcast", channel.ChannelID, sn, sip.GetBroadcastXML(_serverDevices.DeviceID, channel.ChannelID, sn))
+	if err == nil {
+		_, err = parseMessageResponse(body)
+	}
+	if err != nil {
+		b.close(false)
+		return b.fail(err)
+	}
+	timeout := time.NewTimer(broadcastInviteTimeout)
+	defer timeout.Stop()
+	select {
+	case err = <-b.invited:
+	case <-timeout.C:
+		err = errors.New("等待设备发起广播超时")
+	}
+	if err != nil {
+		b.close(true)
+		return b.fail(err)
+	}
+	return b
+}
+
+// 通知等待方设备INVITE的处理结果
+func (b *Broadcast) signal(err error) {
+	select {
+	case b.invited <- err:
+	default:
+	}
+}
+
+func (b *Broadcast) fail(err error) *Broadcast {
+	b.mu.Lock()
+	b.Status = BroadcastStatusFailed
+	b.Msg = err.Error()
+	b.mu.Unlock()
+	return b
+}
+
+// SipStopBroadcast 结束通道的语音广播
+func SipStopBroadcast(channelID string) error {
+	v, ok := _broadcasts.Load(channelID)
+	if !ok {
+		return errors.New("通道未在广播")
+	}
+	v.(*Broadcast).close(true)
+	return nil
+}
+
+// StopBroadcastsBySource 音频源注销后结束使用该音频源的所有广播
+func StopBroadcastsBySource(app, stream string) {
+	_broadcasts.Range(func(_, v any) bool {
+		b := v.(*Broadcast)
+		if b.App == app && b.Stream == stream {
+			go b.close(true)
+		}
+		return true
+	})
+}
+
+// GetBroadcasts 获取当前所有广播会话
+func GetBroadcasts() []*Broadcast {
+	list := []*Broadcast{}
+	_broadcasts.Range(func(_, v any) bool {
+		list = append(list, v.(*Broadcast))
+		return true
+	})
+	return list
+}
+
+// 结束广播，bye 为 true 时向设备发送BYE，设备主动BYE时不需要再发送
+func (b *Broadcast) close(bye bool) {
+	b.mu.Lock()
+	_broadcasts.CompareAndDelete(b.ChannelID, b)
+	if b.Status == BroadcastStatusStopped {
+		b.mu.Unlock()
+		return
+	}
+	b.Status = BroadcastStatusStopped
+	bye = bye && b.callID != "" && b.local != nil
+	sending := b.sending
+	b.sending = false
+	b.mu.Unlock()
+
+	if bye {
+		if err := b.bye(); err != nil {
+			logrus.Warnln("broadcast bye fail,", b.ChannelID, err)
+		}
+	}
+	if sending {
+		if err := _media.StopSendRtp(context.Background(), b.App, b.Stream, b.SSRC); err != nil {
+			logrus.Warnln("broadcast stopSendRtp fail,", b.ChannelID, err)
+		}
+	}
+	logrus.Infoln("broadcast stopped,", b.ChannelID, b.App, b.Stream)
+}
+
+// 向设备发送广播音频中断，结束使用该音频源和ssrc的广播
+func broadcastSendStopped(app, stream, ssrc, reason string) bool {
+	found := false
+	_broadcasts.Range(func(_, v any) bool {
+		b := v.(*Broadcast)
+		b.mu.Lock()
+		match := b.sending && b.App == app && b.Stream == stream && (ssrc == "" || b.SSRC == ssrc)
+		if match {
+			// 媒体服务器已停止发送，结束时只通知设备
+			b.Msg = reason
+			b.sending = false
+		}
+		b.mu.Unlock()
+		if match {
+			found = true
+			go b.close(true)
+		}
+		return true
+	})
+	return found
+}
+
+// 向设备发送BYE，设备为INVITE发起方，From/To 与INVITE相反
+func (b *Broadcast) bye() error {
+	device, ok := _activeDevices.Get(b.DeviceID)
+	if !ok {
+		return errors.New("设备已离线")
+	}
+	callID := sip.CallID(b.callID)
+	hb := sip.NewHeaderBuilder().SetFrom(b.local).SetToWithParam(b.remote).SetCallID(&callID).AddVia(&sip.ViaHop{
+		Params: sip.NewParams().Add("branch", sip.String{Str: sip.GenerateBranch()}),
+	}).SetMethod(sip.BYE).SetContact(_serverDevices.addr)
+	req := sip.NewRequest("", sip.BYE, b.contact, sip.DefaultSipVersion, hb.Build(), nil)
+	req.SetDestination(device.source)
+	// 根据设备的传输方式发送请求
+	var tx *sip.Transaction
+	var err error
+	if strings.ToLower(device.TransPort) == "tcp" {
+		tx, err = srv.RequestWithProtocol(req, "tcp")
+	} else {
+		tx, err = srv.Request(req) // 默认UDP
+	}
+	if err != nil {
+		return err
+	}
+	_, err = sipResponse(tx)
+	return err
+}
+
+// 查找设备INVITE对应的广播会话，INVITE的From一般为语音输出通道编码，部分设备使用设备编号
+func findBroadcast(id string) *Broadcast {
+	if v, ok := _broadcasts.Load(id); ok {
+		return v.(*Broadcast)
+	}
+	var found *Broadcast
+	_broadcasts.Range(func(_, v any) bool {
+		b := v.(*Broadcast)
+		b.mu.Lock()
+		waiting := b.Status == BroadcastStatusWaiting
+		b.mu.Unlock()
+		if b.DeviceID == id && waiting {
+			found = b
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// 根据CallID查找广播会话
+func broadcastByCallID(callID string) *Broadcast {
+	var found *Broadcast
+	_broadcasts.Range(func(_, v any) bool {
+		b := v.(*Broadcast)
+		b.mu.Lock()
+		match := b.callID == callID
+		b.mu.Unlock()
+		if match {
+			found = b
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// handlerInvite 处理设备收到广播通知后发起的INVITE，应答中的SDP指向媒体服务器的音频源
+func handlerInvite(req *sip.Request, tx *sip.Transaction) {
+	fromUser, ok := parserDevicesFromReqeust(req)
+	if !ok {
+		tx.Respond(sip.NewResponseFromRequest("", req, http.StatusBadRequest, http.StatusText(http.StatusBadRequest), nil))
+		return
+	}
+	b := findBroadcast(fromUser.DeviceID)
+	if b == nil {
+		logrus.Warnln("handlerInvite broadcast not found,", fromUser.DeviceID)
+		tx.Respond(sip.NewResponseFromRequest("", req, http.StatusNotFound, http.StatusText(http.StatusNotFound), nil))
+		return
+	}
+	answer, err := b.answer(req)
+	if err != nil {
+		logrus.Warnln("handlerInvite broadcast answer fail,", b.ChannelID, err)
+		tx.Respond(sip.NewResponseFromRequest("", req, 488, "Not Acceptable Here", nil))
+		b.signal(err)
+		return
+	}
+	resp := sip.NewResponseFromRequest("", req, http.StatusOK, "OK", answer)
+	b.mu.Lock()
+	if to, ok := resp.To(); ok {
+		if to.Params == nil {
+			to.Params = sip.NewParams()
+		}
+		to.Params.Add("tag", sip.String{Str: utils.RandString(20)})
+		b.local = &sip.Address{DisplayName: to.DisplayName, URI: to.Address.Clone(), Params: to.Params.Clone()}
+	}
+	b.mu.Unlock()
+	resp.AppendHeader(&sip.ContactHeader{Address: _serverDevices.addr.URI, Params: sip.NewParams()})
+	resp.AppendHeader(&sip.ContentTypeSDP)
+	if err := tx.Respond(resp); err != nil {
+		b.signal(err)
+		return
+	}
+	b.signal(nil)
+}
+
+// 根据设备的SDP开启媒体服务器音频发送，返回应答SDP
+func (b *Broadcast) answer(req *sip.Request) ([]byte, error) {
+	b.mu.Lock()
+	status := b.Status
+	b.mu.Unlock()
+	if status != BroadcastStatusWaiting {
+		return nil, errors.New("广播已结束")
+	}
+	body := req.Body()
+	offer, err := sdp.Decode(body)
+	if err != nil {
+		return nil, fmt.Errorf("sdp解析失败:%v", err)
+	}
+	var audioMedia *sdp.Media
+	for i := range offer.Medias {
+		if offer.Medias[i].Description.Type == "audio" {
+			audioMedia = &offer.Medias[i]
+			break
+		}
+	}
+	if audioMedia == nil {
+		return nil, errors.New("设备sdp中没有音频媒体")
+	}
+	ssrc := sdpField(body, 'y')
+	if ssrc == "" {
+		return nil, errors.New("设备sdp中没有ssrc")
+	}
+	pt, codec, rtpmap := sdpAudioFormat(audioMedia, b.codecs)
+	if pt == "" {
+		return nil, fmt.Errorf("设备不支持音频编码%s", strings.Join(b.codecs, "/"))
+	}
+	ip := audioMedia.Connection.IP
+	if ip == nil {
+		ip = offer.Connection.IP
+	}
+	tcp := strings.Contains(strings.ToUpper(audioMedia.Description.Protocol), "TCP")
+	setup := audioMedia.Attribute("setup")
+
+	sendReq := media.SendRtpReq{
+		App:       b.App,
+		Stream:    b.Stream,
+		SSRC:      ssrc,
+		PT:        pt,
+		OnlyAudio: true,
+	}
+	var port int
+	if tcp && setup != "passive" {
+		// 设备主动连接媒体服务器
+		port, err = _media.StartSendRtpPassive(context.Background(), sendReq)
+		setup = "passive"
+	} else {
+		// 媒体服务器主动向设备发送
+		if ip == nil {
+			return nil, errors.New("设备sdp中没有媒体地址")
+		}
+		sendReq.DstURL = ip.String()
+		sendReq.DstPort = audioMedia.Description.Port
+		sendReq.IsUDP = !tcp
+		port, err = _media.StartSendRtp(context.Background(), sendReq)
+		setup = "active"
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	b.SSRC = ssrc
+	b.Codec = codec
+	b.Port = port
+	b.Transport = "udp"
+	if tcp {
+		b.Transport = "tcp"
+	}
+	b.sending = true
+	b.Status = BroadcastStatusPlaying
+	if callID, ok := req.CallID(); ok {
+		b.callID = string(*callID)
+	}
+	if from, ok := req.From(); ok {
+		b.remote = sip.NewAddressFromFromHeader(from)
+		b.contact = from.Address.Clone()
+	}
+	if contact, ok := req.Contact(); ok && contact.Address != nil {
+		b.contact = contact.Address.Clone()
+	}
+	b.mu.Unlock()
+
+	audio := sdp.Media{
+		Description: sdp.MediaDescription{
+			Type:     "audio",
+			Port:     port,
+			Formats:  []string{pt},
+			Protocol: audioMedia.Description.Protocol,
+		},
+	}
+	audio.AddAttribute("sendonly")
+	audio.AddAttribute("rtpmap", pt, rtpmap)
+	if tcp {
+		audio.AddAttribute("setup", setup)
+		audio.AddAttribute("connection", "new")
+	}
+	msg := &sdp.Message{
+		Origin: sdp.Origin{
+			Username: _serverDevices.DeviceID,
+			Address:  _sysinfo.MediaServerRtpIP.String(),
+		},
+		Name: "Play",
+		Connection: sdp.ConnectionData{
+			IP:  _sysinfo.MediaServerRtpIP,
+			TTL: 0,
+		},
+		Timing: []sdp.Timing{{}},
+		Medias: []sdp.Media{audio},
+		SSRC:   ssrc,
+	}
+	var s sdp.Session
+	s = msg.Append(s)
+	return s.AppendTo(nil), nil
+}
+
+// handlerAck 设备对广播INVITE应答的确认
+func handlerAck(req *sip.Request, _ *sip.Transaction) {
+	if callID, ok := req.CallID(); ok {
+		logrus.Debugln("receive ack,", string(*callID))
+	}
+}
+
+// sipMessageBroadcast 设备对广播通知的应答
+func sipMessageBroadcast(u Devices, body []byte) error {
+	res := &MessageResponse{}
+	if err := utils.XMLDecode(body, res); err != nil {
+		logrus.Errorln("sipMessageBroadcast Unmarshal xml err:", err, "body:", string(body))
+		return err
+	}
+	if sipMessageReply("Broadcast", res.DeviceID, res.SN, body) {
+		return nil
+	}
+	// 部分设备应答中的 DeviceID 为设备编号而非语音输出通道编码
+	found := false
+	_broadcasts.Range(func(_, v any) bool {
+		b := v.(*Broadcast)
+		if b.DeviceID == u.DeviceID && sipMessageReply("Broadcast", b.ChannelID, res.SN, body) {
+			found = true
+			return false
+		}
+		return true
+	})
+	if !found {
+		logrus.Infoln("sipMessageBroadcast not found wait,", u.DeviceID, res.DeviceID, res.SN)
+	}
+	return nil
+}
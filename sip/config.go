@@ -0,0 +1,320 @@
+package sipapi
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"strings"
+
+	sip "github.com/panjjo/gosip/sip/s"
+	"github.com/panjjo/gosip/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// 设备配置类型
+const (
+	ConfigTypeBasicParam       = "BasicParam"
+	ConfigTypeVideoParamOpt    = "VideoParamOpt"
+	ConfigTypeSVACEncodeConfig = "SVACEncodeConfig"
+	ConfigTypeSVACDecodeConfig = "SVACDecodeConfig"
+	ConfigTypeOSDConfig        = "OSDConfig"
+)
+
+var configTypes = map[string]bool{
+	ConfigTypeBasicParam:       true,
+	ConfigTypeVideoParamOpt:    true,
+	ConfigTypeSVACEncodeConfig: true,
+	ConfigTypeSVACDecodeConfig: true,
+	ConfigTypeOSDConfig:        true,
+}
+
+// CheckConfigType 检查配置类型是否支持，多个类型使用/分隔
+func CheckConfigType(configType string) bool {
+	if configType == "" {
+		return false
+	}
+	for _, t := range strings.Split(configType, "/") {
+		if !configTypes[t] {
+			return false
+		}
+	}
+	return true
+}
+
+// ConfigDownload 设备配置查询应答
+type ConfigDownload struct {
+	XMLName  xml.Name `xml:"Response" json:"-"`
+	CmdType  string   `xml:"CmdType" json:"-"`
+	SN       int      `xml:"SN" json:"-"`
+	DeviceID string   `xml:"DeviceID" json:"deviceid"`
+	// Result 查询结果 OK/ERROR
+	Result           string            `xml:"Result" json:"result"`
+	BasicParam       *BasicParam       `xml:"BasicParam" json:"basicparam,omitempty"`
+	VideoParamOpt    *VideoParamOpt    `xml:"VideoParamOpt" json:"videoparamopt,omitempty"`
+	SVACEncodeConfig *SVACEncodeConfig `xml:"SVACEncodeConfig" json:"svacencodeconfig,omitempty"`
+	SVACDecodeConfig *SVACDecodeConfig `xml:"SVACDecodeConfig" json:"svacdecodeconfig,omitempty"`
+	OSDConfig        *OSDConfig        `xml:"OSDConfig" json:"osdconfig,omitempty"`
+}
+
+// BasicParam 设备基本参数
+type BasicParam struct {
+	// Name 设备名称
+	Name     string `xml:"Name" json:"name"`
+	DeviceID string `xml:"DeviceID" json:"deviceid"`
+	// SIPServerID SIP服务器编码
+	SIPServerID   string `xml:"SIPServerID" json:"sipserverid"`
+	SIPServerIP   string `xml:"SIPServerIP" json:"sipserverip"`
+	SIPServerPort int    `xml:"SIPServerPort" json:"sipserverport"`
+	// DomainName 设备所属域名
+	DomainName string `xml:"DomainName" json:"domainname"`
+	// Expiration 注册过期时间(秒)
+	Expiration int `xml:"Expiration" json:"expiration"`
+	// HeartBeatInterval 心跳间隔时间(秒)
+	HeartBeatInterval int `xml:"HeartBeatInterval" json:"heartbeatinterval"`
+	// HeartBeatCount 心跳超时次数
+	HeartBeatCount int `xml:"HeartBeatCount" json:"heartbeatcount"`
+}
+
+// BasicParamConfig 设备基本参数配置，只包含可修改项
+type BasicParamConfig struct {
+	// Name 设备名称
+	Name string `xml:"Name,omitempty" json:"name" binding:"omitempty,max=64"`
+	// Expiration 注册过期时间(秒)
+	Expiration int `xml:"Expiration,omitempty" json:"expiration" binding:"omitempty,min=60,max=86400"`
+	// HeartBeatInterval 心跳间隔时间(秒)
+	HeartBeatInterval int `xml:"HeartBeatInterval,omitempty" json:"heartbeatinterval" binding:"omitempty,min=5,max=3600"`
+	// HeartBeatCount 心跳超时次数
+	HeartBeatCount int `xml:"HeartBeatCount,omitempty" json:"heartbeatcount" binding:"omitempty,min=3,max=255"`
+}
+
+// VideoParamOpt 视频参数范围
+type VideoParamOpt struct {
+	// DownloadSpeed 下载倍速范围，各可选参数以/分隔，如 1/2/4
+	DownloadSpeed string `xml:"DownloadSpeed,omitempty" json:"downloadspeed" binding:"omitempty,max=32"`
+	// Resolution 摄像机支持的分辨率，各可选参数以/分隔，如 5/6
+	Resolution string `xml:"Resolution,omitempty" json:"resolution" binding:"omitempty,max=64"`
+}
+
+// SVACEncodeConfig SVAC编码配置
+type SVACEncodeConfig struct {
+	ROIParam          *SVACROIParam          `xml:"ROIParam,omitempty" json:"roiparam,omitempty"`
+	SVCParam          *SVACSVCParam          `xml:"SVCParam,omitempty" json:"svcparam,omitempty"`
+	SurveillanceParam *SVACSurveillanceParam `xml:"SurveillanceParam,omitempty" json:"surveillanceparam,omitempty"`
+	EncryptParam      *SVACEncryptParam      `xml:"EncryptParam,omitempty" json:"encryptparam,omitempty"`
+	AudioParam        *SVACAudioParam        `xml:"AudioParam,omitempty" json:"audioparam,omitempty"`
+}
+
+// SVACROIParam 感兴趣区域参数
+type SVACROIParam struct {
+	// ROIFlag 感兴趣区域开关 0关闭 1打开
+	ROIFlag *int `xml:"ROIFlag,omitempty" json:"roiflag" binding:"omitempty,oneof=0 1"`
+	// ROINumber 感兴趣区域数量 0-16
+	ROINumber *int          `xml:"ROINumber,omitempty" json:"roinumber" binding:"omitempty,min=0,max=16"`
+	Item      []SVACROIItem `xml:"Item,omitempty" json:"item" binding:"omitempty,max=16,dive"`
+	// BackGroundQP 背景区域编码质量等级 0一般 1较好 2好 3很好
+	BackGroundQP *int `xml:"BackGroundQP,omitempty" json:"backgroundqp" binding:"omitempty,min=0,max=3"`
+	// BackGroundSkipFlag 背景跳过开关 0关闭 1打开
+	BackGroundSkipFlag *int `xml:"BackGroundSkipFlag,omitempty" json:"backgroundskipflag" binding:"omitempty,oneof=0 1"`
+}
+
+// SVACROIItem 感兴趣区域
+type SVACROIItem struct {
+	// ROISeq 感兴趣区域编号 1-16
+	ROISeq int `xml:"ROISeq" json:"roiseq" binding:"min=1,max=16"`
+	// TopLeft 感兴趣区域左上角坐标
+	TopLeft int `xml:"TopLeft" json:"topleft" binding:"min=0"`
+	// BottomRight 感兴趣区域右下角坐标
+	BottomRight int `xml:"BottomRight" json:"bottomright" binding:"min=0"`
+	// ROIQP ROI区域编码质量等级 0一般 1较好 2好 3很好
+	ROIQP int `xml:"ROIQP" json:"roiqp" binding:"min=0,max=3"`
+}
+
+// SVACSVCParam SVC参数
+type SVACSVCParam struct {
+	// SVCSpaceDomainMode 空域编码方式 0不使用 1一级增强 2二级增强 3三级增强
+	SVCSpaceDomainMode *int `xml:"SVCSpaceDomainMode,omitempty" json:"svcspacedomainmode" binding:"omitempty,min=0,max=3"`
+	// SVCTimeDomainMode 时域编码方式 0不使用 1一级增强 2二级增强 3三级增强
+	SVCTimeDomainMode *int `xml:"SVCTimeDomainMode,omitempty" json:"svctimedomainmode" binding:"omitempty,min=0,max=3"`
+	// SVCSpaceSupportMode 空域编码能力 解码配置时为SVC流显示模式
+	SVCSpaceSupportMode *int `xml:"SVCSpaceSupportMode,omitempty" json:"svcspacesupportmode" binding:"omitempty,min=0,max=3"`
+	// SVCTimeSupportMode 时域编码能力
+	SVCTimeSupportMode *int `xml:"SVCTimeSupportMode,omitempty" json:"svctimesupportmode" binding:"omitempty,min=0,max=3"`
+	// SVCSTMMode 码流显示模式 0基本层 1一级增强层 2二级增强层 3三级增强层，解码配置使用
+	SVCSTMMode *int `xml:"SVCSTMMode,omitempty" json:"svcstmmode" binding:"omitempty,min=0,max=3"`
+}
+
+// SVACSurveillanceParam 监控专用信息参数
+type SVACSurveillanceParam struct {
+	// TimeFlag 绝对时间信息开关 0关闭 1打开
+	TimeFlag *int `xml:"TimeFlag,omitempty" json:"timeflag" binding:"omitempty,oneof=0 1"`
+	// EventFlag 监控事件信息开关 0关闭 1打开
+	EventFlag *int `xml:"EventFlag,omitempty" json:"eventflag" binding:"omitempty,oneof=0 1"`
+	// AlertFlag 报警信息开关 0关闭 1打开
+	AlertFlag *int `xml:"AlertFlag,omitempty" json:"alertflag" binding:"omitempty,oneof=0 1"`
+	// TimeShowFlag 绝对时间信息显示开关，解码配置使用
+	TimeShowFlag *int `xml:"TimeShowFlag,omitempty" json:"timeshowflag" binding:"omitempty,oneof=0 1"`
+	// EventShowFlag 监控事件信息显示开关，解码配置使用
+	EventShowFlag *int `xml:"EventShowFlag,omitempty" json:"eventshowflag" binding:"omitempty,oneof=0 1"`
+	// AlerShowtFlag 报警信息显示开关，解码配置使用（字段名与标准保持一致）
+	AlerShowtFlag *int `xml:"AlerShowtFlag,omitempty" json:"alershowtflag" binding:"omitempty,oneof=0 1"`
+}
+
+// SVACEncryptParam 加密与认证参数
+type SVACEncryptParam struct {
+	// EncryptionFlag 加密开关 0关闭 1打开
+	EncryptionFlag *int `xml:"EncryptionFlag,omitempty" json:"encryptionflag" binding:"omitempty,oneof=0 1"`
+	// AuthenticationFlag 认证开关 0关闭 1打开
+	AuthenticationFlag *int `xml:"AuthenticationFlag,omitempty" json:"authenticationflag" binding:"omitempty,oneof=0 1"`
+}
+
+// SVACAudioParam 音频参数
+type SVACAudioParam struct {
+	// AudioRecognitionFlag 声音识别特征参数开关 0关闭 1打开
+	AudioRecognitionFlag *int `xml:"AudioRecognitionFlag,omitempty" json:"audiorecognitionflag" binding:"omitempty,oneof=0 1"`
+}
+
+// SVACDecodeConfig SVAC解码配置
+type SVACDecodeConfig struct {
+	SVCParam          *SVACSVCParam          `xml:"SVCParam,omitempty" json:"svcparam,omitempty"`
+	SurveillanceParam *SVACSurveillanceParam `xml:"SurveillanceParam,omitempty" json:"surveillanceparam,omitempty"`
+}
+
+// OSDConfig 视频OSD配置
+type OSDConfig struct {
+	// Length 配置窗口长度像素值
+	Length int `xml:"Length,omitempty" json:"length" binding:"omitempty,min=1"`
+	// Width 配置窗口宽度像素值
+	Width int `xml:"Width,omitempty" json:"width" binding:"omitempty,min=1"`
+	// TimeX 时间X像素坐标
+	TimeX *int `xml:"TimeX,omitempty" json:"timex" binding:"omitempty,min=0"`
+	// TimeY 时间Y像素坐标
+	TimeY *int `xml:"TimeY,omitempty" json:"timey" binding:"omitempty,min=0"`
+	// TimeEnable 显示时间开关 0关闭 1打开
+	TimeEnable *int `xml:"TimeEnable,omitempty" json:"timeenable" binding:"omitempty,oneof=0 1"`
+	// TimeType 时间显示类型 0 YYYY-MM-DD HH:MM:SS 1 YYYY年MM月DD日 HH:MM:SS
+	TimeType *int `xml:"TimeType,omitempty" json:"timetype" binding:"omitempty,oneof=0 1"`
+	// TextEnable 显示文字开关 0关闭 1打开
+	TextEnable *int `xml:"TextEnable,omitempty" json:"textenable" binding:"omitempty,oneof=0 1"`
+	// SumNum 文字数量
+	SumNum int       `xml:"SumNum,omitempty" json:"sumnum"`
+	Item   []OSDItem `xml:"Item,omitempty" json:"item" binding:"omitempty,max=8,dive"`
+}
+
+// OSDItem OSD叠加文字
+type OSDItem struct {
+	// Text 文字内容
+	Text string `xml:"Text" json:"text" binding:"max=64"`
+	// X 文字X像素坐标
+	X int `xml:"X" json:"x" binding:"min=0"`
+	// Y 文字Y像素坐标
+	Y int `xml:"Y" json:"y" binding:"min=0"`
+}
+
+// DeviceConfigRequest 设备配置请求，至少包含一项配置
+type DeviceConfigRequest struct {
+	BasicParam       *BasicParamConfig `json:"basicparam"`
+	VideoParamOpt    *VideoParamOpt    `json:"videoparamopt"`
+	SVACEncodeConfig *SVACEncodeConfig `json:"svacencodeconfig"`
+	SVACDecodeConfig *SVACDecodeConfig `json:"svacdecodeconfig"`
+	OSDConfig        *OSDConfig        `json:"osdconfig"`
+}
+
+// 生成配置项xml
+func (req *DeviceConfigRequest) encode() ([]byte, error) {
+	if req.OSDConfig != nil {
+		req.OSDConfig.SumNum = len(req.OSDConfig.Item)
+	}
+	items := []struct {
+		name string
+		data any
+		ok   bool
+	}{
+		{ConfigTypeBasicParam, req.BasicParam, req.BasicParam != nil},
+		{ConfigTypeVideoParamOpt, req.VideoParamOpt, req.VideoParamOpt != nil},
+		{ConfigTypeSVACEncodeConfig, req.SVACEncodeConfig, req.SVACEncodeConfig != nil},
+		{ConfigTypeSVACDecodeConfig, req.SVACDecodeConfig, req.SVACDecodeConfig != nil},
+		{ConfigTypeOSDConfig, req.OSDConfig, req.OSDConfig != nil},
+	}
+	buf := &bytes.Buffer{}
+	enc := xml.NewEncoder(buf)
+	for _, item := range items {
+		if !item.ok {
+			continue
+		}
+		if err := enc.EncodeElement(item.data, xml.StartElement{Name: xml.Name{Local: item.name}}); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	if buf.Len() == 0 {
+		return nil, errors.New("缺少配置项")
+	}
+	return buf.Bytes(), nil
+}
+
+// SipConfigDownload 查询设备配置，channel 不为空时查询设备下指定通道的配置
+func SipConfigDownload(device Devices, channel *Channels, configType string) (*ConfigDownload, error) {
+	var to *sip.Address
+	deviceID := device.DeviceID
+	if channel != nil {
+		to = channelAddress(channel)
+		deviceID = channel.ChannelID
+	}
+	sn := utils.RandInt(100000, 999999)
+	body, err := sipMessageQuery(device, to, "ConfigDownload", deviceID, sn, sip.GetConfigDownloadXML(deviceID, sn, configType))
+	if err != nil {
+		return nil, err
+	}
+	res := &ConfigDownload{}
+	if err := utils.XMLDecode(body, res); err != nil {
+		return nil, err
+	}
+	if channel == nil && res.BasicParam != nil {
+		updateDeviceHeartBeat(device.DeviceID, res.BasicParam.HeartBeatInterval, res.BasicParam.HeartBeatCount)
+	}
+	return res, nil
+}
+
+// SipDeviceConfig 设置设备配置，channel 不为空时配置设备下指定通道
+func SipDeviceConfig(device Devices, channel *Channels, req *DeviceConfigRequest) (*MessageResponse, error) {
+	body, err := req.encode()
+	if err != nil {
+		return nil, err
+	}
+	var to *sip.Address
+	deviceID := device.DeviceID
+	if channel != nil {
+		to = channelAddress(channel)
+		deviceID = channel.ChannelID
+	}
+	sn := utils.RandInt(100000, 999999)
+	// 名称、OSD文字等可能包含中文，按声明的 GB2312 编码发送
+	msg, err := utils.Utf8ToGbk(sip.GetDeviceConfigXML(deviceID, sn, body))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := sipMessageQuery(device, to, "DeviceConfig", deviceID, sn, msg)
+	if err != nil {
+		return nil, err
+	}
+	res, err := parseMessageResponse(resp)
+	if err == nil && channel == nil && req.BasicParam != nil {
+		updateDeviceHeartBeat(device.DeviceID, req.BasicParam.HeartBeatInterval, req.BasicParam.HeartBeatCount)
+	}
+	return res, err
+}
+
+// sipMessageConfigDownload 设备配置查询应答，投递给等待方
+func sipMessageConfigDownload(_ Devices, body []byte) error {
+	message := &ConfigDownload{}
+	if err := utils.XMLDecode(body, message); err != nil {
+		logrus.Errorln("sipMessageConfigDownload Unmarshal xml err:", err, "body:", string(body))
+		return err
+	}
+	if !sipMessageReply(message.CmdType, message.DeviceID, message.SN, body) {
+		logrus.Infoln("sipMessageConfigDownload not found wait,", message.DeviceID, message.SN)
+	}
+	return nil
+}
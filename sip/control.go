@@ -0,0 +1,172 @@
+package sipapi
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/panjjo/gosip/db"
+	sip "github.com/panjjo/gosip/sip/s"
+	"github.com/panjjo/gosip/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// 设备控制命令
+const (
+	// ControlTeleBoot 远程启动
+	ControlTeleBoot = "TeleBoot"
+	// ControlRecord 录像控制 Record/StopRecord
+	ControlRecord = "RecordCmd"
+	// ControlGuard 布防/撤防 SetGuard/ResetGuard
+	ControlGuard = "GuardCmd"
+	// ControlAlarm 报警复位
+	ControlAlarm = "AlarmCmd"
+	// ControlIFame 强制关键帧
+	ControlIFame = "IFameCmd"
+)
+
+// ControlLogs 设备控制审计日志
+type ControlLogs struct {
+	db.DBModel
+	// DeviceID 设备编号
+	DeviceID string `json:"deviceid" gorm:"column:deviceid"`
+	// ChannelID 通道编码，控制设备本身时为空
+	ChannelID string `json:"channelid" gorm:"column:channelid"`
+	// Cmd 控制命令 TeleBoot/RecordCmd/GuardCmd/AlarmCmd/IFameCmd
+	Cmd string `json:"cmd" gorm:"column:cmd"`
+	// Value 命令参数
+	Value string `json:"value" gorm:"column:value"`
+	// Operator 操作员，为请求方身份
+	Operator string `json:"operator" gorm:"column:operator"`
+	// IP 操作员ip
+	IP string `json:"ip" gorm:"column:ip"`
+	// Result 设备应答结果 OK/ERROR，未收到应答时为空
+	Result string `json:"result" gorm:"column:result"`
+	// Error 错误信息
+	Error string `json:"error" gorm:"column:error"`
+}
+
+// DeviceControl 设备控制请求
+type DeviceControl struct {
+	// DeviceID 设备编号，指定 ChannelID 时可以为空
+	DeviceID string
+	// ChannelID 通道编码，为空时控制设备本身
+	ChannelID string
+	// Cmd 控制命令
+	Cmd string
+	// Value 命令参数，RecordCmd: Record/StopRecord GuardCmd: SetGuard/ResetGuard
+	Value string
+	// AlarmMethod 报警复位的报警方式
+	AlarmMethod string
+	// AlarmType 报警复位的报警类型
+	AlarmType string
+	// Operator 操作员
+	Operator string
+	// IP 操作员ip
+	IP string
+}
+
+// 获取控制目标设备及地址
+func (ctl *DeviceControl) target() (Devices, *sip.Address, string, error) {
+	if ctl.ChannelID != "" {
+		channel, device, err := channelDevice(ctl.ChannelID)
+		if err != nil {
+			return device, nil, "", err
+		}
+		if ctl.DeviceID != "" && ctl.DeviceID != channel.DeviceID {
+			return device, nil, "", errors.New("通道不属于该设备")
+		}
+		ctl.DeviceID = channel.DeviceID
+		return device, channelAddress(channel), channel.ChannelID, nil
+	}
+	device, ok := _activeDevices.Get(ctl.DeviceID)
+	if !ok {
+		return device, nil, "", errors.New("设备已离线")
+	}
+	return device, nil, device.DeviceID, nil
+}
+
+// 生成控制指令
+func (ctl *DeviceControl) body(deviceID string, sn int) ([]byte, error) {
+	switch ctl.Cmd {
+	case ControlTeleBoot:
+		return sip.GetTeleBootXML(deviceID, sn), nil
+	case ControlRecord:
+		if ctl.Value != "Record" && ctl.Value != "StopRecord" {
+			return nil, errors.New("录像控制命令错误")
+		}
+		return sip.GetRecordCmdXML(deviceID, sn, ctl.Value), nil
+	case ControlGuard:
+		if ctl.Value != "SetGuard" && ctl.Value != "ResetGuard" {
+			return nil, errors.New("布防控制命令错误")
+		}
+		return sip.GetGuardCmdXML(deviceID, sn, ctl.Value), nil
+	case ControlAlarm:
+		if !alarmParamValid(ctl.AlarmMethod, 1, 7) || !alarmParamValid(ctl.AlarmType, 1, 99) {
+			return nil, errors.New("报警方式或报警类型错误")
+		}
+		return sip.GetAlarmCmdXML(deviceID, sn, ctl.AlarmMethod, ctl.AlarmType), nil
+	case ControlIFame:
+		return sip.GetIFameCmdXML(deviceID, sn), nil
+	}
+	return nil, fmt.Errorf("不支持的控制命令:%s", ctl.Cmd)
+}
+
+// 报警复位参数为空或范围内的数字
+func alarmParamValid(v string, min, max int) bool {
+	if v == "" {
+		return true
+	}
+	n, err := strconv.Atoi(v)
+	return err == nil && n >= min && n <= max && strconv.Itoa(n) == v
+}
+
+// SipDeviceControl 发送设备控制指令并等待设备应答，所有控制均记录审计日志
+// 远程启动和强制关键帧设备不返回应答，发送成功即返回OK
+func SipDeviceControl(ctl DeviceControl) (*MessageResponse, error) {
+	res, err := sipDeviceControl(&ctl)
+	log := &ControlLogs{
+		DeviceID:  ctl.DeviceID,
+		ChannelID: ctl.ChannelID,
+		Cmd:       ctl.Cmd,
+		Value:     ctl.Value,
+		Operator:  ctl.Operator,
+		IP:        ctl.IP,
+	}
+	if ctl.Cmd == ControlAlarm {
+		log.Value = fmt.Sprintf("%s/%s", ctl.AlarmMethod, ctl.AlarmType)
+	}
+	if res != nil {
+		log.Result = res.Result
+	}
+	if err != nil {
+		log.Error = err.Error()
+	}
+	if e := db.Create(db.DBClient, log); e != nil {
+		logrus.Errorln("save control log error,", e)
+	}
+	return res, err
+}
+
+func sipDeviceControl(ctl *DeviceControl) (*MessageResponse, error) {
+	device, to, deviceID, err := ctl.target()
+	if err != nil {
+		return nil, err
+	}
+	sn := utils.RandInt(100000, 999999)
+	body, err := ctl.body(deviceID, sn)
+	if err != nil {
+		return nil, err
+	}
+	if ctl.Cmd == ControlTeleBoot || ctl.Cmd == ControlIFame {
+		if err := sipMessage(device, to, body); err != nil {
+			return nil, err
+		}
+		return &MessageResponse{CmdType: "DeviceControl", SN: sn, DeviceID: deviceID, Result: "OK"}, nil
+	}
+	res, err := sipMessageQuery(device, to, "DeviceControl", deviceID, sn, body)
+	if err != nil {
+		return nil, err
+	}
+	return parseMessageResponse(res)
+}
@@ -1,6 +1,7 @@
 package sipapi
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"net"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/panjjo/gosip/db"
 	"github.com/panjjo/gosip/m"
+	"github.com/panjjo/gosip/media"
 	sip "github.com/panjjo/gosip/sip/s"
 	"github.com/panjjo/gosip/utils"
 	"github.com/sirupsen/logrus"
@@ -58,6 +60,12 @@ type Devices struct {
 	PWD string `json:"pwd" gorm:"column:pwd"`
 	// Source
 	Source string `json:"source"  gorm:"column:source"`
+	// Onvif onvif接入设备的设备服务地址
+	Onvif string `json:"onvif"  gorm:"column:onvif"`
+	// HeartBeatInterval 设备心跳间隔(秒)，查询或设置设备基本参数时更新
+	HeartBeatInterval int `json:"heartbeatinterval"  gorm:"column:heartbeatinterval"`
+	// HeartBeatCount 设备心跳超时次数
+	HeartBeatCount int `json:"heartbeatcount"  gorm:"column:heartbeatcount"`
 
 	Sys m.SysInfo `json:"sysinfo" gorm:"-"`
 
@@ -89,6 +97,8 @@ type Channels struct {
 	Secrecy     int    `xml:"Secrecy" json:"secrecy"  gorm:"column:secrecy"`
 	// Status 状态  on 在线
 	Status string `xml:"Status"  json:"status"  gorm:"column:status"`
+	// DutyStatus 报警通道布防状态 ONDUTY/OFFDUTY/ALARM
+	DutyStatus string `json:"dutystatus"  gorm:"column:dutystatus"`
 	// Active 最后活跃时间
 	Active int64  `json:"active"  gorm:"column:active"`
 	URIStr string ` json:"uri"  gorm:"column:uri"`
@@ -103,26 +113,40 @@ type Channels struct {
 	FPS int `json:"fps"  gorm:"column:fps"`
 	//  pull 媒体服务器主动拉流，push 监控设备主动推流
 	StreamType string `json:"streamtype" gorm:"column:streamtype;default:'push'"`
-	// streamtype=pull时，拉流地址
+	// streamtype=pull时，拉流地址 rtsp/rtmp/http-flv
 	URL string `json:"url"  gorm:"column:url"`
+	// 拉流账号
+	PullUsername string `json:"pullusername"  gorm:"column:pullusername"`
+	// 拉流密码
+	PullPassword string `json:"-"  gorm:"column:pullpassword"`
+	// rtsp拉流方式 0 tcp 1 udp 2 组播
+	PullRtpType int `json:"pullrtptype"  gorm:"column:pullrtptype"`
+	// 拉流失败重试次数，0或-1为无限重试
+	PullRetry int `json:"pullretry"  gorm:"column:pullretry"`
+	// 媒体流传输方式 udp/tcp_passive/tcp_active，为空时使用配置的默认方式
+	Transport string `json:"transport"  gorm:"column:transport"`
+	// onvif接入通道对应的媒体配置token
+	ProfileToken string `json:"profiletoken"  gorm:"column:profiletoken"`
+	// 媒体服务器节点分区，为空时按负载选择节点
+	Zone string `json:"zone"  gorm:"column:zone"`
 
 	addr *sip.Address `gorm:"-"`
 }
 
 // 同步摄像头编码格式
 func SyncDevicesCodec(ssrc, deviceid string) {
-	resp := zlmGetMediaList(zlmGetMediaListReq{streamID: ssrc})
-	if resp.Code != 0 {
-		logrus.Errorln("syncDevicesCodec fail", ssrc, resp)
+	resp, err := streamIDMedia(ssrc).GetMediaList(context.Background(), media.MediaListReq{Stream: ssrc})
+	if err != nil {
+		logrus.Errorln("syncDevicesCodec fail", ssrc, err)
 		return
 	}
-	if len(resp.Data) == 0 {
-		logrus.Errorln("syncDevicesCodec fail", ssrc, "not found data", resp)
+	if len(resp) == 0 {
+		logrus.Errorln("syncDevicesCodec fail", ssrc, "not found data")
 		return
 	}
-	for _, data := range resp.Data {
+	for _, data := range resp {
 		if len(data.Tracks) == 0 {
-			logrus.Errorln("syncDevicesCodec fail", ssrc, "not found tracks", resp)
+			logrus.Errorln("syncDevicesCodec fail", ssrc, "not found tracks", data)
 		}
 
 		for _, track := range data.Tracks {
@@ -246,7 +270,7 @@ func SipCatalog(to Devices) {
 }
 
 // sipPTZControl 向设备发送云台控制指令
-func SipPTZControl(device Devices, ptzCmd string) error {
+func SipPTZControl(device Devices, ptzCmd string, priority int) error {
 	hb := sip.NewHeaderBuilder().
 		SetTo(device.addr).
 		SetFrom(_serverDevices.addr).
@@ -261,7 +285,7 @@ func SipPTZControl(device Devices, ptzCmd string) error {
 
 	req := sip.NewRequest(
 		"", sip.MESSAGE, toAddr.URI, sip.DefaultSipVersion, hb.Build(),
-		sip.GetPTZControlXML(device.DeviceID, ptzCmd),
+		sip.GetPTZControlXML(device.DeviceID, ptzCmd, priority),
 	)
 	req.SetDestination(device.source)
 
@@ -0,0 +1,207 @@
+package sipapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/panjjo/gosip/db"
+	"github.com/panjjo/gosip/media"
+	"github.com/panjjo/gosip/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DownloadSpeedDefault 未指定下载倍速时使用的倍速
+	DownloadSpeedDefault = 4
+	// 下载进度刷新间隔
+	downloadCheckInterval = 5 * time.Second
+)
+
+// 下载支持的倍速
+var downloadSpeeds = []int{1, 2, 4, 8}
+
+// 进行中的下载 key=streamid，流关闭后保留到录制文件生成
+var _downloads sync.Map
+
+// 下载录制开始、结束串行处理
+var _downloadLock sync.Mutex
+
+// SipDownload 下载设备存储的历史媒体文件，设备按倍速推流，zlm录制为mp4文件
+func SipDownload(channelID string, start, end int64, speed int) (*Streams, error) {
+	if speed == 0 {
+		speed = DownloadSpeedDefault
+	}
+	valid := false
+	for _, v := range downloadSpeeds {
+		if v == speed {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, fmt.Errorf("不支持的下载倍速:%d", speed)
+	}
+	if start <= 0 || end <= start {
+		return nil, errors.New("下载时间范围错误")
+	}
+	data := &Streams{
+		T:         2,
+		ChannelID: channelID,
+		S:         time.Unix(start, 0),
+		E:         time.Unix(end, 0),
+		Speed:     speed,
+		Ttag:      db.M{},
+		Ftag:      db.M{},
+	}
+	data, err := SipPlay(data)
+	if err != nil {
+		return nil, err
+	}
+	_downloads.Store(data.StreamID, data)
+	go downloadWatch(data)
+	return data, nil
+}
+
+// DownloadRegisted 下载流注册到zlm后开始录制
+func DownloadRegisted(streamID string) {
+	if v, ok := _downloads.Load(streamID); ok {
+		go downloadRecord(v.(*Streams))
+	}
+}
+
+// IsDownloadStream 是否为下载流
+func IsDownloadStream(streamID string) bool {
+	_, ok := _downloads.Load(streamID)
+	return ok
+}
+
+// DownloadRecorded zlm录制文件生成，更新文件记录并通知下载完成，非下载流返回false
+// mediaServerID 为上报录制完成的节点，流所在节点已移除时使用该节点生成下载地址
+func DownloadRecorded(mediaServerID, streamID, file string, size int64) bool {
+	v, ok := _downloads.LoadAndDelete(streamID)
+	if !ok {
+		return false
+	}
+	data := v.(*Streams)
+	if data.FileID == "" {
+		return true
+	}
+	node, ok := findMediaNode(data.MediaServerID)
+	if !ok {
+		node, ok = findMediaNode(mediaServerID)
+	}
+	f := Files{
+		FID:       data.FileID,
+		ChannelID: data.ChannelID,
+		Stream:    data.StreamID,
+		File:      file,
+		Size:      size,
+		End:       time.Now().Unix(),
+		Status:    1,
+	}
+	if ok {
+		f.URL = fmt.Sprintf("%s/%s", node.HTTP, file)
+	} else {
+		logrus.Warnln("download recorded media node not found,", data.StreamID, data.MediaServerID, mediaServerID)
+	}
+	db.UpdateAll(db.DBClient, new(Files), db.M{"fid=?": f.FID}, db.M{"end": f.End, "status": f.Status, "file": f.File, "url": f.URL, "size": f.Size})
+	logrus.Infoln("download recorded,", data.StreamID, data.ChannelID, f.URL, data.Progress)
+	notify(notifyRecordDownloadDone(data, f))
+	return true
+}
+
+// 下载录制参数
+func downloadRecordReq(data *Streams) media.RecordReq {
+	return media.RecordReq{Type: media.RecordTypeMP4, App: "rtp", Stream: data.StreamID}
+}
+
+// 开始录制下载流，已开始录制的跳过
+func downloadRecord(data *Streams) {
+	_downloadLock.Lock()
+	defer _downloadLock.Unlock()
+	if data.FileID != "" {
+		return
+	}
+	req := downloadRecordReq(data)
+	// 下载时段内不切片，保证生成一个完整文件
+	req.MaxSecond = data.E.Unix() - data.S.Unix() + 60
+	if err := streamMedia(data).StartRecord(context.Background(), req); err != nil {
+		logrus.Warningln("download start record fail,", data.StreamID, err)
+		data.Msg = fmt.Sprintf("录制失败:%v", err)
+		return
+	}
+	file := &Files{
+		FID:       utils.RandString(32),
+		ChannelID: data.ChannelID,
+		Stream:    data.StreamID,
+		Start:     time.Now().Unix(),
+	}
+	if err := db.Create(db.DBClient, file); err != nil {
+		logrus.Errorln("download create file fail,", data.StreamID, err)
+	}
+	data.FileID = file.FID
+	db.Save(db.DBClient, data)
+}
+
+// 根据zlm中流的时长计算下载进度，流关闭后结束
+func downloadWatch(data *Streams) {
+	tick := time.NewTicker(downloadCheckInterval)
+	defer tick.Stop()
+	total := data.E.Unix() - data.S.Unix()
+	for range tick.C {
+		if _, ok := StreamList.Response.Load(data.StreamID); !ok {
+			if data.FileID == "" {
+				// 未开始录制就关闭，不会再有录制文件生成
+				_downloads.Delete(data.StreamID)
+			}
+			return
+		}
+		medias, err := streamMedia(data).GetMediaList(context.Background(), media.MediaListReq{App: "rtp", Stream: data.StreamID, Schema: "rtmp"})
+		if err != nil {
+			logrus.Warnln("download get media fail,", data.StreamID, err)
+			continue
+		}
+		for _, info := range medias {
+			// 错过流注册通知时在此开始录制
+			downloadRecord(data)
+			var duration int64
+			for _, track := range info.Tracks {
+				if track.Duration > duration {
+					duration = track.Duration
+				}
+			}
+			progress := float64(duration) / 10 / float64(total)
+			if progress > 99 {
+				// 以设备发送结束通知为准
+				progress = 99
+			}
+			if progress > data.Progress {
+				data.Progress = progress
+				db.Save(db.DBClient, data)
+			}
+		}
+	}
+}
+
+// 设备通知下载文件发送结束，停止录制并关闭流
+func sipDownloadEOF(data *Streams) {
+	_downloadLock.Lock()
+	data.Progress = 100
+	data.Msg = "下载完成"
+	if data.FileID != "" {
+		if err := streamMedia(data).StopRecord(context.Background(), downloadRecordReq(data)); err != nil {
+			logrus.Warningln("download stop record fail,", data.StreamID, err)
+		}
+	}
+	_downloadLock.Unlock()
+	SipStopPlay(data.StreamID)
+	if data.FileID == "" {
+		// 未收到流，没有录制文件生成
+		_downloads.Delete(data.StreamID)
+		data.Msg = "下载失败，未收到媒体流"
+	}
+	db.Save(db.DBClient, data)
+}
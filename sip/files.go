@@ -1,15 +1,18 @@
 package sipapi
 
 import (
+	"context"
 	"errors"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/panjjo/gosip/db"
 	"github.com/panjjo/gosip/m"
+	"github.com/panjjo/gosip/media"
 	"github.com/panjjo/gosip/utils"
 )
 
@@ -55,7 +58,7 @@ func (ri *apiRecordItem) Start() (string, interface{}) {
 		return m.StatusSysERR, errors.New("config record max time invalid.")
 	}
 
-	err := zlmStartRecord(ri.params)
+	err := _media.StartRecord(context.Background(), recordReq(ri.params))
 	if err != nil {
 		return m.StatusParamsERR, err
 	}
@@ -86,13 +89,19 @@ func (ri *apiRecordItem) Start() (string, interface{}) {
 	return m.StatusSucc, ri.id
 }
 func (ri *apiRecordItem) Stop() (string, interface{}) {
-	err := zlmStopRecord(ri.params)
+	err := _media.StopRecord(context.Background(), recordReq(ri.params))
 	if err != nil {
 		return m.StatusSysERR, ""
 	}
 	return m.StatusSucc, ""
 }
 
+// 录制请求参数转换为媒体服务器录制参数
+func recordReq(values url.Values) media.RecordReq {
+	t, _ := strconv.Atoi(values.Get("type"))
+	return media.RecordReq{Type: t, Vhost: values.Get("vhost"), App: values.Get("app"), Stream: values.Get("stream")}
+}
+
 func (ri *apiRecordItem) Down(url string) {
 	db.UpdateAll(db.DBClient, new(Files), db.M{"id=?": ri.id}, db.M{"end": time.Now().Unix(), "status": 1, "file": url})
 }
@@ -111,6 +120,12 @@ type Files struct {
 	Status int    `json:"status" bson:"status"`
 	File   string `json:"file" bson:"file"`
 	Clear  bool   `json:"clear" bson:"clear"`
+	// 下载文件所属通道
+	ChannelID string `json:"channelid" bson:"channelid"`
+	// 文件下载地址
+	URL string `json:"url" bson:"url"`
+	// 文件大小，单位字节
+	Size   int64 `json:"size" bson:"size"`
 	params url.Values
 }
 
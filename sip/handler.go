@@ -67,6 +67,55 @@ func handlerMessage(req *sip.Request, tx *sip.Transaction) {
 		sipMessageDeviceInfo(u, body)
 		tx.Respond(sip.NewResponseFromRequest("", req, http.StatusOK, "OK", nil))
 		return
+	case "DeviceStatus":
+		// 设备状态
+		sipMessageDeviceStatus(u, body)
+		tx.Respond(sip.NewResponseFromRequest("", req, http.StatusOK, "OK", nil))
+		return
+	case "ConfigDownload":
+		// 设备配置查询
+		sipMessageConfigDownload(u, body)
+		tx.Respond(sip.NewResponseFromRequest("", req, http.StatusOK, "OK", nil))
+		return
+	case "DeviceConfig":
+		// 设备配置应答
+		sipMessageResponse(u, body)
+		tx.Respond(sip.NewResponseFromRequest("", req, http.StatusOK, "OK", nil))
+		return
+	case "DeviceControl":
+		// 设备控制应答
+		sipMessageResponse(u, body)
+		tx.Respond(sip.NewResponseFromRequest("", req, http.StatusOK, "OK", nil))
+		return
+	case "UploadSnapShotFinished":
+		// 抓拍图片上传完成
+		sipMessageUploadSnapShotFinished(u, body)
+		tx.Respond(sip.NewResponseFromRequest("", req, http.StatusOK, "OK", nil))
+		return
+	case "DeviceUpgradeResult":
+		// 设备升级结果
+		sipMessageDeviceUpgradeResult(u, body)
+		tx.Respond(sip.NewResponseFromRequest("", req, http.StatusOK, "OK", nil))
+		return
+	case "PresetQuery":
+		// 预置位查询
+		sipMessagePresetQuery(u, body)
+		tx.Respond(sip.NewResponseFromRequest("", req, http.StatusOK, "OK", nil))
+		return
+	case "MediaStatus":
+		// 媒体通知，回放文件发送结束
+		callID := ""
+		if v, ok := req.CallID(); ok {
+			callID = string(*v)
+		}
+		sipMessageMediaStatus(u, callID, body)
+		tx.Respond(sip.NewResponseFromRequest("", req, http.StatusOK, "OK", nil))
+		return
+	case "Broadcast":
+		// 语音广播应答
+		sipMessageBroadcast(u, body)
+		tx.Respond(sip.NewResponseFromRequest("", req, http.StatusOK, "OK", nil))
+		return
 	}
 	tx.Respond(sip.NewResponseFromRequest("", req, http.StatusBadRequest, http.StatusText(http.StatusBadRequest), nil))
 }
@@ -100,6 +149,7 @@ func handlerRegister(req *sip.Request, tx *sip.Transaction) {
 				// 记录活跃设备
 				user.source = fromUser.source
 				user.addr = fromUser.addr
+				user.ActiveAt = time.Now().Unix()
 				_activeDevices.Store(user.DeviceID, user)
 				if !user.Regist {
 					// 第一次激活，保存数据库
@@ -205,6 +255,18 @@ func handlerBye(req *sip.Request, tx *sip.Transaction) {
 	if callID, ok := req.CallID(); ok {
 		logrus.Infof("设备 %s 请求结束会话: CallID=%s", fromUser.DeviceID, string(*callID))
 
+		// 语音广播由设备发起，设备结束广播时不需要再发送BYE
+		if b := broadcastByCallID(string(*callID)); b != nil {
+			go b.close(false)
+			tx.Respond(sip.NewResponseFromRequest("", req, http.StatusOK, "OK", nil))
+			return
+		}
+		if s := talkByCallID(string(*callID)); s != nil {
+			go s.close(false, "设备结束对讲")
+			tx.Respond(sip.NewResponseFromRequest("", req, http.StatusOK, "OK", nil))
+			return
+		}
+
 		// 查找并停止相关流
 		go func() {
 			// 遍历活跃流，找到匹配的CallID并停止
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/panjjo/gosip/db"
+	"github.com/panjjo/gosip/m"
 	"github.com/panjjo/gosip/utils"
 	"github.com/sirupsen/logrus"
 )
@@ -32,6 +33,9 @@ func sipMessageKeepalive(u Devices, body []byte) error {
 	}
 	if message.Status == "OK" {
 		device.ActiveAt = time.Now().Unix()
+		u.ActiveAt = device.ActiveAt
+		u.HeartBeatInterval = device.HeartBeatInterval
+		u.HeartBeatCount = device.HeartBeatCount
 		_activeDevices.Store(u.DeviceID, u)
 	} else {
 		device.ActiveAt = -1
@@ -49,3 +53,95 @@ func sipMessageKeepalive(u Devices, body []byte) error {
 	})
 	return err
 }
+
+// 设备心跳超时时间(秒)，超过此时间未收到心跳的设备视为离线
+// 以设备的心跳间隔*心跳超时次数为准，未知时使用配置
+func deviceKeepaliveTimeout(device Devices) int64 {
+	if device.HeartBeatInterval > 0 && device.HeartBeatCount > 0 {
+		return int64(device.HeartBeatInterval * device.HeartBeatCount)
+	}
+	return int64(config.Keepalive)
+}
+
+// 更新设备的心跳参数，设备在线时同步到活跃设备
+func updateDeviceHeartBeat(deviceID string, interval, count int) {
+	if interval <= 0 && count <= 0 {
+		return
+	}
+	update := db.M{}
+	if interval > 0 {
+		update["heartbeatinterval"] = interval
+	}
+	if count > 0 {
+		update["heartbeatcount"] = count
+	}
+	db.UpdateAll(db.DBClient, new(Devices), db.M{"deviceid=?": deviceID}, update)
+	if device, ok := _activeDevices.Get(deviceID); ok {
+		if interval > 0 {
+			device.HeartBeatInterval = interval
+		}
+		if count > 0 {
+			device.HeartBeatCount = count
+		}
+		_activeDevices.Store(deviceID, device)
+	}
+}
+
+// CheckDevices 定时检查设备在线状态
+// 检查规则：
+// 1. 超过心跳超时时间未收到心跳的设备标记为离线，同时设备下的通道标记为离线
+// 2. 在线设备发送DeviceStatus查询，根据设备返回的状态校正通道状态
+func CheckDevices() {
+	logrus.Debugln("checkDevicesWithCron")
+	now := time.Now().Unix()
+	_activeDevices.Range(func(key, value any) bool {
+		device := value.(Devices)
+		if now-device.ActiveAt > deviceKeepaliveTimeout(device) {
+			logrus.Infoln("checkDevices keepalive timeout,deviceid:", device.DeviceID, "active:", device.ActiveAt)
+			deviceOffline(device)
+			return true
+		}
+		go func() {
+			if _, err := SipDeviceStatus(device); err != nil {
+				logrus.Warnln("checkDevices DeviceStatus fail,deviceid:", device.DeviceID, "err:", err)
+			}
+		}()
+		return true
+	})
+}
+
+// 设备离线，移除活跃设备并将设备下通道标记为离线
+func deviceOffline(device Devices) {
+	_activeDevices.Delete(device.DeviceID)
+	db.UpdateAll(db.DBClient, new(Devices), db.M{"deviceid=?": device.DeviceID}, db.M{"active": -1})
+	db.UpdateAll(db.DBClient, new(Channels), db.M{"deviceid=?": device.DeviceID}, db.M{"status": m.DeviceStatusOFF})
+	go notify(notifyDevicesAcitve(device.DeviceID, "OFFLINE"))
+}
+
+// 设备在线，使用请求中解析出的设备地址刷新活跃设备，通道状态以目录和通道状态上报为准
+func deviceOnline(u Devices) {
+	device, ok := _activeDevices.Get(u.DeviceID)
+	if !ok {
+		device = Devices{DeviceID: u.DeviceID}
+		if err := db.Get(db.DBClient, &device); err != nil {
+			logrus.Warnln("deviceOnline device not found,deviceid:", u.DeviceID, err)
+			return
+		}
+	}
+	u.ActiveAt = time.Now().Unix()
+	u.HeartBeatInterval = device.HeartBeatInterval
+	u.HeartBeatCount = device.HeartBeatCount
+	_activeDevices.Store(u.DeviceID, u)
+	db.UpdateAll(db.DBClient, new(Devices), db.M{"deviceid=?": u.DeviceID}, Devices{
+		Host:     u.Host,
+		Port:     u.Port,
+		Rport:    u.Rport,
+		RAddr:    u.RAddr,
+		Source:   u.Source,
+		URIStr:   u.URIStr,
+		ActiveAt: u.ActiveAt,
+	})
+	if !ok {
+		go notify(notifyDevicesAcitve(u.DeviceID, "OK"))
+	}
+}
@@ -0,0 +1,512 @@
+package sipapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/panjjo/gosip/db"
+	"github.com/panjjo/gosip/m"
+	"github.com/panjjo/gosip/media"
+	"github.com/sirupsen/logrus"
+)
+
+var errNoMediaNode = errors.New("没有可用的媒体服务器节点")
+
+const (
+	// 连续检查失败次数达到此值且心跳超时，节点判定为离线
+	mediaNodeMaxFails = 3
+	// 心跳超时时间，单位秒
+	mediaNodeKeepaliveTimeout = 30
+)
+
+// MediaNode 媒体服务器节点
+type MediaNode struct {
+	ID string `json:"id"`
+	// 节点分区
+	Zone string `json:"zone"`
+	// 播放地址前缀
+	HTTP string `json:"http"`
+	WS   string `json:"ws"`
+	RTMP string `json:"rtmp"`
+	RTSP string `json:"rtsp"`
+	// 加密播放地址前缀，未配置时为空
+	HTTPS string `json:"https"`
+	WSS   string `json:"wss"`
+	RTMPS string `json:"rtmps"`
+	RTSPS string `json:"rtsps"`
+	// 接收rtp推流的地址
+	RTP string `json:"rtp"`
+	// 节点是否可用
+	Online bool `json:"online"`
+	// 节点上的国标流数量
+	Streams int `json:"streams"`
+	// 节点收流总码率，单位 bytes/s
+	BytesSpeed int `json:"bytesspeed"`
+	// 最后一次收到心跳或查询成功的时间
+	Active int64 `json:"active"`
+	// 是否来自配置文件，否则为zlm启动通知注册
+	Static bool `json:"static"`
+
+	rtpIP  net.IP
+	driver media.MediaServer
+	secret string
+	fails  int // 连续检查失败次数
+	// 节点最近一次统计的各直播流观看人数 key=streamid
+	readers map[string]int
+}
+
+type mediaNodeList struct {
+	mu    sync.RWMutex
+	nodes map[string]*MediaNode
+}
+
+// 媒体服务器节点 key=节点id
+var _mediaNodes = &mediaNodeList{nodes: map[string]*MediaNode{}}
+
+// 根据配置创建节点，rtp地址无法解析时返回错误
+func newMediaNode(cfg m.MediaServer) (*MediaNode, error) {
+	u, err := url.Parse(cfg.RTP)
+	if err != nil {
+		return nil, fmt.Errorf("media rtp url error,url:%s,err:%v", cfg.RTP, err)
+	}
+	ipaddr, err := net.ResolveIPAddr("ip", u.Hostname())
+	if err != nil {
+		return nil, fmt.Errorf("media rtp url error,url:%s,err:%v", cfg.RTP, err)
+	}
+	return &MediaNode{
+		ID:     cfg.ID,
+		Zone:   cfg.Zone,
+		HTTP:   publicURL(cfg.HTTP, cfg.PublicHost),
+		WS:     publicURL(cfg.WS, cfg.PublicHost),
+		RTMP:   publicURL(cfg.RTMP, cfg.PublicHost),
+		RTSP:   publicURL(cfg.RTSP, cfg.PublicHost),
+		HTTPS:  publicURL(cfg.HTTPS, cfg.PublicHost),
+		WSS:    publicURL(cfg.WSS, cfg.PublicHost),
+		RTMPS:  publicURL(cfg.RTMPS, cfg.PublicHost),
+		RTSPS:  publicURL(cfg.RTSPS, cfg.PublicHost),
+		RTP:    cfg.RTP,
+		rtpIP:  ipaddr.IP,
+		Online: true,
+		driver: media.NewZLM(cfg.RESTFUL, cfg.Secret, time.Duration(cfg.Timeout)*time.Second),
+		secret: cfg.Secret,
+	}, nil
+}
+
+// 使用对外主机替换地址前缀中的主机，保留协议和端口
+func publicURL(prefix, host string) string {
+	if prefix == "" || host == "" {
+		return prefix
+	}
+	u, err := url.Parse(prefix)
+	if err != nil {
+		return prefix
+	}
+	if port := u.Port(); port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	} else {
+		u.Host = host
+	}
+	return u.String()
+}
+
+// 按 stream 配置开启的协议生成流的播放地址，未开启的协议地址为空
+func (node *MediaNode) playURLs(data *Streams) {
+	id := data.StreamID
+	playURL := func(prefix, format string) string {
+		if prefix == "" {
+			return ""
+		}
+		return prefix + fmt.Sprintf(format, id)
+	}
+	data.HTTP, data.HTTPS, data.HLSFMP4, data.HTTPSHLSFMP4 = "", "", "", ""
+	data.RTMP, data.RTMPS, data.RTSP, data.RTSPS = "", "", "", ""
+	data.FLV, data.HTTPSFLV, data.WSFLV, data.WSSFLV = "", "", "", ""
+	data.FMP4, data.HTTPSFMP4, data.WSFMP4, data.WSSFMP4 = "", "", "", ""
+	data.WebRTC, data.WebRTCS = "", ""
+	if config.Stream.HLS {
+		data.HTTP = playURL(node.HTTP, "/rtp/%s/hls.m3u8")
+		data.HTTPS = playURL(node.HTTPS, "/rtp/%s/hls.m3u8")
+	}
+	if config.Stream.HLSFMP4 {
+		data.HLSFMP4 = playURL(node.HTTP, "/rtp/%s/hls.fmp4.m3u8")
+		data.HTTPSHLSFMP4 = playURL(node.HTTPS, "/rtp/%s/hls.fmp4.m3u8")
+	}
+	if config.Stream.RTMP {
+		data.RTMP = playURL(node.RTMP, "/rtp/%s")
+		data.RTMPS = playURL(node.RTMPS, "/rtp/%s")
+		data.RTSP = playURL(node.RTSP, "/rtp/%s")
+		data.RTSPS = playURL(node.RTSPS, "/rtp/%s")
+		data.FLV = playURL(node.HTTP, "/rtp/%s.live.flv")
+		data.HTTPSFLV = playURL(node.HTTPS, "/rtp/%s.live.flv")
+		data.WSFLV = playURL(node.WS, "/rtp/%s.live.flv")
+		data.WSSFLV = playURL(node.WSS, "/rtp/%s.live.flv")
+		if config.Stream.WebRTC {
+			data.WebRTC, data.WebRTCS = node.webrtcURLs("rtp", id, "play")
+		}
+	}
+	if config.Stream.FMP4 {
+		data.FMP4 = playURL(node.HTTP, "/rtp/%s.live.mp4")
+		data.HTTPSFMP4 = playURL(node.HTTPS, "/rtp/%s.live.mp4")
+		data.WSFMP4 = playURL(node.WS, "/rtp/%s.live.mp4")
+		data.WSSFMP4 = playURL(node.WSS, "/rtp/%s.live.mp4")
+	}
+}
+
+// 生成节点上流的 webrtc 信令地址，type 为 play/push，节点未配置 https 时加密地址为空
+func (node *MediaNode) webrtcURLs(app, stream, typ string) (string, string) {
+	webrtcURL := func(prefix string) string {
+		if prefix == "" {
+			return ""
+		}
+		return fmt.Sprintf("%s/index/api/webrtc?app=%s&stream=%s&type=%s", prefix, app, stream, typ)
+	}
+	return webrtcURL(node.HTTP), webrtcURL(node.HTTPS)
+}
+
+// 加载配置文件中的节点，media 为默认节点，对讲、广播等使用默认节点
+func loadMediaNodes() {
+	node, err := newMediaNode(config.Media)
+	if err != nil {
+		logrus.Fatalln(err)
+	}
+	node.Static = true
+	if _media == nil {
+		_media = node.driver
+	}
+	node.driver = _media
+	_sysinfo.MediaServerRtpIP = node.rtpIP
+	if u, err := url.Parse(config.Media.RTP); err == nil {
+		_sysinfo.MediaServerRtpPort, _ = strconv.Atoi(u.Port())
+	}
+	_mediaNodes.nodes[node.ID] = node
+
+	for _, cfg := range config.MediaNodes {
+		if cfg.ID == "" {
+			logrus.Warnln("media node id is empty,skip", cfg.RESTFUL)
+			continue
+		}
+		if _, ok := _mediaNodes.nodes[cfg.ID]; ok {
+			logrus.Warnln("media node id duplicate,skip", cfg.ID)
+			continue
+		}
+		node, err := newMediaNode(cfg)
+		if err != nil {
+			logrus.Warnln("media node load fail,", cfg.ID, err)
+			continue
+		}
+		node.Static = true
+		_mediaNodes.nodes[node.ID] = node
+	}
+}
+
+// 获取节点，不存在时返回默认节点
+func getMediaNode(id string) *MediaNode {
+	_mediaNodes.mu.RLock()
+	defer _mediaNodes.mu.RUnlock()
+	if node, ok := _mediaNodes.nodes[id]; ok {
+		return node
+	}
+	return _mediaNodes.nodes[config.Media.ID]
+}
+
+// 查找节点，不回退到默认节点
+func findMediaNode(id string) (*MediaNode, bool) {
+	_mediaNodes.mu.RLock()
+	defer _mediaNodes.mu.RUnlock()
+	node, ok := _mediaNodes.nodes[id]
+	return node, ok
+}
+
+// 流所在节点的媒体服务器
+func streamMedia(data *Streams) media.MediaServer {
+	return getMediaNode(data.MediaServerID).driver
+}
+
+// 按流id查找流所在节点的媒体服务器，流不存在时使用默认节点
+func streamIDMedia(streamID string) media.MediaServer {
+	if v, ok := StreamList.Response.Load(streamID); ok {
+		return streamMedia(v.(*Streams))
+	}
+	return _media
+}
+
+// 为新流选择节点：优先同分区的在线节点，再按流数量、码率选择负载最低的
+func selectMediaNode(zone string) (*MediaNode, error) {
+	counts := map[string]int{}
+	StreamList.Response.Range(func(key, value any) bool {
+		counts[value.(*Streams).MediaServerID]++
+		return true
+	})
+	_mediaNodes.mu.Lock()
+	defer _mediaNodes.mu.Unlock()
+	nodes := []*MediaNode{}
+	zoned := []*MediaNode{}
+	for _, node := range _mediaNodes.nodes {
+		if !node.Online {
+			continue
+		}
+		node.Streams = counts[node.ID]
+		nodes = append(nodes, node)
+		if zone != "" && node.Zone == zone {
+			zoned = append(zoned, node)
+		}
+	}
+	if len(zoned) > 0 {
+		nodes = zoned
+	}
+	if len(nodes) == 0 {
+		return nil, errNoMediaNode
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Streams != nodes[j].Streams {
+			return nodes[i].Streams < nodes[j].Streams
+		}
+		if nodes[i].BytesSpeed != nodes[j].BytesSpeed {
+			return nodes[i].BytesSpeed < nodes[j].BytesSpeed
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+	return nodes[0], nil
+}
+
+// MediaNodeStarted zlm启动通知，已知节点重启后原有的流已丢失，未知节点按通知中的配置注册
+// 通知来源未经鉴权，通知中的 api.secret 需与节点配置一致，未知节点需与默认节点一致才允许注册
+func MediaNodeStarted(id string, cfg m.MediaServer) error {
+	_mediaNodes.mu.Lock()
+	node, ok := _mediaNodes.nodes[id]
+	if ok {
+		if !mediaNodeSecretEqual(node.secret, cfg.Secret) {
+			_mediaNodes.mu.Unlock()
+			return errors.New("媒体节点密钥错误")
+		}
+		online := node.Online
+		node.Online = true
+		node.fails = 0
+		node.Active = time.Now().Unix()
+		snap := *node
+		_mediaNodes.mu.Unlock()
+		go func() {
+			streams := mediaNodeStreamsLost(id, "媒体节点重启")
+			if !online {
+				notify(notifyMediaNode(NotifyMethodMediaNodeUp, snap, streams, "媒体节点启动"))
+			}
+		}()
+		return nil
+	}
+	_mediaNodes.mu.Unlock()
+	if !mediaNodeSecretEqual(config.Media.Secret, cfg.Secret) {
+		return errors.New("未配置的媒体节点密钥错误")
+	}
+	cfg.ID = id
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = config.Media.Timeout
+	}
+	// 解析rtp地址可能较慢，在加锁前完成
+	node, err := newMediaNode(cfg)
+	if err != nil {
+		return err
+	}
+	node.Active = time.Now().Unix()
+	_mediaNodes.mu.Lock()
+	if _, ok := _mediaNodes.nodes[id]; ok {
+		// 并发的启动通知已完成注册
+		_mediaNodes.mu.Unlock()
+		return nil
+	}
+	_mediaNodes.nodes[id] = node
+	_mediaNodes.mu.Unlock()
+	logrus.Infoln("media node registed,", id, cfg.RESTFUL)
+	go notify(notifyMediaNode(NotifyMethodMediaNodeUp, *node, nil, "媒体节点注册"))
+	return nil
+}
+
+// 校验启动通知中的节点密钥，未配置密钥时不接受通知
+func mediaNodeSecretEqual(secret, reported string) bool {
+	return secret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(reported)) == 1
+}
+
+// MediaNodeKeepalive zlm心跳，未知节点返回false
+func MediaNodeKeepalive(id string) bool {
+	_mediaNodes.mu.Lock()
+	node, ok := _mediaNodes.nodes[id]
+	if !ok {
+		_mediaNodes.mu.Unlock()
+		return false
+	}
+	online := node.Online
+	node.Online = true
+	node.fails = 0
+	node.Active = time.Now().Unix()
+	snap := *node
+	_mediaNodes.mu.Unlock()
+	if !online {
+		logrus.Infoln("media node up by keepalive,", id)
+		go notify(notifyMediaNode(NotifyMethodMediaNodeUp, snap, nil, "收到媒体节点心跳"))
+	}
+	return true
+}
+
+// CheckMediaNodes 定时检查节点存活，离线节点上的流进行故障转移
+func CheckMediaNodes() {
+	_mediaNodes.mu.RLock()
+	nodes := make([]*MediaNode, 0, len(_mediaNodes.nodes))
+	for _, node := range _mediaNodes.nodes {
+		nodes = append(nodes, node)
+	}
+	_mediaNodes.mu.RUnlock()
+	for _, node := range nodes {
+		_, err := node.driver.GetServerConfig(context.Background())
+		_mediaNodes.mu.Lock()
+		online := node.Online
+		if err == nil {
+			node.Online = true
+			node.fails = 0
+			node.Active = time.Now().Unix()
+			snap := *node
+			_mediaNodes.mu.Unlock()
+			if !online {
+				logrus.Infoln("media node up,", node.ID)
+				notify(notifyMediaNode(NotifyMethodMediaNodeUp, snap, nil, "媒体节点恢复"))
+			}
+			continue
+		}
+		node.fails++
+		down := online && node.fails >= mediaNodeMaxFails && time.Now().Unix()-node.Active > mediaNodeKeepaliveTimeout
+		if down {
+			node.Online = false
+		}
+		snap := *node
+		_mediaNodes.mu.Unlock()
+		logrus.Warnln("check media node fail,", snap.ID, snap.fails, err)
+		if down {
+			reason := fmt.Sprintf("媒体节点离线:%v", err)
+			logrus.Errorln("media node down,", node.ID, err)
+			streams := mediaNodeStreamsLost(node.ID, reason)
+			notify(notifyMediaNode(NotifyMethodMediaNodeDown, snap, streams, reason))
+		}
+	}
+}
+
+// 节点上的流已中断：通知设备停止推流，有观看者的直播在可用节点上重新点播，返回中断的流id
+func mediaNodeStreamsLost(id, reason string) []string {
+	streams := []*Streams{}
+	StreamList.Response.Range(func(key, value any) bool {
+		if play := value.(*Streams); play.MediaServerID == id {
+			streams = append(streams, play)
+		}
+		return true
+	})
+	ids := make([]string, 0, len(streams))
+	for _, play := range streams {
+		ids = append(ids, play.StreamID)
+		replay := play.T == 0 && (mediaNodeReaders(id, play.StreamID) > 0 || StreamViewers(play.StreamID) > 0)
+		sipStreamBroken(play, reason)
+		if replay {
+			go mediaNodeReplay(play)
+		}
+	}
+	if len(ids) > 0 {
+		logrus.Warnln("media node streams lost,", id, reason, ids)
+	}
+	return ids
+}
+
+// 节点上直播流最近一次统计的观看人数
+func mediaNodeReaders(id, streamID string) int {
+	_mediaNodes.mu.RLock()
+	defer _mediaNodes.mu.RUnlock()
+	if node, ok := _mediaNodes.nodes[id]; ok {
+		return node.readers[streamID]
+	}
+	return 0
+}
+
+// 流因媒体节点异常中断，节点不可用，只通知设备并释放资源
+func sipStreamBroken(play *Streams, reason string) {
+	StreamList.Response.Delete(play.StreamID)
+	if play.T == 0 {
+		StreamList.Succ.Delete(play.ChannelID)
+	}
+	_downloads.Delete(play.StreamID)
+	if play.StreamType == m.StreamTypePush && play.Resp != nil {
+		if u, ok := _activeDevices.Load(play.DeviceID); ok {
+			if err := sipPlayBye(u.(Devices), play.Resp); err != nil {
+				logrus.Warnln("sipStreamBroken bye fail.id:", play.DeviceID, play.ChannelID, "err:", err)
+			}
+		}
+	}
+	play.Stream = false
+	play.Status = 1
+	play.Stop = true
+	play.Msg = reason
+	db.Save(db.DBClient, play)
+	_ssrcPool.release(play.SSRC)
+}
+
+// 在可用节点上重新点播中断的直播
+func mediaNodeReplay(play *Streams) {
+	data := &Streams{ChannelID: play.ChannelID, Ttag: db.M{}, Ftag: db.M{}}
+	data.SetTransport(play.transport)
+	if _, err := SipPlay(data); err != nil {
+		logrus.Warnln("media node failover replay fail,", play.StreamID, play.ChannelID, err)
+		return
+	}
+	logrus.Infoln("media node failover replay,", play.StreamID, play.MediaServerID, "->", data.MediaServerID)
+}
+
+// MediaNodes 当前所有节点
+func MediaNodes() []MediaNode {
+	counts := map[string]int{}
+	StreamList.Response.Range(func(key, value any) bool {
+		counts[value.(*Streams).MediaServerID]++
+		return true
+	})
+	_mediaNodes.mu.Lock()
+	defer _mediaNodes.mu.Unlock()
+	res := make([]MediaNode, 0, len(_mediaNodes.nodes))
+	for _, node := range _mediaNodes.nodes {
+		node.Streams = counts[node.ID]
+		res = append(res, *node)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
+
+// RefreshMediaNodes 定时查询各节点收流码率，用于负载选择
+func RefreshMediaNodes() {
+	_mediaNodes.mu.RLock()
+	nodes := make([]*MediaNode, 0, len(_mediaNodes.nodes))
+	for _, node := range _mediaNodes.nodes {
+		nodes = append(nodes, node)
+	}
+	_mediaNodes.mu.RUnlock()
+	for _, node := range nodes {
+		medias, err := node.driver.GetMediaList(context.Background(), media.MediaListReq{Schema: "rtmp"})
+		if err != nil {
+			logrus.Warnln("refresh media node fail,", node.ID, err)
+			continue
+		}
+		speed := 0
+		readers := map[string]int{}
+		for _, info := range medias {
+			speed += info.BytesSpeed
+			if info.App == "rtp" {
+				// 记录观看人数，节点故障时只恢复有人观看的直播
+				readers[info.Stream] += info.ReaderCount
+			}
+		}
+		_mediaNodes.mu.Lock()
+		node.BytesSpeed = speed
+		node.readers = readers
+		node.Active = time.Now().Unix()
+		_mediaNodes.mu.Unlock()
+	}
+}
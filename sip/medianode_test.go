@@ -0,0 +1,40 @@
+package sipapi
+
+import (
+	"testing"
+
+	"github.com/panjjo/gosip/m"
+)
+
+func TestMediaNodeStarted(t *testing.T) {
+	testSetup(t)
+	config.Media.Secret = "secret"
+	_mediaNodes.nodes[config.Media.ID].secret = "secret"
+	cfg := m.MediaServer{HTTP: "http://127.0.0.1:18080", RTP: "http://127.0.0.1:10000"}
+
+	// 未知节点密钥与默认节点不一致时不注册
+	cfg.Secret = "wrong"
+	if err := MediaNodeStarted("zlm2", cfg); err == nil {
+		t.Fatal("node with wrong secret registered")
+	}
+	if _, ok := findMediaNode("zlm2"); ok {
+		t.Fatal("node with wrong secret registered")
+	}
+	cfg.Secret = "secret"
+	if err := MediaNodeStarted("zlm2", cfg); err != nil {
+		t.Fatal(err)
+	}
+	node, ok := findMediaNode("zlm2")
+	if !ok || node.HTTP != cfg.HTTP || node.rtpIP.String() != "127.0.0.1" {
+		t.Fatalf("node not registered: %+v", node)
+	}
+
+	// 已知节点的启动通知同样校验密钥
+	_mediaNodes.nodes[config.Media.ID].Online = false
+	if err := MediaNodeStarted(config.Media.ID, m.MediaServer{Secret: "wrong"}); err == nil {
+		t.Fatal("known node accepted wrong secret")
+	}
+	if node, _ := findMediaNode(config.Media.ID); node.Online {
+		t.Fatal("known node marked online by forged notify")
+	}
+}
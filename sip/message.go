@@ -0,0 +1,125 @@
+package sipapi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	sip "github.com/panjjo/gosip/sip/s"
+	"github.com/panjjo/gosip/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// 等待设备应答的默认超时时间
+const messageWaitTimeout = 10 * time.Second
+
+// 等待设备通过 MESSAGE 异步返回的应答
+// key=cmdtype+deviceid+sn value=chan []byte
+var _messageWaits *sync.Map
+
+func messageWaitKey(cmdType, deviceID string, sn int) string {
+	return fmt.Sprintf("%s%s%d", cmdType, deviceID, sn)
+}
+
+// sipMessage 向设备发送 MESSAGE 请求，to 为空时直接发送给设备本身
+func sipMessage(device Devices, to *sip.Address, body []byte) error {
+	if to == nil {
+		to = device.addr
+	}
+	hb := sip.NewHeaderBuilder().SetTo(to).SetFrom(_serverDevices.addr).AddVia(&sip.ViaHop{
+		Params: sip.NewParams().Add("branch", sip.String{Str: sip.GenerateBranch()}),
+	}).SetContentType(&sip.ContentTypeXML).SetMethod(sip.MESSAGE)
+	req := sip.NewRequest("", sip.MESSAGE, to.URI, sip.DefaultSipVersion, hb.Build(), body)
+	req.SetDestination(device.source)
+	// 根据设备的传输方式发送请求
+	var tx *sip.Transaction
+	var err error
+	if strings.ToLower(device.TransPort) == "tcp" {
+		tx, err = srv.RequestWithProtocol(req, "tcp")
+	} else {
+		tx, err = srv.Request(req) // 默认UDP
+	}
+	if err != nil {
+		return err
+	}
+	_, err = sipResponse(tx)
+	return err
+}
+
+// sipMessageQuery 发送 MESSAGE 请求并等待设备返回对应 CmdType 和 SN 的应答消息体
+func sipMessageQuery(device Devices, to *sip.Address, cmdType, deviceID string, sn int, body []byte) ([]byte, error) {
+	key := messageWaitKey(cmdType, deviceID, sn)
+	resp := make(chan []byte, 1)
+	_messageWaits.Store(key, resp)
+	defer _messageWaits.Delete(key)
+
+	if err := sipMessage(device, to, body); err != nil {
+		return nil, err
+	}
+	tick := time.NewTicker(messageWaitTimeout)
+	defer tick.Stop()
+	select {
+	case res := <-resp:
+		return res, nil
+	case <-tick.C:
+		return nil, errors.New("等待设备应答超时")
+	}
+}
+
+// sipMessageReply 将设备返回的应答投递给等待方，不存在等待方时返回false
+func sipMessageReply(cmdType, deviceID string, sn int, body []byte) bool {
+	if v, ok := _messageWaits.Load(messageWaitKey(cmdType, deviceID, sn)); ok {
+		select {
+		case v.(chan []byte) <- body:
+		default:
+			logrus.Warnln("sipMessageReply duplicate response", cmdType, deviceID, sn)
+		}
+		return true
+	}
+	return false
+}
+
+// MessageResponse 设备控制、配置类应答通用结构
+type MessageResponse struct {
+	CmdType  string `xml:"CmdType" json:"cmdtype"`
+	SN       int    `xml:"SN" json:"sn"`
+	DeviceID string `xml:"DeviceID" json:"deviceid"`
+	// Result 执行结果 OK/ERROR
+	Result string `xml:"Result" json:"result"`
+}
+
+// sipMessageResponse 设备控制、配置类应答，投递给等待方
+func sipMessageResponse(_ Devices, body []byte) error {
+	message := &MessageResponse{}
+	if err := utils.XMLDecode(body, message); err != nil {
+		logrus.Errorln("sipMessageResponse Unmarshal xml err:", err, "body:", string(body))
+		return err
+	}
+	if !sipMessageReply(message.CmdType, message.DeviceID, message.SN, body) {
+		logrus.Infoln("sipMessageResponse not found wait,", message.CmdType, message.DeviceID, message.SN)
+	}
+	return nil
+}
+
+// 解析设备控制、配置类应答结果
+func parseMessageResponse(body []byte) (*MessageResponse, error) {
+	res := &MessageResponse{}
+	if err := utils.XMLDecode(body, res); err != nil {
+		return nil, err
+	}
+	if res.Result != "OK" {
+		return res, utils.NewError(nil, res.CmdType, " result:", res.Result)
+	}
+	return res, nil
+}
+
+// 获取通道的sip地址
+func channelAddress(channel *Channels) *sip.Address {
+	uri, err := sip.ParseURI(channel.URIStr)
+	if err != nil || uri == nil {
+		uri, _ = sip.ParseURI(fmt.Sprintf("sip:%s@%s", channel.ChannelID, _sysinfo.Region))
+	}
+	return &sip.Address{URI: uri}
+}
@@ -21,6 +21,22 @@ const (
 	NotifyMethodChannelsActive = "channels.active"
 	// NotifyMethodRecordStop 视频录制结束
 	NotifyMethodRecordStop = "records.stop"
+	// NotifyMethodRecordDownloadDone 历史媒体文件下载完成
+	NotifyMethodRecordDownloadDone = "records.download_done"
+	// NotifyMethodSnapshotFinished 设备抓拍图片上传完成
+	NotifyMethodSnapshotFinished = "snapshots.finished"
+	// NotifyMethodDevicesUpgrade 设备升级结果
+	NotifyMethodDevicesUpgrade = "devices.upgrade"
+	// NotifyMethodMediaNodeDown 媒体节点离线
+	NotifyMethodMediaNodeDown = "media.node_down"
+	// NotifyMethodMediaNodeUp 媒体节点上线
+	NotifyMethodMediaNodeUp = "media.node_up"
+	// NotifyMethodStreamsStalled 直播流卡死
+	NotifyMethodStreamsStalled = "streams.stalled"
+	// NotifyMethodStreamsClosed 流异常关闭
+	NotifyMethodStreamsClosed = "streams.closed"
+	// NotifyMethodMediaSendRtpStopped 媒体服务器停止发送rtp，对讲或广播已结束
+	NotifyMethodMediaSendRtpStopped = "media.send_rtp_stopped"
 )
 
 // Notify 消息通知结构
@@ -97,3 +113,96 @@ func notifyRecordStop(url string, req url.Values) *Notify {
 		Data:   d,
 	}
 }
+
+func notifyRecordDownloadDone(data *Streams, file Files) *Notify {
+	return &Notify{
+		Method: NotifyMethodRecordDownloadDone,
+		Data: map[string]any{
+			"streamid":  data.StreamID,
+			"channelid": data.ChannelID,
+			"deviceid":  data.DeviceID,
+			"start":     data.S.Unix(),
+			"end":       data.E.Unix(),
+			"progress":  data.Progress,
+			"fid":       file.FID,
+			"url":       file.URL,
+			"size":      file.Size,
+			"time":      time.Now().Unix(),
+		},
+	}
+}
+
+func notifySnapshotFinished(session *snapshotSession, files []Snapshots) *Notify {
+	return &Notify{
+		Method: NotifyMethodSnapshotFinished,
+		Data: map[string]any{
+			"sessionid": session.SessionID,
+			"channelid": session.ChannelID,
+			"deviceid":  session.DeviceID,
+			"files":     files,
+			"time":      time.Now().Unix(),
+		},
+	}
+}
+
+func notifyDevicesUpgrade(u Upgrades) *Notify {
+	return &Notify{
+		Method: NotifyMethodDevicesUpgrade,
+		Data:   u,
+	}
+}
+
+func notifyMediaNode(method string, node MediaNode, streams []string, reason string) *Notify {
+	return &Notify{
+		Method: method,
+		Data: map[string]any{
+			"id":      node.ID,
+			"zone":    node.Zone,
+			"streams": streams,
+			"reason":  reason,
+			"time":    time.Now().Unix(),
+		},
+	}
+}
+
+func notifyStreamStalled(play *Streams, retry int, reinvite bool) *Notify {
+	return &Notify{
+		Method: NotifyMethodStreamsStalled,
+		Data: map[string]any{
+			"streamid":      play.StreamID,
+			"deviceid":      play.DeviceID,
+			"channelid":     play.ChannelID,
+			"mediaserverid": play.MediaServerID,
+			"retry":         retry,
+			"reinvite":      reinvite,
+			"time":          time.Now().Unix(),
+		},
+	}
+}
+
+func notifyStreamClosed(play *Streams, reason string) *Notify {
+	return &Notify{
+		Method: NotifyMethodStreamsClosed,
+		Data: map[string]any{
+			"streamid":      play.StreamID,
+			"deviceid":      play.DeviceID,
+			"channelid":     play.ChannelID,
+			"mediaserverid": play.MediaServerID,
+			"reason":        reason,
+			"time":          time.Now().Unix(),
+		},
+	}
+}
+
+func notifySendRtpStopped(app, stream, ssrc, reason string) *Notify {
+	return &Notify{
+		Method: NotifyMethodMediaSendRtpStopped,
+		Data: map[string]any{
+			"app":    app,
+			"stream": stream,
+			"ssrc":   ssrc,
+			"reason": reason,
+			"time":   time.Now().Unix(),
+		},
+	}
+}
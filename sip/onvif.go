@@ -0,0 +1,136 @@
+package sipapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/panjjo/gorm"
+	"github.com/panjjo/gosip/db"
+	"github.com/panjjo/gosip/m"
+	"github.com/panjjo/gosip/onvif"
+	"github.com/sirupsen/logrus"
+)
+
+// DeviceTypeOnvif onvif 接入的设备类型
+const DeviceTypeOnvif = "ONVIF"
+
+// OnvifDiscover 在配置的网卡上发现onvif设备
+func OnvifDiscover() ([]onvif.Device, error) {
+	return onvif.Discover(config.Onvif.Interfaces, time.Duration(config.Onvif.Timeout)*time.Second)
+}
+
+// OnvifOnboard 查询onvif设备的媒体配置，创建设备及对应的拉流通道
+// 同一设备服务地址重复接入时更新已有通道的拉流地址和账号，新增的媒体配置创建新通道
+func OnvifOnboard(xaddr, username, password, name string) (*Devices, []Channels, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Duration(config.Onvif.Timeout)*time.Second)
+	defer cancel()
+	client := onvif.NewClient(xaddr, username, password, time.Duration(config.Onvif.Timeout)*time.Second)
+	info, err := client.GetDeviceInformation(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	mediaXAddr, err := client.MediaXAddr(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	profiles, err := client.GetProfiles(ctx, mediaXAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(profiles) == 0 {
+		return nil, nil, errors.New("onvif设备没有媒体配置")
+	}
+	uris := make([]string, len(profiles))
+	for i, profile := range profiles {
+		if uris[i], err = client.GetStreamUri(ctx, mediaXAddr, profile.Token); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	tx, err := db.NewTx(db.DBClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.End()
+
+	dnum := 0
+	device := &Devices{Onvif: xaddr}
+	if err := db.Get(tx.DB(), device); err != nil {
+		if !db.RecordNotFound(err) {
+			return nil, nil, err
+		}
+		device = &Devices{
+			DeviceID:   fmt.Sprintf("%s%06d", config.GB28181.DID, config.GB28181.DNUM+1),
+			Region:     config.GB28181.Region,
+			DeviceType: DeviceTypeOnvif,
+			Onvif:      xaddr,
+		}
+		if err := db.Create(tx.DB(), device); err != nil {
+			return nil, nil, err
+		}
+		if _, err := db.UpdateAll(tx.DB(), new(m.SysInfo), db.M{}, db.M{"dnum": gorm.Expr("dnum+1")}); err != nil {
+			return nil, nil, err
+		}
+		dnum = 1
+	}
+	if name != "" {
+		device.Name = name
+	}
+	if device.Name == "" {
+		device.Name = fmt.Sprintf("%s %s", info.Manufacturer, info.Model)
+	}
+	device.Manufacturer = info.Manufacturer
+	device.Model = info.Model
+	device.Firmware = info.FirmwareVersion
+	if err := db.Save(tx.DB(), device); err != nil {
+		return nil, nil, err
+	}
+
+	channels := []Channels{}
+	cnum := 0
+	for i, profile := range profiles {
+		channel := Channels{DeviceID: device.DeviceID, ProfileToken: profile.Token}
+		if err := db.Get(tx.DB(), &channel); err != nil {
+			if !db.RecordNotFound(err) {
+				return nil, nil, err
+			}
+			cnum++
+			channel = Channels{
+				ChannelID:    fmt.Sprintf("%s%06d", config.GB28181.CID, config.GB28181.CNUM+cnum),
+				DeviceID:     device.DeviceID,
+				ProfileToken: profile.Token,
+				StreamType:   m.StreamTypePull,
+			}
+		}
+		channel.Name = profile.Name
+		channel.Manufacturer = info.Manufacturer
+		channel.Model = info.Model
+		channel.VF = profile.Encoding
+		channel.Width = profile.Width
+		channel.Height = profile.Height
+		channel.FPS = profile.FPS
+		channel.URL = uris[i]
+		channel.PullUsername = username
+		channel.PullPassword = password
+		channel.Status = m.DeviceStatusON
+		channel.Active = time.Now().Unix()
+		if err := db.Save(tx.DB(), &channel); err != nil {
+			return nil, nil, err
+		}
+		channels = append(channels, channel)
+	}
+	if cnum > 0 {
+		if _, err := db.UpdateAll(tx.DB(), new(m.SysInfo), db.M{}, db.M{"cnum": gorm.Expr(fmt.Sprintf("cnum+%d", cnum))}); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	config.GB28181.DNUM += dnum
+	config.GB28181.CNUM += cnum
+	logrus.Infoln("onvif device onboard,", device.DeviceID, xaddr, "channels:", len(channels))
+	return device, channels, nil
+}
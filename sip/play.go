@@ -1,15 +1,17 @@
 package sipapi
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	sdp "github.com/panjjo/gosdp"
 	"github.com/panjjo/gosip/db"
 	"github.com/panjjo/gosip/m"
+	"github.com/panjjo/gosip/media"
 	sip "github.com/panjjo/gosip/sip/s"
 	"github.com/panjjo/gosip/utils"
 	"github.com/sirupsen/logrus"
@@ -33,10 +35,32 @@ func SipPlay(data *Streams) (*Streams, error) {
 
 	data.DeviceID = channel.DeviceID
 	data.StreamType = channel.StreamType
+
+	// 直播重新点播时沿用原节点，原节点不可用时按分区和负载选择
+	if node := getMediaNode(data.MediaServerID); node.ID != data.MediaServerID || !node.Online {
+		node, err := selectMediaNode(channel.Zone)
+		if err != nil {
+			return nil, err
+		}
+		data.MediaServerID = node.ID
+	}
+
 	// 使用通道的播放模式进行处理
 	switch channel.StreamType {
 	case m.StreamTypePull:
-		// 拉流
+		// 媒体服务器拉流
+		if data.T != 0 {
+			return nil, errors.New("拉流通道不支持回放和下载")
+		}
+		if data.StreamID == "" {
+			data.StreamID = generateStreamID(data.DeviceID, data.ChannelID)
+			db.Create(db.DBClient, data)
+		}
+		if err := sipPullProxy(data, channel); err != nil {
+			data.Msg = err.Error()
+			db.Save(db.DBClient, data)
+			return nil, fmt.Errorf("拉流失败:%v", err)
+		}
 
 	default:
 		// 推流模式要求设备在线且活跃
@@ -49,45 +73,41 @@ func SipPlay(data *Streams) (*Streams, error) {
 		}
 		// GB28181推流
 		if data.StreamID == "" {
-			ssrcLock.Lock()
-			data.ssrc = getSSRC(data.T)
-			// data.StreamID = ssrc2stream(data.ssrc)
-			data.StreamID = generateStreamID(data.DeviceID, data.ChannelID)
-
-			// 在 ZLM 中开启 RTP 服务器，指定自定义 streamId
-			rtpReq := zlmOpenRtpServerReq{
-				Port:      "0", // 0 表示让 ZLM 自动分配端口
-				StreamID:  data.StreamID,
-				EnableTCP: "1", // 默认开启 TCP
-			}
-
-			rtpResp, err := zlmOpenRtpServer(rtpReq)
+			ssrc, err := _ssrcPool.alloc(data.T != 0)
 			if err != nil {
-				ssrcLock.Unlock()
-				return nil, fmt.Errorf("开启 ZLM RTP 服务器失败: %v", err)
+				return nil, err
 			}
-
-			// 更新媒体服务器端口（如果 ZLM 返回了新端口）
-			if rtpResp.Port > 0 {
-				_sysinfo.MediaServerRtpPort = rtpResp.Port
+			data.ssrc = ssrc
+			data.SSRC = ssrc
+			if data.T == 0 {
+				data.StreamID = generateStreamID(data.DeviceID, data.ChannelID)
+			} else {
+				// 回放、下载每次请求生成一个流，使用ssrc作为流id，可与直播并存
+				data.StreamID = ssrc2stream(ssrc)
 			}
-
-			// 成功后保存
 			db.Create(db.DBClient, data)
-			ssrcLock.Unlock()
 		}
 
+		// 传输方式优先级：请求指定 > 通道设置 > 默认配置
+		transport := data.transport
+		if transport == "" {
+			transport = channel.Transport
+		}
+		if !m.CheckTransport(transport) {
+			transport = config.Stream.Transport
+		}
 		var err error
-		data, err = sipPlayPush(data, channel, user)
+		data, err = sipPlayInvite(data, channel, user, transport)
 		if err != nil {
+			_ssrcPool.release(data.SSRC)
 			return nil, fmt.Errorf("获取视频失败:%v", err)
 		}
+		if data.T != 0 {
+			data.Scale = 1
+		}
 	}
 
-	data.HTTP = fmt.Sprintf("%s/rtp/%s/hls.m3u8", config.Media.HTTP, data.StreamID)
-	data.RTMP = fmt.Sprintf("%s/rtp/%s", config.Media.RTMP, data.StreamID)
-	data.RTSP = fmt.Sprintf("%s/rtp/%s", config.Media.RTSP, data.StreamID)
-	data.WSFLV = fmt.Sprintf("%s/rtp/%s.live.flv", config.Media.WS, data.StreamID)
+	getMediaNode(data.MediaServerID).playURLs(data)
 
 	data.Ext = time.Now().Unix() + 2*60 // 2分钟等待时间
 	StreamList.Response.Store(data.StreamID, data)
@@ -98,45 +118,130 @@ func SipPlay(data *Streams) (*Streams, error) {
 	return data, nil
 }
 
-var ssrcLock *sync.Mutex
+// SetTransport 指定本次播放的传输方式，为空时使用通道设置
+func (s *Streams) SetTransport(transport string) {
+	s.transport = transport
+}
+
+// 设备不接受当前传输方式，可回退到其他方式重试
+var errTransportRejected = errors.New("设备不支持当前传输方式")
+
+// 向设备发起点播，测试时可替换为不经过sip信令的实现
+var playPush = sipPlayPush
+
+// 设备拒绝传输方式时的应答码
+var transportRejectedCodes = map[int]bool{
+	400: true,
+	406: true,
+	415: true,
+	488: true,
+	500: true,
+	501: true,
+	606: true,
+}
+
+// 按传输方式发起点播，设备拒绝时按 m.Transports 的顺序回退
+func sipPlayInvite(data *Streams, channel Channels, device Devices, transport string) (*Streams, error) {
+	modes := []string{transport}
+	for _, v := range m.Transports {
+		if v != transport {
+			modes = append(modes, v)
+		}
+	}
+	var err error
+	for _, mode := range modes {
+		if err = sipOpenRtpServer(data, mode); err != nil {
+			return data, err
+		}
+		data, err = playPush(data, channel, device, mode)
+		if err == nil {
+			return data, nil
+		}
+		streamMedia(data).CloseRtpServer(context.Background(), data.StreamID)
+		data.rtpPort = 0
+		if !errors.Is(err, errTransportRejected) {
+			return data, err
+		}
+		logrus.Infoln("sipPlayInvite transport rejected.", data.StreamID, mode, err)
+	}
+	return data, err
+}
+
+// 按传输方式在zlm开启rtp服务器，已开启的先关闭
+func sipOpenRtpServer(data *Streams, mode string) error {
+	mediaServer := streamMedia(data)
+	if data.rtpPort != 0 {
+		mediaServer.CloseRtpServer(context.Background(), data.StreamID)
+		data.rtpPort = 0
+	}
+	// udp 与 tcp被动同时监听，设备应答任一方式都可以收流
+	tcpMode := media.TCPModePassive
+	if mode == m.TransportTCPActive {
+		tcpMode = media.TCPModeActive
+	}
+	port, err := mediaServer.OpenRtpServer(context.Background(), media.OpenRtpServerReq{
+		Port:     0, // 0 表示让 ZLM 自动分配端口
+		StreamID: data.StreamID,
+		TCPMode:  tcpMode,
+		SSRC:     data.ssrc,
+	})
+	if err != nil {
+		return fmt.Errorf("开启 ZLM RTP 服务器失败: %v", err)
+	}
+	data.rtpPort = port
+	return nil
+}
 
-func sipPlayPush(data *Streams, channel Channels, device Devices) (*Streams, error) {
+func sipPlayPush(data *Streams, channel Channels, device Devices, mode string) (*Streams, error) {
 	var (
 		s sdp.Session
 		b []byte
 	)
 	name := "Play"
-	protocal := "TCP/RTP/AVP"
-	if data.T == 1 {
+	switch data.T {
+	case 1:
 		name = "Playback"
-		protocal = "RTP/RTCP"
+	case 2:
+		name = "Download"
+	}
+	rtpIP := getMediaNode(data.MediaServerID).rtpIP
+	protocal := "TCP/RTP/AVP"
+	if mode == m.TransportUDP {
+		protocal = "RTP/AVP"
 	}
 
 	// 视频媒体描述
 	video := sdp.Media{
 		Description: sdp.MediaDescription{
 			Type:     "video",
-			Port:     _sysinfo.MediaServerRtpPort,
+			Port:     data.rtpPort,
 			Formats:  []string{"96"},
 			Protocol: protocal,
 		},
 	}
 	video.AddAttribute("recvonly")
-	if data.T == 0 {
+	switch mode {
+	case m.TransportTCPPassive:
 		video.AddAttribute("setup", "passive")
 		video.AddAttribute("connection", "new")
+	case m.TransportTCPActive:
+		video.AddAttribute("setup", "active")
+		video.AddAttribute("connection", "new")
 	}
 	video.AddAttribute("rtpmap", "96", "PS/90000")
+	if data.T == 2 {
+		video.AddAttribute("downloadspeed", strconv.Itoa(data.Speed))
+	}
 
 	// defining message
 	msg := &sdp.Message{
 		Origin: sdp.Origin{
-			Username: _serverDevices.DeviceID,            // 媒体服务器id
-			Address:  _sysinfo.MediaServerRtpIP.String(), // TODO: 此处可以扩展成内外网收流地址
+			Username: _serverDevices.DeviceID, // 媒体服务器id
+			Address:  rtpIP.String(),          // TODO: 此处可以扩展成内外网收流地址
 		},
 		Name: name,
 		Connection: sdp.ConnectionData{
-			IP:  _sysinfo.MediaServerRtpIP, // TODO: 此处可以扩展成内外网收流地址
+			IP:  rtpIP, // TODO: 此处可以扩展成内外网收流地址
 			TTL: 0,
 		},
 		Timing: []sdp.Timing{
@@ -148,7 +253,7 @@ func sipPlayPush(data *Streams, channel Channels, device Devices) (*Streams, err
 		Medias: []sdp.Media{video}, // 同时包含视频和音频
 		SSRC:   data.ssrc,
 	}
-	if data.T == 1 {
+	if data.T != 0 {
 		msg.URI = fmt.Sprintf("%s:0", channel.ChannelID)
 	}
 
@@ -181,7 +286,10 @@ func sipPlayPush(data *Streams, channel Channels, device Devices) (*Streams, err
 	// response
 	response, err := sipResponse(tx)
 	if err != nil {
-		logrus.Warningln("sipPlayPush response fail.id:", device.DeviceID, channel.ChannelID, "err:", err)
+		logrus.Warningln("sipPlayPush response fail.id:", device.DeviceID, channel.ChannelID, "mode:", mode, "err:", err)
+		if response != nil && transportRejectedCodes[response.StatusCode()] {
+			return data, fmt.Errorf("%w(%s):%v", errTransportRejected, mode, err)
+		}
 		return data, err
 	}
 	data.Resp = response
@@ -206,14 +314,78 @@ func sipPlayPush(data *Streams, channel Channels, device Devices) (*Streams, err
 	}
 	data.Status = 0
 
-	return data, err
+	// 以设备应答的媒体信息为准，不可用时结束会话
+	answer, err := parseVideoAnswer(response.Body())
+	transport := m.TransportUDP
+	if err == nil && answer.Transport == "tcp" {
+		transport = m.TransportTCPPassive
+		if strings.ToLower(answer.Setup) == "passive" {
+			transport = m.TransportTCPActive
+		}
+		if transport == m.TransportTCPActive {
+			if mode != m.TransportTCPActive {
+				// rtp服务器未按主动方式开启，回退后重新协商
+				err = fmt.Errorf("%w(%s):设备要求媒体服务器主动建立tcp连接", errTransportRejected, mode)
+			} else if e := streamMedia(data).ConnectRtpServer(context.Background(), data.StreamID, answer.IP, answer.Port); e != nil {
+				err = fmt.Errorf("%w(%s):连接设备媒体端口失败:%v", errTransportRejected, mode, e)
+			}
+		}
+	}
+	if err != nil {
+		logrus.Warningln("sipPlayPush answer invalid.id:", device.DeviceID, channel.ChannelID, "err:", err)
+		if e := sipPlayBye(device, response); e != nil {
+			logrus.Warnln("sipPlayPush bye fail.id:", device.DeviceID, channel.ChannelID, "err:", e)
+		}
+		return data, err
+	}
+	if answer.SSRC != "" && answer.SSRC != data.ssrc {
+		logrus.Infoln("sipPlayPush device ssrc changed.", data.StreamID, data.ssrc, "->", answer.SSRC)
+		if err := streamMedia(data).UpdateRtpServerSSRC(context.Background(), data.StreamID, answer.SSRC); err != nil {
+			logrus.Warningln("sipPlayPush update ssrc fail.", data.StreamID, err)
+		}
+		// 以设备的ssrc占用分配池，避免再分配给其他流
+		_ssrcPool.release(data.ssrc)
+		_ssrcPool.reserve(answer.SSRC)
+		data.ssrc = answer.SSRC
+	}
+	data.SSRC = data.ssrc
+	data.Transport = transport
+	data.Setup = answer.Setup
+	data.MediaAddr = fmt.Sprintf("%s:%d", answer.IP, answer.Port)
+	data.Codec = answer.Codec
+	data.MediaFormat = answer.F
+
+	return data, nil
+}
+
+// 在播放会话内发送BYE
+func sipPlayBye(device Devices, resp *sip.Response) error {
+	req := sip.NewRequestFromResponse(sip.BYE, resp)
+	req.SetDestination(device.source)
+	// 根据设备的传输方式发送请求
+	var tx *sip.Transaction
+	var err error
+	if strings.ToLower(device.TransPort) == "tcp" {
+		tx, err = srv.RequestWithProtocol(req, "tcp")
+	} else {
+		tx, err = srv.Request(req) // 默认UDP
+	}
+	if err != nil {
+		return err
+	}
+	_, err = sipResponse(tx)
+	return err
 }
 
 // sip 停止播放
 func SipStopPlay(ssrc string) {
-	zlmCloseStream(ssrc)
+	viewerStreamClosed(ssrc)
+	mediaServer := streamIDMedia(ssrc)
+	if err := mediaServer.CloseStreams(context.Background(), "rtp", ssrc); err != nil {
+		logrus.Warnln("关闭 ZLM 流失败:", err)
+	}
 	// 关闭 ZLM RTP 服务器
-	if err := zlmCloseRtpServer(ssrc); err != nil {
+	if err := mediaServer.CloseRtpServer(context.Background(), ssrc); err != nil && !errors.Is(err, media.ErrNotFound) {
 		logrus.Errorln("关闭 ZLM RTP 服务器失败:", err)
 	}
 
@@ -224,38 +396,32 @@ func SipStopPlay(ssrc string) {
 	play := data.(*Streams)
 	logrus.Infoln("SipStopPlay", play.StreamType, m.StreamTypePush)
 	if play.StreamType == m.StreamTypePush {
-		// 推流，需要发送关闭请求
-		resp := play.Resp
-		u, ok := _activeDevices.Load(play.DeviceID)
-		if !ok {
-			return
+		if play.T != 0 && play.Resp != nil {
+			// 回放先通知设备停止发送
+			sipPlaybackTeardown(play)
 		}
-		user := u.(Devices)
-		req := sip.NewRequestFromResponse(sip.BYE, resp)
-		req.SetDestination(user.source)
-		// 根据设备的传输方式发送请求
-		var tx *sip.Transaction
-		var err error
-		if strings.ToLower(user.TransPort) == "tcp" {
-			tx, err = srv.RequestWithProtocol(req, "tcp")
-		} else {
-			tx, err = srv.Request(req) // 默认UDP
-		}
-		if err != nil {
-			logrus.Warningln("sipStopPlay bye fail.id:", play.DeviceID, play.ChannelID, "err:", err)
+		// 推流，需要发送关闭请求，设备已离线时只释放本地资源
+		if u, ok := _activeDevices.Load(play.DeviceID); ok {
+			if err := sipPlayBye(u.(Devices), play.Resp); err != nil {
+				logrus.Warnln("sipStopPlay bye fail.id:", play.DeviceID, play.ChannelID, "err:", err)
+				play.Msg = err.Error()
+			}
+		} else if play.Msg == "" {
+			play.Msg = "设备已离线"
 		}
-		_, err = sipResponse(tx)
-		if err != nil {
-			logrus.Warnln("sipStopPlay response fail", err)
+	} else if play.StreamType == m.StreamTypePull {
+		if err := sipPullStop(play); err != nil {
+			logrus.Warnln("sipStopPlay del proxy fail.id:", play.ChannelID, play.StreamID, "err:", err)
 			play.Msg = err.Error()
-		} else {
-			play.Status = 1
-			play.Stop = true
 		}
-		db.Save(db.DBClient, play)
 	}
+	// 流已从列表移除，无论设备是否应答都记录为关闭
+	play.Status = 1
+	play.Stop = true
+	db.Save(db.DBClient, play)
 	StreamList.Response.Delete(ssrc)
 	if play.T == 0 {
 		StreamList.Succ.Delete(play.ChannelID)
 	}
+	_ssrcPool.release(play.SSRC)
 }
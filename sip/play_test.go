@@ -0,0 +1,114 @@
+package sipapi
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/panjjo/gosip/db"
+	"github.com/panjjo/gosip/m"
+	"github.com/panjjo/gosip/media"
+)
+
+func TestSipPlayStop(t *testing.T) {
+	fake := testSetup(t)
+	testChannel(t, "34020000001310000001", "34020000001110000001")
+	playPush = func(data *Streams, channel Channels, device Devices, mode string) (*Streams, error) {
+		data.Transport = mode
+		return data, nil
+	}
+
+	data, err := SipPlay(&Streams{ChannelID: "34020000001310000001", Ttag: db.M{}, Ftag: db.M{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.RtpServers[data.StreamID]; !ok {
+		t.Fatalf("rtp server not opened for %s", data.StreamID)
+	}
+	if data.SSRC == "" || _ssrcPool.count() != 1 {
+		t.Fatalf("ssrc not allocated, ssrc:%q used:%d", data.SSRC, _ssrcPool.count())
+	}
+	if data.Transport != m.TransportTCPPassive {
+		t.Fatalf("transport = %s, want %s", data.Transport, m.TransportTCPPassive)
+	}
+	if want := fmt.Sprintf("http://127.0.0.1:8080/rtp/%s/hls.m3u8", data.StreamID); data.HTTP != want {
+		t.Fatalf("http = %s, want %s", data.HTTP, want)
+	}
+	if _, ok := StreamList.Succ.Load(data.ChannelID); !ok {
+		t.Fatal("live stream not in StreamList.Succ")
+	}
+
+	// 设备离线后停止播放，不发送BYE但仍需释放资源
+	_activeDevices.Delete(data.DeviceID)
+	SipStopPlay(data.StreamID)
+	if len(fake.RtpServers) != 0 {
+		t.Fatalf("rtp servers not closed: %v", fake.RtpServers)
+	}
+	if _, ok := StreamList.Response.Load(data.StreamID); ok {
+		t.Fatal("stream still in StreamList.Response")
+	}
+	if _, ok := StreamList.Succ.Load(data.ChannelID); ok {
+		t.Fatal("stream still in StreamList.Succ")
+	}
+	if n := _ssrcPool.count(); n != 0 {
+		t.Fatalf("ssrc not released, used:%d", n)
+	}
+	if !data.Stop || data.Status != 1 {
+		t.Fatalf("stream not marked stopped, stop:%v status:%d", data.Stop, data.Status)
+	}
+}
+
+func TestSipPlayTransportFallback(t *testing.T) {
+	fake := testSetup(t)
+	testChannel(t, "34020000001310000001", "34020000001110000001")
+	modes := []string{}
+	tcpModes := []int{}
+	playPush = func(data *Streams, channel Channels, device Devices, mode string) (*Streams, error) {
+		modes = append(modes, mode)
+		tcpModes = append(tcpModes, fake.RtpServers[data.StreamID].TCPMode)
+		if mode != m.TransportTCPActive {
+			return data, fmt.Errorf("%w(%s):488", errTransportRejected, mode)
+		}
+		data.Transport = mode
+		return data, nil
+	}
+
+	data, err := SipPlay(&Streams{ChannelID: "34020000001310000001", Ttag: db.M{}, Ftag: db.M{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{m.TransportTCPPassive, m.TransportUDP, m.TransportTCPActive}
+	if fmt.Sprint(modes) != fmt.Sprint(want) {
+		t.Fatalf("modes = %v, want %v", modes, want)
+	}
+	if tcpModes[2] != media.TCPModeActive {
+		t.Fatalf("rtp server tcp mode = %d for tcp_active", tcpModes[2])
+	}
+	if data.Transport != m.TransportTCPActive || len(fake.RtpServers) != 1 {
+		t.Fatalf("transport:%s rtp servers:%d", data.Transport, len(fake.RtpServers))
+	}
+}
+
+func TestSipPlayFailReleasesSSRC(t *testing.T) {
+	fake := testSetup(t)
+	testChannel(t, "34020000001310000001", "34020000001110000001")
+	calls := 0
+	playPush = func(data *Streams, channel Channels, device Devices, mode string) (*Streams, error) {
+		calls++
+		return data, errors.New("timeout")
+	}
+
+	if _, err := SipPlay(&Streams{ChannelID: "34020000001310000001", Ttag: db.M{}, Ftag: db.M{}}); err == nil {
+		t.Fatal("want error")
+	}
+	// 非传输方式被拒绝的错误不回退
+	if calls != 1 {
+		t.Fatalf("invite calls = %d, want 1", calls)
+	}
+	if len(fake.RtpServers) != 0 {
+		t.Fatalf("rtp servers not closed: %v", fake.RtpServers)
+	}
+	if n := _ssrcPool.count(); n != 0 {
+		t.Fatalf("ssrc not released, used:%d", n)
+	}
+}
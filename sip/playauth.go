@@ -0,0 +1,162 @@
+package sipapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/panjjo/gosip/db"
+	"github.com/sirupsen/logrus"
+)
+
+var errPlayAuth = errors.New("播放地址签名无效")
+
+// PlayRevokes 用户播放地址吊销记录，吊销时间之前签发的播放地址失效
+type PlayRevokes struct {
+	db.DBModel
+	User string `json:"user" gorm:"column:username"`
+	// 吊销时间，单位毫秒
+	Revoked int64 `json:"revoked" gorm:"column:revoked"`
+}
+
+// key=user value=吊销时间
+var _playRevokes sync.Map
+
+func loadPlayRevokes() {
+	revokes := []PlayRevokes{}
+	if err := db.Find(db.DBClient, db.M{}, nil, "", 0, -1, &revokes); err != nil {
+		logrus.Errorln("load play revokes fail,", err)
+		return
+	}
+	for _, r := range revokes {
+		_playRevokes.Store(r.User, r.Revoked)
+	}
+}
+
+// 签名内容，绑定流、用户、签发时间（毫秒）、过期时间，绑定ip时包含ip
+func playAuthSign(app, stream, user, ip string, iat, expire int64) string {
+	mac := hmac.New(sha256.New, []byte(config.PlayAuth.Secret))
+	fmt.Fprintf(mac, "%s/%s|%s|%d|%d|%s", app, stream, user, iat, expire, ip)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// 生成播放地址的签名参数，ip不为空时绑定ip
+func playAuthParams(app, stream, user, ip string) string {
+	now := time.Now()
+	// 签发时间使用毫秒，并保证晚于用户的吊销时间，吊销后立即重新签发的地址不会被误判为已吊销
+	iat := now.UnixMilli()
+	if v, ok := _playRevokes.Load(user); ok && iat <= v.(int64) {
+		iat = v.(int64) + 1
+	}
+	expire := now.Unix() + int64(config.PlayAuth.Expire)
+	query := url.Values{}
+	query.Set("user", user)
+	query.Set("iat", strconv.FormatInt(iat, 10))
+	query.Set("expire", strconv.FormatInt(expire, 10))
+	if ip != "" {
+		query.Set("bind", "1")
+	}
+	query.Set("sign", playAuthSign(app, stream, user, ip, iat, expire))
+	return query.Encode()
+}
+
+// 在地址后追加参数，webrtc 等地址已带有参数
+func appendURLParams(u, params string) string {
+	if u == "" {
+		return u
+	}
+	if strings.Contains(u, "?") {
+		return u + "&" + params
+	}
+	return u + "?" + params
+}
+
+// SignPlayURLs 为流的播放地址添加签名参数，ip不为空时只允许该ip播放，未开启签名时不处理
+func SignPlayURLs(data *Streams, user, ip string) {
+	if !config.PlayAuth.Enable {
+		return
+	}
+	params := playAuthParams("rtp", data.StreamID, user, ip)
+	for _, u := range []*string{
+		&data.HTTP, &data.HTTPS, &data.HLSFMP4, &data.HTTPSHLSFMP4,
+		&data.RTMP, &data.RTMPS, &data.RTSP, &data.RTSPS,
+		&data.FLV, &data.HTTPSFLV, &data.WSFLV, &data.WSSFLV,
+		&data.FMP4, &data.HTTPSFMP4, &data.WSFMP4, &data.WSSFMP4,
+		&data.WebRTC, &data.WebRTCS,
+	} {
+		*u = appendURLParams(*u, params)
+	}
+}
+
+// CheckPlayAuth 校验播放请求的签名参数，返回签名剩余有效时间，单位秒
+func CheckPlayAuth(app, stream, params, ip string) (int64, error) {
+	if !config.PlayAuth.Enable {
+		return int64(config.PlayAuth.Expire), nil
+	}
+	query, err := url.ParseQuery(params)
+	if err != nil {
+		return 0, errPlayAuth
+	}
+	user := query.Get("user")
+	iat, _ := strconv.ParseInt(query.Get("iat"), 10, 64)
+	expire, _ := strconv.ParseInt(query.Get("expire"), 10, 64)
+	remain := expire - time.Now().Unix()
+	if remain <= 0 {
+		return 0, fmt.Errorf("%w:已过期", errPlayAuth)
+	}
+	if v, ok := _playRevokes.Load(user); ok && iat <= v.(int64) {
+		return 0, fmt.Errorf("%w:已吊销", errPlayAuth)
+	}
+	if query.Get("bind") != "1" {
+		ip = ""
+	}
+	sign := playAuthSign(app, stream, user, ip, iat, expire)
+	if !hmac.Equal([]byte(sign), []byte(query.Get("sign"))) {
+		return 0, errPlayAuth
+	}
+	return remain, nil
+}
+
+// CheckHTTPPlayAuth 校验http访问，只校验流媒体路径 /rtp/{stream}...，其他路径如录制文件不校验
+// 返回授权的路径及授权缓存时间
+func CheckHTTPPlayAuth(path, params, ip string) (string, int64, error) {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "rtp" {
+		return "", int64(config.PlayAuth.Expire), nil
+	}
+	// http-flv 等地址为 /rtp/{stream}.live.flv
+	stream := strings.SplitN(parts[1], ".", 2)[0]
+	remain, err := CheckPlayAuth(parts[0], stream, params, ip)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(parts) == 2 {
+		// 单个文件的访问，不缓存授权
+		return path, 0, nil
+	}
+	// hls 切片与 m3u8 在同一目录，授权整个目录
+	return "/" + parts[0] + "/" + parts[1] + "/", remain, nil
+}
+
+// RevokePlayUser 吊销用户此前签发的所有播放地址
+func RevokePlayUser(user string) error {
+	now := time.Now().UnixMilli()
+	revoke := PlayRevokes{User: user}
+	if err := db.Get(db.DBClient, &revoke); err != nil && !db.RecordNotFound(err) {
+		return err
+	}
+	revoke.Revoked = now
+	if err := db.Save(db.DBClient, &revoke); err != nil {
+		return err
+	}
+	_playRevokes.Store(user, now)
+	logrus.Infoln("play urls revoked,", user)
+	return nil
+}
@@ -0,0 +1,59 @@
+package sipapi
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func testPlayAuthParams(t *testing.T, u string) string {
+	t.Helper()
+	parsed, err := url.Parse(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.RawQuery
+}
+
+func TestPlayAuth(t *testing.T) {
+	testSetup(t)
+	config.PlayAuth.Enable = true
+	config.PlayAuth.Secret = "secret"
+	config.PlayAuth.Expire = 60
+
+	data := &Streams{StreamID: "s1", HTTP: "http://127.0.0.1/rtp/s1/hls.m3u8", WebRTC: "http://127.0.0.1/index/api/webrtc?app=rtp&stream=s1&type=play"}
+	SignPlayURLs(data, "u1", "10.0.0.1")
+	if !strings.Contains(data.WebRTC, "type=play&") {
+		t.Fatalf("webrtc params not appended: %s", data.WebRTC)
+	}
+	params := testPlayAuthParams(t, data.HTTP)
+
+	if _, err := CheckPlayAuth("rtp", "s1", params, "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CheckPlayAuth("rtp", "s1", params, "10.0.0.2"); err == nil {
+		t.Fatal("bound ip not checked")
+	}
+	if _, err := CheckPlayAuth("rtp", "s2", params, "10.0.0.1"); err == nil {
+		t.Fatal("stream not checked")
+	}
+	if _, err := CheckPlayAuth("rtp", "s1", "", "10.0.0.1"); err == nil {
+		t.Fatal("unsigned url accepted")
+	}
+	if path, _, err := CheckHTTPPlayAuth("/rtp/s1/hls.m3u8", params, "10.0.0.1"); err != nil || path != "/rtp/s1/" {
+		t.Fatalf("http access path:%s err:%v", path, err)
+	}
+
+	// 吊销后已签发的地址失效，立即重新签发的地址可用
+	if err := RevokePlayUser("u1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CheckPlayAuth("rtp", "s1", params, "10.0.0.1"); err == nil {
+		t.Fatal("revoked url accepted")
+	}
+	data = &Streams{StreamID: "s1", HTTP: "http://127.0.0.1/rtp/s1/hls.m3u8"}
+	SignPlayURLs(data, "u1", "")
+	if _, err := CheckPlayAuth("rtp", "s1", testPlayAuthParams(t, data.HTTP), "10.0.0.2"); err != nil {
+		t.Fatalf("url signed after revoke rejected: %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package sipapi
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/panjjo/gosip/db"
+	sip "github.com/panjjo/gosip/sip/s"
+	"github.com/panjjo/gosip/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// 媒体通知类型
+const (
+	// MediaStatusEOF 回放/下载文件结束
+	MediaStatusEOF = "121"
+)
+
+// 回放支持的倍速
+var playbackScales = []float64{0.25, 0.5, 1, 2, 4}
+
+// 回放控制指令串行发送，保证 MANSRTSP CSeq 递增
+var _playbackLock sync.Mutex
+
+// 获取回放流
+func playbackStream(streamID string) (*Streams, error) {
+	v, ok := StreamList.Response.Load(streamID)
+	if !ok {
+		return nil, errors.New("视频流不存在或已关闭")
+	}
+	data := v.(*Streams)
+	if data.T == 0 {
+		return nil, errors.New("直播流不支持回放控制")
+	}
+	if data.Resp == nil {
+		return nil, errors.New("回放会话未建立")
+	}
+	return data, nil
+}
+
+// SipPlaybackPause 暂停回放
+func SipPlaybackPause(streamID string) error {
+	return sipPlaybackControl(streamID, func(data *Streams) []byte {
+		data.Paused = true
+		return sip.GetMANSRTSPPause(data.rtspSeq)
+	})
+}
+
+// SipPlaybackResume 从暂停位置继续回放
+func SipPlaybackResume(streamID string) error {
+	return sipPlaybackControl(streamID, func(data *Streams) []byte {
+		data.Paused = false
+		return sip.GetMANSRTSPPlay(data.rtspSeq, 0, "now")
+	})
+}
+
+// SipPlaybackSeek 跳转到指定时间回放，t 为unix时间戳，需在回放时间范围内
+func SipPlaybackSeek(streamID string, t int64) error {
+	data, err := playbackStream(streamID)
+	if err != nil {
+		return err
+	}
+	if t < data.S.Unix() || (!data.E.IsZero() && t > data.E.Unix()) {
+		return errors.New("跳转时间超出回放范围")
+	}
+	return sipPlaybackControl(streamID, func(data *Streams) []byte {
+		data.Paused = false
+		return sip.GetMANSRTSPPlay(data.rtspSeq, 0, strconv.FormatInt(t-data.S.Unix(), 10))
+	})
+}
+
+// SipPlaybackSpeed 设置回放倍速
+func SipPlaybackSpeed(streamID string, scale float64) error {
+	valid := false
+	for _, s := range playbackScales {
+		valid = valid || s == scale
+	}
+	if !valid {
+		return errors.New("回放倍速错误，支持0.25/0.5/1/2/4")
+	}
+	return sipPlaybackControl(streamID, func(data *Streams) []byte {
+		data.Scale = scale
+		return sip.GetMANSRTSPPlay(data.rtspSeq, scale, "")
+	})
+}
+
+// 生成并发送回放控制指令，设备应答成功后保存回放状态
+func sipPlaybackControl(streamID string, body func(data *Streams) []byte) error {
+	_playbackLock.Lock()
+	defer _playbackLock.Unlock()
+	data, err := playbackStream(streamID)
+	if err != nil {
+		return err
+	}
+	scale, paused := data.Scale, data.Paused
+	data.rtspSeq++
+	if err := sipPlaybackInfo(data, body(data), true); err != nil {
+		data.Scale, data.Paused = scale, paused
+		return err
+	}
+	db.Save(db.DBClient, data)
+	return nil
+}
+
+// 结束回放前通知设备停止发送，不等待设备应答
+func sipPlaybackTeardown(data *Streams) {
+	_playbackLock.Lock()
+	defer _playbackLock.Unlock()
+	data.rtspSeq++
+	if err := sipPlaybackInfo(data, sip.GetMANSRTSPTeardown(data.rtspSeq), false); err != nil {
+		logrus.Warnln("sipPlaybackTeardown fail,", data.StreamID, err)
+	}
+}
+
+// 在回放会话内发送 INFO 请求
+func sipPlaybackInfo(data *Streams, body []byte, wait bool) error {
+	device, ok := _activeDevices.Get(data.DeviceID)
+	if !ok {
+		return errors.New("设备已离线")
+	}
+	req := sip.NewRequestFromResponse(sip.INFO, data.Resp)
+	req.AppendHeader(&sip.ContentTypeMANSRTSP)
+	req.SetBody(body, true)
+	req.SetDestination(device.source)
+	// 根据设备的传输方式发送请求
+	var tx *sip.Transaction
+	var err error
+	if strings.ToLower(device.TransPort) == "tcp" {
+		tx, err = srv.RequestWithProtocol(req, "tcp")
+	} else {
+		tx, err = srv.Request(req) // 默认UDP
+	}
+	if err != nil || !wait {
+		return err
+	}
+	_, err = sipResponse(tx)
+	return err
+}
+
+// MessageMediaStatus 媒体通知
+type MessageMediaStatus struct {
+	CmdType  string `xml:"CmdType"`
+	SN       int    `xml:"SN"`
+	DeviceID string `xml:"DeviceID"`
+	// NotifyType 通知类型 121 历史媒体文件发送结束
+	NotifyType string `xml:"NotifyType"`
+}
+
+// sipMessageMediaStatus 设备媒体通知，回放/下载文件发送结束后关闭对应的流
+func sipMessageMediaStatus(u Devices, callID string, body []byte) error {
+	message := &MessageMediaStatus{}
+	if err := utils.XMLDecode(body, message); err != nil {
+		logrus.Errorln("sipMessageMediaStatus Unmarshal xml err:", err, "body:", string(body))
+		return err
+	}
+	if message.NotifyType != MediaStatusEOF {
+		logrus.Infoln("sipMessageMediaStatus unknown notify type,", u.DeviceID, message.DeviceID, message.NotifyType)
+		return nil
+	}
+	// 优先使用会话CallID匹配，设备未在会话内发送时按通道匹配回放流
+	var data *Streams
+	StreamList.Response.Range(func(_, v any) bool {
+		stream := v.(*Streams)
+		if stream.T != 0 && stream.CallID == callID {
+			data = stream
+			return false
+		}
+		if data == nil && stream.T != 0 && stream.ChannelID == message.DeviceID {
+			data = stream
+		}
+		return true
+	})
+	if data == nil {
+		logrus.Infoln("sipMessageMediaStatus stream not found,", u.DeviceID, message.DeviceID, callID)
+		return nil
+	}
+	logrus.Infoln("sipMessageMediaStatus media end,", data.StreamID, data.ChannelID)
+	if data.T == 2 {
+		go sipDownloadEOF(data)
+		return nil
+	}
+	data.Msg = "回放结束"
+	go SipStopPlay(data.StreamID)
+	return nil
+}
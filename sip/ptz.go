@@ -0,0 +1,415 @@
+package sipapi
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/panjjo/gosip/db"
+	sip "github.com/panjjo/gosip/sip/s"
+	"github.com/panjjo/gosip/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// Presets 通道预置位，预置位名称保存在本地
+type Presets struct {
+	db.DBModel
+	// ChannelID 通道编码
+	ChannelID string `json:"channelid" gorm:"column:channelid"`
+	// PresetID 预置位编号 1-255
+	PresetID int `json:"presetid" gorm:"column:presetid"`
+	// Name 预置位名称
+	Name string `json:"name" gorm:"column:name"`
+}
+
+// PresetQuery 预置位查询应答
+type PresetQuery struct {
+	XMLName  xml.Name `xml:"Response"`
+	CmdType  string   `xml:"CmdType"`
+	SN       int      `xml:"SN"`
+	DeviceID string   `xml:"DeviceID"`
+	Items    []struct {
+		PresetID   int    `xml:"PresetID"`
+		PresetName string `xml:"PresetName"`
+	} `xml:"PresetList>Item"`
+}
+
+// 获取通道以及通道所属的在线设备
+func channelDevice(channelID string) (*Channels, Devices, error) {
+	channel := &Channels{ChannelID: channelID}
+	if err := db.Get(db.DBClient, channel); err != nil {
+		if db.RecordNotFound(err) {
+			return nil, Devices{}, errors.New("通道不存在")
+		}
+		return nil, Devices{}, err
+	}
+	device, ok := _activeDevices.Get(channel.DeviceID)
+	if !ok {
+		return nil, Devices{}, errors.New("设备已离线")
+	}
+	return channel, device, nil
+}
+
+// 云台控制优先级范围，数值越大优先级越高
+const (
+	PTZPriorityMin     = 1
+	PTZPriorityMax     = 5
+	PTZPriorityDefault = 3
+)
+
+// ptzHaltCmd 云台停止指令
+const ptzHaltCmd = "A50F0100000000B5"
+
+var (
+	// 最后一次控制后云台锁定时间，锁定期间其他同等及以下优先级的操作员无法控制
+	ptzLockTimeout = 30 * time.Second
+	// 持续转动模式下心跳超时时间，超时未收到心跳自动发送停止指令
+	ptzMoveTimeout = 5 * time.Second
+)
+
+// PTZPriority 获取请求方的云台控制优先级，未配置时使用默认优先级
+func PTZPriority(principal string) int {
+	for _, item := range config.PTZ.Priorities {
+		if item.Principal == principal && item.Priority >= PTZPriorityMin && item.Priority <= PTZPriorityMax {
+			return item.Priority
+		}
+	}
+	return PTZPriorityDefault
+}
+
+// PTZOperator 云台操作员
+type PTZOperator struct {
+	// Operator 操作员标识
+	Operator string `json:"operator"`
+	// Priority 控制优先级 1-5，数值越大优先级越高
+	Priority int `json:"priority"`
+}
+
+// PTZLock 通道云台控制锁
+type PTZLock struct {
+	PTZOperator
+	ChannelID string `json:"channelid"`
+	// ExpireAt 锁定到期时间
+	ExpireAt int64 `json:"expire"`
+	// Moving 是否处于持续转动中
+	Moving bool `json:"moving"`
+
+	timer *time.Timer
+}
+
+type ptzLocks struct {
+	sync.Mutex
+	items map[string]*PTZLock
+}
+
+var _ptzLocks = &ptzLocks{items: map[string]*PTZLock{}}
+
+// 检查通道云台控制权，被其他同等及以上优先级的操作员锁定时返回错误
+func (l *ptzLocks) check(channelID string, op PTZOperator) error {
+	lock, ok := l.items[channelID]
+	if ok && lock.Operator != op.Operator && time.Now().Unix() < lock.ExpireAt && lock.Priority >= op.Priority {
+		return fmt.Errorf("云台正在被%s控制", lock.Operator)
+	}
+	return nil
+}
+
+// 获取通道云台控制权，被其他同等及以上优先级的操作员锁定时返回错误
+func (l *ptzLocks) acquire(channelID string, op PTZOperator) (*PTZLock, error) {
+	if err := l.check(channelID, op); err != nil {
+		return nil, err
+	}
+	lock, ok := l.items[channelID]
+	if ok && lock.Operator != op.Operator {
+		// 被抢占，停止原操作员的持续转动
+		lock.stopTimer()
+		ok = false
+	}
+	if !ok {
+		lock = &PTZLock{ChannelID: channelID}
+		l.items[channelID] = lock
+	}
+	lock.PTZOperator = op
+	lock.ExpireAt = time.Now().Add(ptzLockTimeout).Unix()
+	return lock, nil
+}
+
+func (lock *PTZLock) stopTimer() {
+	if lock.timer != nil {
+		lock.timer.Stop()
+		lock.timer = nil
+	}
+	lock.Moving = false
+}
+
+// GetPTZLock 获取通道当前的云台控制锁，不存在或已过期时返回false
+func GetPTZLock(channelID string) (PTZLock, bool) {
+	_ptzLocks.Lock()
+	defer _ptzLocks.Unlock()
+	lock, ok := _ptzLocks.items[channelID]
+	if !ok || (!lock.Moving && time.Now().Unix() >= lock.ExpireAt) {
+		return PTZLock{}, false
+	}
+	return *lock, true
+}
+
+// 发送云台控制指令
+func sipChannelPTZ(channelID, ptzCmd string, priority int) error {
+	channel, device, err := channelDevice(channelID)
+	if err != nil {
+		return err
+	}
+	if err := sipMessage(device, channelAddress(channel), sip.GetPTZControlXML(channel.ChannelID, ptzCmd, priority)); err != nil {
+		logrus.Warnln("PTZControl send error,", channelID, err)
+		return err
+	}
+	return nil
+}
+
+// 获取通道云台控制权，并结束该通道上正在进行的持续转动
+func ptzAcquire(channelID string, op PTZOperator) error {
+	return ptzAcquireAll([]string{channelID}, op)
+}
+
+// SipChannelPTZControl 向通道发送云台控制指令，会结束该通道上正在进行的持续转动
+func SipChannelPTZControl(channelID string, op PTZOperator, ptzCmd string) error {
+	if err := ptzAcquire(channelID, op); err != nil {
+		return err
+	}
+	return sipChannelPTZ(channelID, ptzCmd, op.Priority)
+}
+
+// SipDevicePTZControl 向设备本身发送云台控制指令（单通道IPC）
+// 控制设备本身即控制设备下的通道，需同时获取设备下所有通道的控制权
+func SipDevicePTZControl(device Devices, op PTZOperator, ptzCmd string) error {
+	if err := devicePTZAcquire(device.DeviceID, op); err != nil {
+		return err
+	}
+	return SipPTZControl(device, ptzCmd, op.Priority)
+}
+
+// 获取设备下所有通道的云台控制权，设备没有通道时以设备id加锁
+func devicePTZAcquire(deviceID string, op PTZOperator) error {
+	channels := []Channels{}
+	if err := db.Find(db.DBClient, db.M{"deviceid=?": deviceID}, nil, "", 0, -1, &channels); err != nil {
+		return err
+	}
+	keys := []string{}
+	for _, channel := range channels {
+		keys = append(keys, channel.ChannelID)
+	}
+	if len(keys) == 0 {
+		keys = append(keys, deviceID)
+	}
+	return ptzAcquireAll(keys, op)
+}
+
+// 同时获取多个通道的云台控制权，任一通道被锁定时都不获取
+func ptzAcquireAll(channelIDs []string, op PTZOperator) error {
+	_ptzLocks.Lock()
+	defer _ptzLocks.Unlock()
+	for _, channelID := range channelIDs {
+		if err := _ptzLocks.check(channelID, op); err != nil {
+			return err
+		}
+	}
+	for _, channelID := range channelIDs {
+		lock, _ := _ptzLocks.acquire(channelID, op)
+		lock.stopTimer()
+	}
+	return nil
+}
+
+// DragZoom 拉框放大/缩小参数，坐标均以播放窗口左上角为原点，单位像素
+type DragZoom struct {
+	// ZoomIn true 拉框放大 false 拉框缩小
+	ZoomIn bool
+	// Length 播放窗口长度
+	Length int
+	// Width 播放窗口宽度
+	Width int
+	// MidPointX 拉框中心X坐标
+	MidPointX int
+	// MidPointY 拉框中心Y坐标
+	MidPointY int
+	// LengthX 拉框长度
+	LengthX int
+	// LengthY 拉框宽度
+	LengthY int
+}
+
+// SipDragZoom 向通道发送拉框放大/缩小指令
+func SipDragZoom(channelID string, op PTZOperator, zoom DragZoom) error {
+	if err := ptzAcquire(channelID, op); err != nil {
+		return err
+	}
+	channel, device, err := channelDevice(channelID)
+	if err != nil {
+		return err
+	}
+	sn := utils.RandInt(100000, 999999)
+	body := sip.GetDragZoomXML(channel.ChannelID, sn, zoom.ZoomIn, zoom.Length, zoom.Width, zoom.MidPointX, zoom.MidPointY, zoom.LengthX, zoom.LengthY)
+	if err := sipMessage(device, channelAddress(channel), body); err != nil {
+		logrus.Warnln("DragZoom send error,", channelID, err)
+		return err
+	}
+	return nil
+}
+
+// SipPTZPreciseCtrl 向通道发送云台精准控制指令，pan/tilt 单位为度，zoom 为变倍倍数
+func SipPTZPreciseCtrl(channelID string, op PTZOperator, pan, tilt, zoom float64) error {
+	if err := ptzAcquire(channelID, op); err != nil {
+		return err
+	}
+	channel, device, err := channelDevice(channelID)
+	if err != nil {
+		return err
+	}
+	sn := utils.RandInt(100000, 999999)
+	if err := sipMessage(device, channelAddress(channel), sip.GetPTZPreciseCtrlXML(channel.ChannelID, sn, pan, tilt, zoom)); err != nil {
+		logrus.Warnln("PTZPreciseCtrl send error,", channelID, err)
+		return err
+	}
+	return nil
+}
+
+// SipPTZMoveStart 开始持续转动，需要在 ptzMoveTimeout 内调用 SipPTZMoveHeartbeat 保持转动，否则自动停止
+func SipPTZMoveStart(channelID string, op PTZOperator, ptzCmd string) (*PTZLock, error) {
+	_ptzLocks.Lock()
+	lock, err := _ptzLocks.acquire(channelID, op)
+	if err != nil {
+		_ptzLocks.Unlock()
+		return nil, err
+	}
+	lock.stopTimer()
+	lock.Moving = true
+	lock.timer = time.AfterFunc(ptzMoveTimeout, func() { ptzMoveTimeoutStop(channelID, lock) })
+	res := *lock
+	_ptzLocks.Unlock()
+	if err := sipChannelPTZ(channelID, ptzCmd, op.Priority); err != nil {
+		_ptzLocks.Lock()
+		lock.stopTimer()
+		_ptzLocks.Unlock()
+		return nil, err
+	}
+	return &res, nil
+}
+
+// SipPTZMoveHeartbeat 持续转动心跳，延长自动停止时间
+func SipPTZMoveHeartbeat(channelID string, op PTZOperator) (*PTZLock, error) {
+	_ptzLocks.Lock()
+	defer _ptzLocks.Unlock()
+	lock, ok := _ptzLocks.items[channelID]
+	if !ok || !lock.Moving {
+		return nil, errors.New("云台未在持续转动")
+	}
+	if lock.Operator != op.Operator {
+		return nil, fmt.Errorf("云台正在被%s控制", lock.Operator)
+	}
+	lock.timer.Reset(ptzMoveTimeout)
+	lock.ExpireAt = time.Now().Add(ptzLockTimeout).Unix()
+	res := *lock
+	return &res, nil
+}
+
+// SipPTZMoveStop 结束持续转动并发送停止指令
+func SipPTZMoveStop(channelID string, op PTZOperator) error {
+	return SipChannelPTZControl(channelID, op, ptzHaltCmd)
+}
+
+// 心跳超时，自动发送停止指令
+func ptzMoveTimeoutStop(channelID string, lock *PTZLock) {
+	_ptzLocks.Lock()
+	if _ptzLocks.items[channelID] != lock || !lock.Moving {
+		// 已被抢占或已停止
+		_ptzLocks.Unlock()
+		return
+	}
+	lock.stopTimer()
+	op := lock.PTZOperator
+	_ptzLocks.Unlock()
+	logrus.Infoln("ptz move heartbeat timeout, send halt,", channelID, op.Operator)
+	if err := sipChannelPTZ(channelID, ptzHaltCmd, op.Priority); err != nil {
+		logrus.Warnln("ptz move auto halt error,", channelID, err)
+	}
+}
+
+// SipHomePosition 设置通道看守位，enabled=0 时关闭看守位
+func SipHomePosition(channelID string, enabled, resetTime, presetIndex int) (*MessageResponse, error) {
+	channel, device, err := channelDevice(channelID)
+	if err != nil {
+		return nil, err
+	}
+	sn := utils.RandInt(100000, 999999)
+	body, err := sipMessageQuery(device, channelAddress(channel), "DeviceControl", channel.ChannelID, sn, sip.GetHomePositionXML(channel.ChannelID, sn, enabled, resetTime, presetIndex))
+	if err != nil {
+		return nil, err
+	}
+	return parseMessageResponse(body)
+}
+
+// SipPresetQuery 查询设备上保存的预置位，并使用本地保存的名称
+func SipPresetQuery(channelID string) ([]Presets, error) {
+	channel, device, err := channelDevice(channelID)
+	if err != nil {
+		return nil, err
+	}
+	sn := utils.RandInt(100000, 999999)
+	body, err := sipMessageQuery(device, channelAddress(channel), "PresetQuery", channel.ChannelID, sn, sip.GetPresetQueryXML(channel.ChannelID, sn))
+	if err != nil {
+		return nil, err
+	}
+	res := &PresetQuery{}
+	if err := utils.XMLDecode(body, res); err != nil {
+		return nil, err
+	}
+	locals := []Presets{}
+	db.FindT(db.DBClient, new(Presets), &locals, db.M{"channelid=?": channelID}, "", 0, -1, false)
+	names := map[int]Presets{}
+	for _, p := range locals {
+		names[p.PresetID] = p
+	}
+	list := []Presets{}
+	for _, item := range res.Items {
+		preset, ok := names[item.PresetID]
+		if !ok {
+			// 本地不存在的预置位，使用设备上的名称保存
+			preset = Presets{ChannelID: channelID, PresetID: item.PresetID, Name: item.PresetName}
+			db.Create(db.DBClient, &preset)
+		}
+		list = append(list, preset)
+	}
+	return list, nil
+}
+
+// sipMessagePresetQuery 预置位查询应答，投递给等待方
+func sipMessagePresetQuery(_ Devices, body []byte) error {
+	message := &PresetQuery{}
+	if err := utils.XMLDecode(body, message); err != nil {
+		logrus.Errorln("sipMessagePresetQuery Unmarshal xml err:", err, "body:", string(body))
+		return err
+	}
+	if !sipMessageReply(message.CmdType, message.DeviceID, message.SN, body) {
+		logrus.Infoln("sipMessagePresetQuery not found wait,", message.DeviceID, message.SN)
+	}
+	return nil
+}
+
+// SavePreset 保存预置位名称
+func SavePreset(channelID string, presetID int, name string) (*Presets, error) {
+	preset := &Presets{}
+	if err := db.GetQ(db.DBClient, preset, db.M{"channelid=?": channelID, "presetid=?": presetID}); err != nil {
+		if !db.RecordNotFound(err) {
+			return nil, err
+		}
+		preset = &Presets{ChannelID: channelID, PresetID: presetID}
+	}
+	preset.Name = name
+	return preset, db.Save(db.DBClient, preset)
+}
+
+// DeletePreset 删除本地保存的预置位
+func DeletePreset(channelID string, presetID int) error {
+	return db.DelQ(db.DBClient, new(Presets), db.M{"channelid=?": channelID, "presetid=?": presetID})
+}
@@ -0,0 +1,61 @@
+package sipapi
+
+import (
+	"testing"
+
+	"github.com/panjjo/gosip/m"
+)
+
+func TestPTZLockPriority(t *testing.T) {
+	testSetup(t)
+	config.PTZ.Priorities = []m.PTZPriority{{Principal: "10.0.0.1", Priority: 5}, {Principal: "10.0.0.3", Priority: 9}}
+	_ptzLocks = &ptzLocks{items: map[string]*PTZLock{}}
+
+	if p := PTZPriority("10.0.0.1"); p != 5 {
+		t.Fatalf("priority = %d, want 5", p)
+	}
+	// 未配置或配置越界的请求方使用默认优先级
+	for _, principal := range []string{"10.0.0.2", "10.0.0.3"} {
+		if p := PTZPriority(principal); p != PTZPriorityDefault {
+			t.Fatalf("priority of %s = %d, want %d", principal, p, PTZPriorityDefault)
+		}
+	}
+
+	low := PTZOperator{Operator: "10.0.0.2", Priority: PTZPriority("10.0.0.2")}
+	high := PTZOperator{Operator: "10.0.0.1", Priority: PTZPriority("10.0.0.1")}
+	if err := ptzAcquire("34020000001110000001", low); err != nil {
+		t.Fatal(err)
+	}
+	if err := ptzAcquire("34020000001110000001", high); err != nil {
+		t.Fatalf("higher priority not preempted: %v", err)
+	}
+	if err := ptzAcquire("34020000001110000001", low); err == nil {
+		t.Fatal("lower priority acquired locked device")
+	}
+}
+
+// 设备级控制与通道级控制使用同一通道锁
+func TestPTZLockDeviceChannel(t *testing.T) {
+	testSetup(t)
+	testChannel(t, "34020000001310000001", "34020000001110000001")
+	_ptzLocks = &ptzLocks{items: map[string]*PTZLock{}}
+	low := PTZOperator{Operator: "10.0.0.2", Priority: PTZPriorityDefault}
+	same := PTZOperator{Operator: "10.0.0.3", Priority: PTZPriorityDefault}
+	high := PTZOperator{Operator: "10.0.0.1", Priority: PTZPriorityMax}
+
+	if err := ptzAcquire("34020000001310000001", low); err != nil {
+		t.Fatal(err)
+	}
+	if err := devicePTZAcquire("34020000001110000001", same); err == nil {
+		t.Fatal("device level control ignored channel lock")
+	}
+	if err := devicePTZAcquire("34020000001110000001", high); err != nil {
+		t.Fatalf("device level control not preempted: %v", err)
+	}
+	if err := ptzAcquire("34020000001310000001", low); err == nil {
+		t.Fatal("channel level control ignored device level lock")
+	}
+	if lock, ok := GetPTZLock("34020000001310000001"); !ok || lock.Operator != high.Operator {
+		t.Fatalf("lock = %+v", lock)
+	}
+}
@@ -0,0 +1,131 @@
+package sipapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/panjjo/gosip/db"
+	"github.com/panjjo/gosip/media"
+	"github.com/sirupsen/logrus"
+)
+
+// rtsp 拉流方式
+const (
+	PullRtpTCP       = 0
+	PullRtpUDP       = 1
+	PullRtpMulticast = 2
+)
+
+// 拉流超时时间，单位秒
+const pullTimeoutSec = 10
+
+// 支持拉流的协议，http/https 为 http-flv
+var pullSchemes = map[string]bool{
+	"rtsp":  true,
+	"rtsps": true,
+	"rtmp":  true,
+	"rtmps": true,
+	"http":  true,
+	"https": true,
+}
+
+// CheckPullURL 检查拉流地址
+func CheckPullURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("拉流地址错误:%v", err)
+	}
+	if !pullSchemes[strings.ToLower(u.Scheme)] || u.Host == "" {
+		return fmt.Errorf("不支持的拉流地址:%s", raw)
+	}
+	return nil
+}
+
+// CheckPullRtpType 检查rtsp拉流方式
+func CheckPullRtpType(t int) bool {
+	return t == PullRtpTCP || t == PullRtpUDP || t == PullRtpMulticast
+}
+
+// 通道拉流地址，设置了账号时写入地址中
+func pullURL(channel Channels) (string, error) {
+	if err := CheckPullURL(channel.URL); err != nil {
+		return "", err
+	}
+	u, _ := url.Parse(channel.URL)
+	if channel.PullUsername != "" {
+		u.User = url.UserPassword(channel.PullUsername, channel.PullPassword)
+	}
+	return u.String(), nil
+}
+
+// 在媒体服务器添加拉流代理，已存在的代理先删除
+func sipPullProxy(data *Streams, channel Channels) error {
+	addr, err := pullURL(channel)
+	if err != nil {
+		return err
+	}
+	mediaServer := streamMedia(data)
+	if data.ProxyKey != "" {
+		if err := mediaServer.DelStreamProxy(context.Background(), data.ProxyKey); err != nil && !errors.Is(err, media.ErrNotFound) {
+			logrus.Warnln("sipPullProxy del proxy fail,", data.StreamID, data.ProxyKey, err)
+		}
+		data.ProxyKey = ""
+	}
+	key, err := mediaServer.AddStreamProxy(context.Background(), media.StreamProxyReq{
+		App:        "rtp",
+		Stream:     data.StreamID,
+		URL:        addr,
+		RetryCount: channel.PullRetry,
+		RTPType:    channel.PullRtpType,
+		TimeoutSec: pullTimeoutSec,
+	})
+	if err != nil {
+		return err
+	}
+	data.ProxyKey = key
+	data.Status = 0
+	return nil
+}
+
+// 删除拉流代理，代理不存在时视为成功
+func sipPullStop(play *Streams) error {
+	if play.ProxyKey == "" {
+		return nil
+	}
+	err := streamMedia(play).DelStreamProxy(context.Background(), play.ProxyKey)
+	if err != nil && !errors.Is(err, media.ErrNotFound) {
+		return err
+	}
+	play.ProxyKey = ""
+	return nil
+}
+
+// 清理不在流列表中的拉流代理，如服务重启前未关闭的流
+func checkPullStreams() {
+	var skip int
+	for {
+		streams := []Streams{}
+		db.FindT(db.DBClient, new(Streams), &streams, db.M{"status=?": 0, "streamtype=?": "pull"}, "", skip, 100, false)
+		for _, stream := range streams {
+			if _, ok := StreamList.Response.Load(stream.StreamID); ok {
+				continue
+			}
+			if err := sipPullStop(&stream); err != nil {
+				logrus.Warnln("checkPullStreams del proxy fail,", stream.StreamID, err)
+				stream.Msg = err.Error()
+				db.Save(db.DBClient, &stream)
+				continue
+			}
+			stream.Status = 1
+			stream.Stop = true
+			db.Save(db.DBClient, &stream)
+		}
+		if len(streams) != 100 {
+			break
+		}
+		skip += 100
+	}
+}
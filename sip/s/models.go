@@ -1,7 +1,10 @@
 package sip
 
 import (
+	"bytes"
+	"encoding/xml"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -78,6 +81,9 @@ var ContentTypeSDP = ContentType("application/sdp")
 // ContentTypeXML XML contenttype
 var ContentTypeXML = ContentType("Application/MANSCDP+xml")
 
+// ContentTypeMANSRTSP 回放控制 contenttype
+var ContentTypeMANSRTSP = ContentType("Application/MANSRTSP")
+
 var (
 	// CatalogXML 获取设备列表xml样式
 	CatalogXML = `<?xml version="1.0" encoding="GB2312"?>
@@ -106,6 +112,32 @@ var (
 <SN>%d</SN>
 <DeviceID>%s</DeviceID>
 </Query>
+`
+	// DeviceStatusXML 查询设备状态xml样式
+	DeviceStatusXML = `<?xml version="1.0" encoding="GB2312"?>
+<Query>
+<CmdType>DeviceStatus</CmdType>
+<SN>%d</SN>
+<DeviceID>%s</DeviceID>
+</Query>
+`
+	// ConfigDownloadXML 查询设备配置xml样式
+	ConfigDownloadXML = `<?xml version="1.0" encoding="GB2312"?>
+<Query>
+<CmdType>ConfigDownload</CmdType>
+<SN>%d</SN>
+<DeviceID>%s</DeviceID>
+<ConfigType>%s</ConfigType>
+</Query>
+`
+	// DeviceConfigXML 设备配置xml样式
+	DeviceConfigXML = `<?xml version="1.0" encoding="GB2312"?>
+<Control>
+<CmdType>DeviceConfig</CmdType>
+<SN>%d</SN>
+<DeviceID>%s</DeviceID>
+%s
+</Control>
 `
 	// PTZControlXML 摄像头云台控制xml样式
 	PTZControlXML = `<?xml version="1.0" encoding="GB2312"?>
@@ -115,9 +147,77 @@ var (
 <DeviceID>%s</DeviceID>
 <PTZCmd>%s</PTZCmd>
 <Info>
-<ControlPriority>5</ControlPriority>
+<ControlPriority>%d</ControlPriority>
 </Info>
 </Control>
+`
+	// HomePositionXML 看守位控制xml样式
+	HomePositionXML = `<?xml version="1.0" encoding="GB2312"?>
+<Control>
+<CmdType>DeviceControl</CmdType>
+<SN>%d</SN>
+<DeviceID>%s</DeviceID>
+<HomePosition>
+<Enabled>%d</Enabled>
+<ResetTime>%d</ResetTime>
+<PresetIndex>%d</PresetIndex>
+</HomePosition>
+</Control>
+`
+	// DeviceControlXML 设备控制xml样式，%s 为控制命令内容
+	DeviceControlXML = `<?xml version="1.0" encoding="GB2312"?>
+<Control>
+<CmdType>DeviceControl</CmdType>
+<SN>%d</SN>
+<DeviceID>%s</DeviceID>
+%s
+</Control>
+`
+	// DragZoomXML 拉框放大/缩小xml样式，第三个参数为 DragZoomIn 或 DragZoomOut
+	DragZoomXML = `<?xml version="1.0" encoding="GB2312"?>
+<Control>
+<CmdType>DeviceControl</CmdType>
+<SN>%d</SN>
+<DeviceID>%s</DeviceID>
+<%[3]s>
+<Length>%d</Length>
+<Width>%d</Width>
+<MidPointX>%d</MidPointX>
+<MidPointY>%d</MidPointY>
+<LengthX>%d</LengthX>
+<LengthY>%d</LengthY>
+</%[3]s>
+</Control>
+`
+	// PTZPreciseCtrlXML 云台精准控制xml样式
+	PTZPreciseCtrlXML = `<?xml version="1.0" encoding="GB2312"?>
+<Control>
+<CmdType>DeviceControl</CmdType>
+<SN>%d</SN>
+<DeviceID>%s</DeviceID>
+<PTZPreciseCtrl>
+<Pan>%.2f</Pan>
+<Tilt>%.2f</Tilt>
+<Zoom>%.2f</Zoom>
+</PTZPreciseCtrl>
+</Control>
+`
+	// PresetQueryXML 预置位查询xml样式
+	PresetQueryXML = `<?xml version="1.0" encoding="GB2312"?>
+<Query>
+<CmdType>PresetQuery</CmdType>
+<SN>%d</SN>
+<DeviceID>%s</DeviceID>
+</Query>
+`
+	// BroadcastXML 语音广播通知xml样式
+	BroadcastXML = `<?xml version="1.0" encoding="GB2312"?>
+<Notify>
+<CmdType>Broadcast</CmdType>
+<SN>%d</SN>
+<SourceID>%s</SourceID>
+<TargetID>%s</TargetID>
+</Notify>
 `
 )
 
@@ -126,6 +226,101 @@ func GetDeviceInfoXML(deviceID string) []byte {
 	return fmt.Appendf(nil, DeviceInfoXML, utils.RandInt(100000, 999999), deviceID)
 }
 
+// GetDeviceStatusXML 获取设备状态指令
+func GetDeviceStatusXML(deviceID string, sn int) []byte {
+	return fmt.Appendf(nil, DeviceStatusXML, sn, deviceID)
+}
+
+// GetConfigDownloadXML 获取设备配置查询指令，configType 多个配置类型使用/分隔
+func GetConfigDownloadXML(deviceID string, sn int, configType string) []byte {
+	return fmt.Appendf(nil, ConfigDownloadXML, sn, deviceID, configType)
+}
+
+// GetDeviceConfigXML 获取设备配置指令，config 为配置项xml
+func GetDeviceConfigXML(deviceID string, sn int, config []byte) []byte {
+	return fmt.Appendf(nil, DeviceConfigXML, sn, deviceID, config)
+}
+
+// GetHomePositionXML 获取看守位控制指令
+func GetHomePositionXML(deviceID string, sn, enabled, resetTime, presetIndex int) []byte {
+	return fmt.Appendf(nil, HomePositionXML, sn, deviceID, enabled, resetTime, presetIndex)
+}
+
+// GetSnapShotConfigXML 获取图像抓拍配置指令
+func GetSnapShotConfigXML(deviceID string, sn, snapNum, interval int, uploadURL, sessionID string) []byte {
+	config := fmt.Sprintf("<SnapShotConfig>\n<SnapNum>%d</SnapNum>\n<Interval>%d</Interval>\n<UploadURL>%s</UploadURL>\n<SessionID>%s</SessionID>\n</SnapShotConfig>",
+		snapNum, interval, xmlText(uploadURL), xmlText(sessionID))
+	return GetDeviceConfigXML(deviceID, sn, []byte(config))
+}
+
+// xmlText 转义xml文本内容，地址等外部传入的值写入xml前需要转义
+func xmlText(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// GetTeleBootXML 获取远程启动指令
+func GetTeleBootXML(deviceID string, sn int) []byte {
+	return fmt.Appendf(nil, DeviceControlXML, sn, deviceID, "<TeleBoot>Boot</TeleBoot>")
+}
+
+// GetRecordCmdXML 获取录像控制指令，cmd 为 Record 或 StopRecord
+func GetRecordCmdXML(deviceID string, sn int, cmd string) []byte {
+	return fmt.Appendf(nil, DeviceControlXML, sn, deviceID, fmt.Sprintf("<RecordCmd>%s</RecordCmd>", cmd))
+}
+
+// GetGuardCmdXML 获取布防/撤防指令，cmd 为 SetGuard 或 ResetGuard
+func GetGuardCmdXML(deviceID string, sn int, cmd string) []byte {
+	return fmt.Appendf(nil, DeviceControlXML, sn, deviceID, fmt.Sprintf("<GuardCmd>%s</GuardCmd>", cmd))
+}
+
+// GetAlarmCmdXML 获取报警复位指令，alarmMethod/alarmType 为空时复位全部报警
+func GetAlarmCmdXML(deviceID string, sn int, alarmMethod, alarmType string) []byte {
+	cmd := "<AlarmCmd>ResetAlarm</AlarmCmd>"
+	if alarmMethod != "" || alarmType != "" {
+		cmd += fmt.Sprintf("\n<Info>\n<AlarmMethod>%s</AlarmMethod>\n<AlarmType>%s</AlarmType>\n</Info>", xmlText(alarmMethod), xmlText(alarmType))
+	}
+	return fmt.Appendf(nil, DeviceControlXML, sn, deviceID, cmd)
+}
+
+// GetIFameCmdXML 获取强制关键帧指令
+func GetIFameCmdXML(deviceID string, sn int) []byte {
+	return fmt.Appendf(nil, DeviceControlXML, sn, deviceID, "<IFameCmd>Send</IFameCmd>")
+}
+
+// GetDeviceUpgradeXML 获取设备升级指令
+func GetDeviceUpgradeXML(deviceID string, sn int, firmware, fileURL, manufacturer, sessionID string) []byte {
+	cmd := fmt.Sprintf("<DeviceUpgrade>\n<Firmware>%s</Firmware>\n<FileURL>%s</FileURL>\n<Manufacturer>%s</Manufacturer>\n<SessionID>%s</SessionID>\n</DeviceUpgrade>",
+		xmlText(firmware), xmlText(fileURL), xmlText(manufacturer), xmlText(sessionID))
+	return fmt.Appendf(nil, DeviceControlXML, sn, deviceID, cmd)
+}
+
+// GetDragZoomXML 获取拉框放大/缩小指令，zoomIn=true 时为拉框放大
+// length/width 为播放窗口长宽，坐标均以播放窗口左上角为原点
+func GetDragZoomXML(deviceID string, sn int, zoomIn bool, length, width, midPointX, midPointY, lengthX, lengthY int) []byte {
+	cmd := "DragZoomOut"
+	if zoomIn {
+		cmd = "DragZoomIn"
+	}
+	return fmt.Appendf(nil, DragZoomXML, sn, deviceID, cmd, length, width, midPointX, midPointY, lengthX, lengthY)
+}
+
+// GetPTZPreciseCtrlXML 获取云台精准控制指令
+func GetPTZPreciseCtrlXML(deviceID string, sn int, pan, tilt, zoom float64) []byte {
+	return fmt.Appendf(nil, PTZPreciseCtrlXML, sn, deviceID, pan, tilt, zoom)
+}
+
+// GetPresetQueryXML 获取预置位查询指令
+func GetPresetQueryXML(deviceID string, sn int) []byte {
+	return fmt.Appendf(nil, PresetQueryXML, sn, deviceID)
+}
+
+// GetBroadcastXML 获取语音广播通知，sourceID 为广播源编码，targetID 为接收广播的语音输出通道编码
+func GetBroadcastXML(sourceID, targetID string, sn int) []byte {
+	return fmt.Appendf(nil, BroadcastXML, sn, sourceID, targetID)
+}
+
 // GetCatalogXML 获取NVR下设备列表指令
 func GetCatalogXML(deviceID string) []byte {
 	return fmt.Appendf(nil, CatalogXML, utils.RandInt(100000, 999999), deviceID)
@@ -136,9 +331,32 @@ func GetRecordInfoXML(deviceID string, sceqNo int, start, end int64) []byte {
 	return fmt.Appendf(nil, RecordInfoXML, sceqNo, deviceID, time.Unix(start, 0).Format("2006-01-02T15:04:05"), time.Unix(end, 0).Format("2006-01-02T15:04:05"))
 }
 
-// GetPTZControlXML 获取摄像头云台控制指令
-func GetPTZControlXML(deviceID string, ptzCmd string) []byte {
-	return fmt.Appendf(nil, PTZControlXML, utils.RandInt(100000, 999999), deviceID, ptzCmd)
+// GetPTZControlXML 获取摄像头云台控制指令，priority 为云台控制优先级
+func GetPTZControlXML(deviceID string, ptzCmd string, priority int) []byte {
+	return fmt.Appendf(nil, PTZControlXML, utils.RandInt(100000, 999999), deviceID, ptzCmd, priority)
+}
+
+// GetMANSRTSPPlay 获取回放播放控制消息，scale 为0时不指定倍速，npt 为空时不指定播放位置，now 表示从当前位置继续播放
+func GetMANSRTSPPlay(cseq int, scale float64, npt string) []byte {
+	b := fmt.Appendf(nil, "PLAY RTSP/1.0\r\nCSeq: %d\r\n", cseq)
+	if scale > 0 {
+		b = fmt.Appendf(b, "Scale: %s\r\n", strconv.FormatFloat(scale, 'f', -1, 64))
+	}
+	if npt != "" {
+		b = fmt.Appendf(b, "Range: npt=%s-\r\n", npt)
+	}
+	// 按 RTSP 消息格式以空行结束
+	return append(b, "\r\n"...)
+}
+
+// GetMANSRTSPPause 获取回放暂停消息
+func GetMANSRTSPPause(cseq int) []byte {
+	return fmt.Appendf(nil, "PAUSE RTSP/1.0\r\nCSeq: %d\r\nPauseTime: now\r\n\r\n", cseq)
+}
+
+// GetMANSRTSPTeardown 获取回放结束消息
+func GetMANSRTSPTeardown(cseq int) []byte {
+	return fmt.Appendf(nil, "TEARDOWN RTSP/1.0\r\nCSeq: %d\r\n\r\n", cseq)
 }
 
 // RFC3261BranchMagicCookie RFC3261BranchMagicCookie
@@ -0,0 +1,30 @@
+package sip
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMANSRTSP(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  []byte
+		want string
+	}{
+		{"play", GetMANSRTSPPlay(1, 0, ""), "PLAY RTSP/1.0\r\nCSeq: 1\r\n\r\n"},
+		{"play scale range", GetMANSRTSPPlay(2, 0.5, "100"), "PLAY RTSP/1.0\r\nCSeq: 2\r\nScale: 0.5\r\nRange: npt=100-\r\n\r\n"},
+		{"pause", GetMANSRTSPPause(3), "PAUSE RTSP/1.0\r\nCSeq: 3\r\nPauseTime: now\r\n\r\n"},
+		{"teardown", GetMANSRTSPTeardown(4), "TEARDOWN RTSP/1.0\r\nCSeq: 4\r\n\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := string(tt.msg)
+			if !strings.HasSuffix(msg, "\r\n\r\n") {
+				t.Fatalf("message not terminated by empty line: %q", msg)
+			}
+			if msg != tt.want {
+				t.Fatalf("message = %q, want %q", msg, tt.want)
+			}
+		})
+	}
+}
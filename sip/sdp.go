@@ -0,0 +1,192 @@
+package sipapi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	sdp "github.com/panjjo/gosdp"
+)
+
+// 音频编码
+const (
+	AudioCodecPCMA = "PCMA"
+	AudioCodecPCMU = "PCMU"
+	AudioCodecAAC  = "AAC"
+)
+
+// 音频编码对应的默认 rtp payload type 和 rtpmap
+var audioCodecs = map[string]struct {
+	PT     string
+	RtpMap string
+}{
+	AudioCodecPCMA: {"8", "PCMA/8000"},
+	AudioCodecPCMU: {"0", "PCMU/8000"},
+	AudioCodecAAC:  {"97", "mpeg4-generic/8000"},
+}
+
+// CheckAudioCodec 校验是否为支持的音频编码
+func CheckAudioCodec(codec string) bool {
+	_, ok := audioCodecs[codec]
+	return ok
+}
+
+// 视频编码
+const (
+	VideoCodecPS    = "PS"
+	VideoCodecH264  = "H264"
+	VideoCodecH265  = "H265"
+	VideoCodecMPEG4 = "MPEG4"
+)
+
+// rtpmap 编码名称对应的视频编码
+var rtpmapVideoCodecs = map[string]string{
+	"PS":      VideoCodecPS,
+	"MP2P":    VideoCodecPS,
+	"H264":    VideoCodecH264,
+	"H265":    VideoCodecH265,
+	"HEVC":    VideoCodecH265,
+	"MP4V-ES": VideoCodecMPEG4,
+}
+
+// 缺少 rtpmap 时gb28181常用的视频 payload type
+var ptVideoCodecs = map[string]string{
+	"96": VideoCodecPS,
+	"97": VideoCodecMPEG4,
+	"98": VideoCodecH264,
+	"99": VideoCodecH265,
+}
+
+// f= 字段中视频编码格式对应的编码 1 MPEG-4 2 H.264 5 H.265
+var sdpFVideoCodecs = map[string]string{
+	"1": VideoCodecMPEG4,
+	"2": VideoCodecH264,
+	"5": VideoCodecH265,
+}
+
+// zlm 音频track编码id对应的音频编码
+var zlmAudioCodecs = map[int]string{
+	2: AudioCodecAAC,
+	3: AudioCodecPCMA,
+	4: AudioCodecPCMU,
+}
+
+// sdpField 获取sdp中指定类型行的值，用于解析 y= f= 等gb28181扩展字段
+func sdpField(body []byte, key byte) string {
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) > 2 && line[0] == key && line[1] == '=' {
+			return string(line[2:])
+		}
+	}
+	return ""
+}
+
+// sdpAudioFormat 按sdp中媒体格式的顺序选择第一个 codecs 中包含的音频编码
+// 返回 payload type、音频编码和 rtpmap，未找到时返回空
+func sdpAudioFormat(media *sdp.Media, codecs []string) (pt, codec, rtpmap string) {
+	for _, f := range media.Description.Formats {
+		rtpmap = media.PayloadFormat(f)
+		name := strings.ToUpper(strings.Split(rtpmap, "/")[0])
+		switch {
+		case name == "PCMA" || (name == "" && f == "8"):
+			codec = AudioCodecPCMA
+		case name == "PCMU" || (name == "" && f == "0"):
+			codec = AudioCodecPCMU
+		case name == "MPEG4-GENERIC":
+			codec = AudioCodecAAC
+		default:
+			continue
+		}
+		for _, c := range codecs {
+			if c == codec {
+				if rtpmap == "" {
+					rtpmap = audioCodecs[codec].RtpMap
+				}
+				return f, codec, rtpmap
+			}
+		}
+	}
+	return "", "", ""
+}
+
+// sdpVideoAnswer 设备应答sdp中的视频媒体信息
+type sdpVideoAnswer struct {
+	// 设备发送媒体的地址
+	IP   string
+	Port int
+	// udp tcp
+	Transport string
+	// tcp 连接方式 active passive
+	Setup string
+	SSRC  string
+	PT    string
+	// 视频编码，PS封装时为PS
+	Codec string
+	// f= 媒体参数
+	F string
+}
+
+// parseVideoAnswer 解析并校验设备应答的sdp
+func parseVideoAnswer(body []byte) (*sdpVideoAnswer, error) {
+	msg, err := sdp.Decode(body)
+	if err != nil {
+		return nil, fmt.Errorf("设备sdp解析失败:%v", err)
+	}
+	var media *sdp.Media
+	for i := range msg.Medias {
+		if msg.Medias[i].Description.Type == "video" {
+			media = &msg.Medias[i]
+			break
+		}
+	}
+	if media == nil {
+		return nil, errors.New("设备sdp缺少视频媒体")
+	}
+	if media.Description.Port <= 0 {
+		return nil, errors.New("设备拒绝视频媒体")
+	}
+	answer := &sdpVideoAnswer{
+		IP:    media.Connection.IP.String(),
+		Port:  media.Description.Port,
+		Setup: media.Attributes.Value("setup"),
+		SSRC:  sdpField(body, 'y'),
+		F:     sdpField(body, 'f'),
+	}
+	if media.Connection.IP == nil {
+		answer.IP = msg.Connection.IP.String()
+	}
+	protocol := strings.ToUpper(media.Description.Protocol)
+	switch {
+	case strings.HasPrefix(protocol, "TCP/RTP"):
+		answer.Transport = "tcp"
+	case strings.HasPrefix(protocol, "RTP/"):
+		answer.Transport = "udp"
+	default:
+		return nil, fmt.Errorf("不支持的传输协议:%s", media.Description.Protocol)
+	}
+	for _, f := range media.Description.Formats {
+		name := strings.ToUpper(strings.Split(media.PayloadFormat(f), "/")[0])
+		codec, ok := rtpmapVideoCodecs[name]
+		if !ok && name == "" {
+			codec, ok = ptVideoCodecs[f]
+		}
+		if ok {
+			answer.PT, answer.Codec = f, codec
+			break
+		}
+	}
+	if answer.Codec == "" {
+		return nil, fmt.Errorf("不支持的视频编码:%v", media.Description.Formats)
+	}
+	if answer.Codec == VideoCodecPS {
+		// PS封装的实际视频编码由 f= 字段说明
+		if fields := strings.Split(strings.TrimPrefix(answer.F, "v/"), "/"); len(fields) > 1 {
+			if codec, ok := sdpFVideoCodecs[fields[0]]; ok {
+				answer.Codec = VideoCodecPS + "/" + codec
+			}
+		}
+	}
+	return answer, nil
+}
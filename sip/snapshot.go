@@ -0,0 +1,193 @@
+package sipapi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/panjjo/gosip/db"
+	sip "github.com/panjjo/gosip/sip/s"
+	"github.com/panjjo/gosip/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// 抓拍会话在最后一张图片预计上传时间之后的保留时间
+const snapshotSessionTimeout = 60 * time.Second
+
+// Snapshots 设备抓拍图片
+type Snapshots struct {
+	db.DBModel
+	// ChannelID 通道编码
+	ChannelID string `json:"channelid" gorm:"column:channelid"`
+	// DeviceID 设备编号
+	DeviceID string `json:"deviceid" gorm:"column:deviceid"`
+	// SessionID 抓拍会话id
+	SessionID string `json:"sessionid" gorm:"column:sessionid"`
+	// File 图片文件相对路径
+	File string `json:"file" gorm:"column:file"`
+	// Size 图片大小
+	Size int64 `json:"size" gorm:"column:size"`
+}
+
+// snapshotSession 抓拍会话，设备上传图片时使用会话id校验
+type snapshotSession struct {
+	SessionID string
+	ChannelID string
+	DeviceID  string
+	SnapNum   int
+	ExpireAt  time.Time
+
+	sync.Mutex
+	count int
+}
+
+// SnapshotTask 抓拍任务
+type SnapshotTask struct {
+	// SessionID 抓拍会话id
+	SessionID string `json:"sessionid"`
+	// ChannelID 通道编码
+	ChannelID string `json:"channelid"`
+	// SnapNum 抓拍张数
+	SnapNum int `json:"snapnum"`
+	// Interval 抓拍间隔，单位秒
+	Interval int `json:"interval"`
+}
+
+// 抓拍会话 key=sessionid value=*snapshotSession
+var _snapshotSessions sync.Map
+
+// SipSnapShot 下发图像抓拍配置，设备抓拍后将图片上传到本服务
+func SipSnapShot(channelID string, snapNum, interval int) (*SnapshotTask, error) {
+	if config.Snapshot.UploadURL == "" {
+		return nil, errors.New("未配置抓拍图片上传地址")
+	}
+	channel, device, err := channelDevice(channelID)
+	if err != nil {
+		return nil, err
+	}
+	clearSnapshotSessions()
+	session := &snapshotSession{
+		SessionID: utils.RandString(32),
+		ChannelID: channel.ChannelID,
+		DeviceID:  channel.DeviceID,
+		SnapNum:   snapNum,
+		ExpireAt:  time.Now().Add(time.Duration(snapNum*interval)*time.Second + snapshotSessionTimeout),
+	}
+	_snapshotSessions.Store(session.SessionID, session)
+	uploadURL := SnapshotUploadURL(config.Snapshot.UploadURL, session.SessionID)
+	sn := utils.RandInt(100000, 999999)
+	body, err := sipMessageQuery(device, channelAddress(channel), "DeviceConfig", channel.ChannelID, sn,
+		sip.GetSnapShotConfigXML(channel.ChannelID, sn, snapNum, interval, uploadURL, session.SessionID))
+	if err == nil {
+		_, err = parseMessageResponse(body)
+	}
+	if err != nil {
+		_snapshotSessions.Delete(session.SessionID)
+		return nil, err
+	}
+	return &SnapshotTask{SessionID: session.SessionID, ChannelID: channel.ChannelID, SnapNum: snapNum, Interval: interval}, nil
+}
+
+// SnapshotUploadURL 下发给设备的抓拍图片上传地址，base 为本服务对设备开放的地址
+func SnapshotUploadURL(base, sessionID string) string {
+	return fmt.Sprintf("%s/snapshots/upload/%s", strings.TrimRight(base, "/"), sessionID)
+}
+
+// 清理过期的抓拍会话
+func clearSnapshotSessions() {
+	now := time.Now()
+	_snapshotSessions.Range(func(key, value any) bool {
+		if value.(*snapshotSession).ExpireAt.Before(now) {
+			_snapshotSessions.Delete(key)
+		}
+		return true
+	})
+}
+
+// SaveSnapshot 保存设备上传的抓拍图片
+func SaveSnapshot(sessionID, name string, data io.Reader) (*Snapshots, error) {
+	v, ok := _snapshotSessions.Load(sessionID)
+	if !ok {
+		return nil, errors.New("抓拍会话不存在")
+	}
+	session := v.(*snapshotSession)
+	if session.ExpireAt.Before(time.Now()) {
+		_snapshotSessions.Delete(sessionID)
+		return nil, errors.New("抓拍会话已过期")
+	}
+	session.Lock()
+	if session.count >= session.SnapNum {
+		session.Unlock()
+		return nil, errors.New("超出抓拍张数")
+	}
+	session.count++
+	seq := session.count
+	session.Unlock()
+
+	name = filepath.Base(name)
+	if name == "." || name == "/" || name == "" {
+		name = fmt.Sprintf("%d", seq)
+	}
+	if filepath.Ext(name) == "" {
+		name += ".jpg"
+	}
+	file := filepath.Join(session.ChannelID, time.Now().Format("20060102"), fmt.Sprintf("%s_%s", sessionID, name))
+	filename := filepath.Join(config.Snapshot.FilePath, file)
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	size, err := io.Copy(f, data)
+	if err != nil {
+		os.Remove(filename)
+		return nil, err
+	}
+	snapshot := &Snapshots{
+		ChannelID: session.ChannelID,
+		DeviceID:  session.DeviceID,
+		SessionID: sessionID,
+		File:      filepath.ToSlash(file),
+		Size:      size,
+	}
+	if err := db.Create(db.DBClient, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// MessageUploadSnapShotFinished 抓拍图片上传完成通知
+type MessageUploadSnapShotFinished struct {
+	CmdType   string   `xml:"CmdType"`
+	SN        int      `xml:"SN"`
+	DeviceID  string   `xml:"DeviceID"`
+	SessionID string   `xml:"SessionID"`
+	FileIDs   []string `xml:"SnapShotList>SnapShotFileID"`
+}
+
+// sipMessageUploadSnapShotFinished 设备抓拍图片上传完成，结束抓拍会话并发送通知
+func sipMessageUploadSnapShotFinished(_ Devices, body []byte) error {
+	message := &MessageUploadSnapShotFinished{}
+	if err := utils.XMLDecode(body, message); err != nil {
+		logrus.Errorln("sipMessageUploadSnapShotFinished Unmarshal xml err:", err, "body:", string(body))
+		return err
+	}
+	v, ok := _snapshotSessions.LoadAndDelete(message.SessionID)
+	if !ok {
+		logrus.Infoln("sipMessageUploadSnapShotFinished session not found,", message.DeviceID, message.SessionID)
+		return nil
+	}
+	session := v.(*snapshotSession)
+	files := []Snapshots{}
+	db.FindT(db.DBClient, new(Snapshots), &files, db.M{"sessionid=?": session.SessionID}, "id", 0, -1, false)
+	go notify(notifySnapshotFinished(session, files))
+	return nil
+}
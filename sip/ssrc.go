@@ -0,0 +1,133 @@
+package sipapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/panjjo/gosip/db"
+	"github.com/sirupsen/logrus"
+)
+
+// ssrc 最大序号，ssrc 后4位
+const ssrcMaxSeq = 9999
+
+var errSSRCExhausted = errors.New("ssrc已用尽")
+
+// ssrcPool gb28181 ssrc分配
+// ssrc 为10位十进制数：第1位 0 实时流 1 历史流，第2-6位取自域id第4-8位，后4位为序号
+type ssrcPool struct {
+	mu sync.Mutex
+	// 域id对应的5位前缀
+	region string
+	// 已分配的ssrc
+	used map[string]bool
+	// 实时、历史流下次分配的序号
+	next [2]int
+}
+
+var _ssrcPool = &ssrcPool{used: map[string]bool{}, next: [2]int{1, 1}}
+
+// 按域id初始化，并从未关闭的流中恢复已分配的ssrc
+// 服务重启前未关闭的流，媒体节点上已不存在的标记为已关闭，不再占用ssrc
+func loadSSRCPool(region string) {
+	_ssrcPool.mu.Lock()
+	_ssrcPool.region = region[3:8]
+	_ssrcPool.used = map[string]bool{}
+	_ssrcPool.mu.Unlock()
+
+	var skip int
+	stale := []Streams{}
+	for {
+		streams := []Streams{}
+		db.FindT(db.DBClient, new(Streams), &streams, db.M{"status=?": 0, "stop=?": false}, "", skip, 100, false)
+		for _, stream := range streams {
+			if stream.SSRC != "" {
+				stale = append(stale, stream)
+			}
+		}
+		if len(streams) != 100 {
+			break
+		}
+		skip += 100
+	}
+	closed := 0
+	for _, stream := range stale {
+		if ssrcStreamAlive(stream) {
+			_ssrcPool.reserve(stream.SSRC)
+			continue
+		}
+		closed++
+		db.UpdateAll(db.DBClient, new(Streams), db.M{"streamid=?": stream.StreamID, "stop=?": false}, db.M{"status": 1, "stop": true, "msg": "服务重启时流已不存在"})
+	}
+	logrus.Infoln("ssrc pool loaded, used:", _ssrcPool.count(), "closed:", closed)
+}
+
+// 流是否仍在媒体节点上收流，节点不存在视为已关闭，节点异常无法判断时视为仍在收流
+func ssrcStreamAlive(stream Streams) bool {
+	id := stream.MediaServerID
+	if id == "" {
+		id = config.Media.ID
+	}
+	node, ok := findMediaNode(id)
+	if !ok {
+		return false
+	}
+	info, err := node.driver.GetRtpInfo(context.Background(), stream.StreamID)
+	if err != nil {
+		logrus.Warnln("load ssrc pool get rtp info fail,", stream.StreamID, id, err)
+		return true
+	}
+	return info.Exist
+}
+
+// alloc 分配ssrc，history 为 true 时分配历史流ssrc
+func (p *ssrcPool) alloc(history bool) (string, error) {
+	t := 0
+	if history {
+		t = 1
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := 0; i < ssrcMaxSeq; i++ {
+		seq := p.next[t]
+		p.next[t] = seq%ssrcMaxSeq + 1
+		ssrc := fmt.Sprintf("%d%s%04d", t, p.region, seq)
+		if !p.used[ssrc] {
+			p.used[ssrc] = true
+			return ssrc, nil
+		}
+	}
+	return "", errSSRCExhausted
+}
+
+// reserve 标记ssrc已使用，用于恢复或采用设备指定的ssrc
+func (p *ssrcPool) reserve(ssrc string) {
+	p.mu.Lock()
+	p.used[ssrc] = true
+	p.mu.Unlock()
+}
+
+// release 释放ssrc
+func (p *ssrcPool) release(ssrc string) {
+	if ssrc == "" {
+		return
+	}
+	p.mu.Lock()
+	delete(p.used, ssrc)
+	p.mu.Unlock()
+}
+
+func (p *ssrcPool) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.used)
+}
+
+// zlm接收到的ssrc为16进制。发起请求的ssrc为10进制
+func ssrc2stream(ssrc string) string {
+	num, _ := strconv.ParseUint(ssrc, 10, 32)
+	return fmt.Sprintf("%08X", num)
+}
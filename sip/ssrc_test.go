@@ -0,0 +1,42 @@
+package sipapi
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/panjjo/gosip/media"
+)
+
+// 服务重启时只恢复媒体节点上仍在收流的ssrc
+func TestLoadSSRCPool(t *testing.T) {
+	fake := testSetup(t)
+	testTables.mu.Lock()
+	for _, row := range []struct{ stream, ssrc, node string }{
+		{"alive", "0020000001", ""},
+		{"stale", "0020000002", ""},
+		{"gone", "0020000003", "zlm2"},
+	} {
+		testTables.rows["streams"] = append(testTables.rows["streams"], map[string]driver.Value{
+			"id":            int64(1),
+			"streamid":      row.stream,
+			"ssrc":          row.ssrc,
+			"mediaserverid": row.node,
+		})
+	}
+	testTables.mu.Unlock()
+	fake.RtpServers["alive"] = media.OpenRtpServerReq{StreamID: "alive", Port: 30000}
+	fake.Publish(media.MediaInfo{App: "rtp", Stream: "alive", Schema: "rtsp"})
+
+	loadSSRCPool("3402000000")
+	if n := _ssrcPool.count(); n != 1 || !_ssrcPool.used["0020000001"] {
+		t.Fatalf("reserved ssrc:%v, want only the alive stream", _ssrcPool.used)
+	}
+
+	// 媒体节点异常无法判断时保留
+	fake.SetError("GetRtpInfo", errors.New("timeout"))
+	loadSSRCPool("3402000000")
+	if n := _ssrcPool.count(); n != 2 {
+		t.Fatalf("reserved ssrc:%v, want streams on the default node kept", _ssrcPool.used)
+	}
+}
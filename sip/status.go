@@ -0,0 +1,88 @@
+package sipapi
+
+import (
+	"encoding/xml"
+
+	"github.com/panjjo/gosip/db"
+	"github.com/panjjo/gosip/m"
+	sip "github.com/panjjo/gosip/sip/s"
+	"github.com/panjjo/gosip/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// DeviceStatus 设备状态查询应答
+type DeviceStatus struct {
+	XMLName  xml.Name `xml:"Response" json:"-"`
+	CmdType  string   `xml:"CmdType" json:"-"`
+	SN       int      `xml:"SN" json:"-"`
+	DeviceID string   `xml:"DeviceID" json:"deviceid"`
+	// Result 查询结果 OK/ERROR
+	Result string `xml:"Result" json:"result"`
+	// Online 是否在线 ONLINE/OFFLINE
+	Online string `xml:"Online" json:"online"`
+	// Status 是否正常工作 OK/ERROR
+	Status string `xml:"Status" json:"status"`
+	// Reason 不正常工作原因
+	Reason string `xml:"Reason" json:"reason"`
+	// Encode 是否编码 ON/OFF
+	Encode string `xml:"Encode" json:"encode"`
+	// Record 是否录像 ON/OFF
+	Record string `xml:"Record" json:"record"`
+	// DeviceTime 设备时间和日期
+	DeviceTime string `xml:"DeviceTime" json:"devicetime"`
+	// Alarmstatus 报警设备状态列表
+	Alarmstatus []DeviceAlarmStatus `xml:"Alarmstatus>Item" json:"alarmstatus"`
+}
+
+// DeviceAlarmStatus 报警通道布防状态
+type DeviceAlarmStatus struct {
+	DeviceID string `xml:"DeviceID" json:"deviceid"`
+	// DutyStatus 报警设备状态 ONDUTY/OFFDUTY/ALARM
+	DutyStatus string `xml:"DutyStatus" json:"dutystatus"`
+}
+
+// SipDeviceStatus 查询设备状态
+func SipDeviceStatus(device Devices) (*DeviceStatus, error) {
+	sn := utils.RandInt(100000, 999999)
+	body, err := sipMessageQuery(device, nil, "DeviceStatus", device.DeviceID, sn, sip.GetDeviceStatusXML(device.DeviceID, sn))
+	if err != nil {
+		return nil, err
+	}
+	status := &DeviceStatus{}
+	if err := utils.XMLDecode(body, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// sipMessageDeviceStatus 解析设备返回的状态信息，校正通道状态
+func sipMessageDeviceStatus(u Devices, body []byte) error {
+	message := &DeviceStatus{}
+	if err := utils.XMLDecode(body, message); err != nil {
+		logrus.Errorln("sipMessageDeviceStatus Unmarshal xml err:", err, "body:", string(body))
+		return err
+	}
+	syncDeviceStatus(u, message)
+	sipMessageReply(message.CmdType, message.DeviceID, message.SN, body)
+	return nil
+}
+
+// 根据设备状态同步设备在线状态和报警通道布防状态，u 为应答请求中解析出的设备
+func syncDeviceStatus(u Devices, status *DeviceStatus) {
+	if status.Result != "" && status.Result != "OK" {
+		return
+	}
+	switch status.Online {
+	case "OFFLINE":
+		// 设备离线，设备下所有通道离线
+		db.UpdateAll(db.DBClient, new(Channels), db.M{"deviceid=?": status.DeviceID}, db.M{"status": m.DeviceStatusOFF})
+	case "ONLINE":
+		// 设备在线，刷新活跃设备
+		if u.DeviceID == status.DeviceID {
+			deviceOnline(u)
+		}
+	}
+	for _, item := range status.Alarmstatus {
+		db.UpdateAll(db.DBClient, new(Channels), db.M{"channelid=?": item.DeviceID}, db.M{"dutystatus": item.DutyStatus})
+	}
+}
@@ -1,6 +1,7 @@
 package sipapi
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"sync"
@@ -14,7 +15,7 @@ import (
 // Streams Streams
 type Streams struct {
 	db.DBModel
-	// 0  直播 1 历史
+	// 0  直播 1 历史 2 下载
 	T int `json:"t" gorm:"column:t"`
 	// 设备ID
 	DeviceID string `json:"deviceid" gorm:"column:deviceid"`
@@ -44,14 +45,67 @@ type Streams struct {
 	RTSP string `json:"rtsp" gorm:"column:rtsp"`
 	// flv 播放地址
 	WSFLV string `json:"wsflv" gorm:"column:wsflv"`
+	// http-flv 播放地址
+	FLV string `json:"flv" gorm:"column:flv"`
+	// http-fmp4 播放地址
+	FMP4 string `json:"fmp4" gorm:"column:fmp4"`
+	// ws-fmp4 播放地址
+	WSFMP4 string `json:"wsfmp4" gorm:"column:wsfmp4"`
+	// fmp4 切片的m3u8播放地址
+	HLSFMP4 string `json:"hlsfmp4" gorm:"column:hlsfmp4"`
+	// webrtc 播放地址，zlm webrtc 信令接口
+	WebRTC string `json:"webrtc" gorm:"column:webrtc"`
+	// 加密播放地址，媒体节点未配置对应的加密地址时为空
+	HTTPS        string `json:"https" gorm:"column:https"`
+	RTMPS        string `json:"rtmps" gorm:"column:rtmps"`
+	RTSPS        string `json:"rtsps" gorm:"column:rtsps"`
+	WSSFLV       string `json:"wssflv" gorm:"column:wssflv"`
+	HTTPSFLV     string `json:"httpsflv" gorm:"column:httpsflv"`
+	HTTPSFMP4    string `json:"httpsfmp4" gorm:"column:httpsfmp4"`
+	WSSFMP4      string `json:"wssfmp4" gorm:"column:wssfmp4"`
+	HTTPSHLSFMP4 string `json:"httpshlsfmp4" gorm:"column:httpshlsfmp4"`
+	WebRTCS      string `json:"webrtcs" gorm:"column:webrtcs"`
 	// zlm是否收到流
 	Stream bool `json:"stream" gorm:"column:stream"`
+	// 回放倍速，1为正常速度
+	Scale float64 `json:"scale" gorm:"column:scale"`
+	// 回放是否暂停
+	Paused bool `json:"paused" gorm:"column:paused"`
+	// 下载倍速
+	Speed int `json:"speed" gorm:"column:speed"`
+	// 下载进度 0-100
+	Progress float64 `json:"progress" gorm:"column:progress"`
+	// 下载录制文件id，对应Files.FID
+	FileID string `json:"fileid" gorm:"column:fileid"`
+	// 设备实际使用的ssrc，以设备应答sdp的y字段为准
+	SSRC string `json:"ssrc" gorm:"column:ssrc"`
+	// 协商后的传输方式 udp/tcp_passive/tcp_active
+	Transport string `json:"transport" gorm:"column:transport"`
+	// 设备应答的tcp连接方式 active passive
+	Setup string `json:"setup" gorm:"column:setup"`
+	// 设备发送媒体的地址 ip:port
+	MediaAddr string `json:"mediaaddr" gorm:"column:mediaaddr"`
+	// 设备应答的视频编码，PS封装时为 PS/实际编码
+	Codec string `json:"codec" gorm:"column:codec"`
+	// 设备应答sdp的f字段，媒体参数
+	MediaFormat string `json:"mediaformat" gorm:"column:mediaformat"`
+	// 收流的媒体服务器节点id
+	MediaServerID string `json:"mediaserverid" gorm:"column:mediaserverid"`
+	// 拉流通道在媒体服务器的拉流代理key
+	ProxyKey string `json:"proxykey" gorm:"column:proxykey"`
+	// 当前观看会话数量
+	Viewers int `json:"viewers" gorm:"-"`
+	// 播放接口创建的观看会话token，用于心跳和结束观看
+	Token string `json:"token,omitempty" gorm:"-"`
 
 	// ---
-	S, E time.Time     `json:"-" gorm:"-"`
-	ssrc string        // 国标ssrc 10进制字符串
-	Ext  int64         `json:"-" gorm:"-"` // 流等待过期时间
-	Resp *sip.Response `json:"-" gorm:"-"`
+	S, E      time.Time     `json:"-" gorm:"-"`
+	ssrc      string        // 国标ssrc 10进制字符串
+	rtspSeq   int           // 回放控制 MANSRTSP CSeq
+	rtpPort   int           // zlm 为此流开启的rtp端口
+	transport string        // 请求的传输方式，为空时使用通道设置
+	Ext       int64         `json:"-" gorm:"-"` // 流等待过期时间
+	Resp      *sip.Response `json:"-" gorm:"-"`
 }
 
 // 当前系统中存在的流列表
@@ -60,28 +114,10 @@ type streamsList struct {
 	Response *sync.Map
 	// key=channelid value={Play}  当前设备直播信息，防止重复直播
 	Succ *sync.Map
-	ssrc int
 }
 
 var StreamList streamsList
 
-func getSSRC(t int) string {
-	r := false
-	for {
-		StreamList.ssrc++
-		// ssrc最大为四位数，超过时从1开始重新计算
-		if StreamList.ssrc > 9000 && !r {
-			StreamList.ssrc = 0
-			r = true
-		}
-		key := fmt.Sprintf("%d%s%04d", t, _sysinfo.Region[3:8], StreamList.ssrc)
-		stream := Streams{StreamID: ssrc2stream(key), Stop: false}
-		if err := db.Get(db.DBClient, &stream); db.RecordNotFound(err) || stream.CreatedAt == 0 {
-			return key
-		}
-	}
-}
-
 // 定时检查未关闭的流
 // 检查规则：
 // 1. 数据库查询当前status=0在推流状态的所有流信息
@@ -99,7 +135,12 @@ func CheckStreams() {
 				if streamActive.ChannelID == stream.ChannelID {
 					// 此流在用
 					// 查询media流是否仍然存在。不存在的需要关闭。
-					rtpInfo := zlmGetMediaInfo(stream.StreamID)
+					rtpInfo, err := streamMedia(streamActive).GetRtpInfo(context.Background(), stream.StreamID)
+					if err != nil {
+						// 媒体服务器异常时无法判断，下次再检查
+						logrus.Warnln("checkStreamGetRtpInfo fail", stream.StreamID, err)
+						continue
+					}
 					if rtpInfo.Exist {
 						// 流仍然存在
 						continue
@@ -180,6 +221,9 @@ func CheckStreams() {
 				stream.Status = 1
 				stream.Stop = true
 			}
+			if stream.Stop {
+				_ssrcPool.release(stream.SSRC)
+			}
 			db.Save(db.DBClient, stream)
 
 		}
@@ -188,4 +232,5 @@ func CheckStreams() {
 		}
 		skip += 100
 	}
+	checkPullStreams()
 }
@@ -0,0 +1,242 @@
+package sipapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/panjjo/gosip/db"
+	"github.com/panjjo/gosip/m"
+	"github.com/panjjo/gosip/media"
+	"github.com/sirupsen/logrus"
+)
+
+var errStreamStatsNotFound = errors.New("流不存在或尚未采集统计")
+
+// StreamStat 流的一次统计采样
+type StreamStat struct {
+	Time int64 `json:"time"`
+	// 收流码率，单位 bytes/s
+	BytesSpeed int `json:"bytesspeed"`
+	// 观看人数
+	Readers int `json:"readers"`
+	// 视频帧率
+	FPS int `json:"fps"`
+	// 视频累计帧数
+	Frames int64 `json:"frames"`
+	// 距上次采样的视频帧数，媒体服务器未返回帧数时为-1
+	FrameDelta int64 `json:"framedelta"`
+	// 视频rtp丢包率 0-1，-1 表示无法统计
+	Loss float64 `json:"loss"`
+	// 媒体服务器rtp端口是否在收流，拉流通道为false
+	RtpExist bool `json:"rtpexist"`
+	// 设备发送媒体的地址 ip:port
+	Peer string `json:"peer"`
+}
+
+// StreamStats 流的统计窗口
+type StreamStats struct {
+	StreamID      string `json:"streamid"`
+	ChannelID     string `json:"channelid"`
+	MediaServerID string `json:"mediaserverid"`
+	// 是否卡死，持续没有数据超过 stream.stall_timeout
+	Stalled bool `json:"stalled"`
+	// 最后一次收到数据的时间
+	LastData int64 `json:"lastdata"`
+	// 卡死后连续重新点播的次数，恢复收流后清零
+	Retry int `json:"retry"`
+	// 采样窗口，按时间正序，最多 stream.stats_window 条
+	Samples []StreamStat `json:"samples"`
+}
+
+type streamStatsList struct {
+	mu sync.Mutex
+	// key=streamid，直播流id固定，重新点播后统计继续累计
+	stats map[string]*StreamStats
+}
+
+var _streamStats = &streamStatsList{stats: map[string]*StreamStats{}}
+
+// GetStreamStats 流的统计窗口
+func GetStreamStats(streamID string) (StreamStats, error) {
+	_streamStats.mu.Lock()
+	defer _streamStats.mu.Unlock()
+	stats, ok := _streamStats.stats[streamID]
+	if !ok {
+		return StreamStats{}, errStreamStatsNotFound
+	}
+	res := *stats
+	res.Samples = append([]StreamStat{}, stats.Samples...)
+	return res, nil
+}
+
+// CollectStreamStats 定时采集各节点上流的统计，检测卡死的直播流并重新点播
+func CollectStreamStats() {
+	now := time.Now().Unix()
+	nodeStreams := map[string][]*Streams{}
+	alive := map[string]bool{}
+	StreamList.Response.Range(func(key, value any) bool {
+		play := value.(*Streams)
+		alive[play.StreamID] = true
+		// 暂停的回放没有数据，不采集
+		if play.Stream && !play.Paused {
+			nodeStreams[play.MediaServerID] = append(nodeStreams[play.MediaServerID], play)
+		}
+		return true
+	})
+
+	stalls := []*Streams{}
+	for id, streams := range nodeStreams {
+		node := getMediaNode(id)
+		if node == nil || !node.Online {
+			// 节点异常由节点故障转移处理
+			continue
+		}
+		medias, err := node.driver.GetMediaList(context.Background(), media.MediaListReq{App: "rtp"})
+		if err != nil {
+			logrus.Warnln("collect stream stats fail,", node.ID, err)
+			continue
+		}
+		infos := map[string]media.MediaInfo{}
+		for _, info := range medias {
+			// 各协议的收流码率相同，totalReaderCount 为各协议观看人数合计
+			if _, ok := infos[info.Stream]; !ok || info.Schema == "rtmp" {
+				infos[info.Stream] = info
+			}
+		}
+		for _, play := range streams {
+			sample := StreamStat{Time: now, FrameDelta: -1, Loss: -1}
+			if info, ok := infos[play.StreamID]; ok {
+				sample.BytesSpeed = info.BytesSpeed
+				sample.Readers = info.ReaderCount
+				for _, track := range info.Tracks {
+					if track.Type == 0 {
+						sample.FPS = track.FPS
+						sample.Frames = track.Frames
+						sample.Loss = track.Loss
+						break
+					}
+				}
+			}
+			if play.StreamType == m.StreamTypePush {
+				if rtp, err := node.driver.GetRtpInfo(context.Background(), play.StreamID); err == nil && rtp.Exist {
+					sample.RtpExist = true
+					sample.Peer = fmt.Sprintf("%s:%d", rtp.PeerIP, rtp.PeerPort)
+				}
+			}
+			if streamStatsAdd(play, sample) {
+				stalls = append(stalls, play)
+			}
+		}
+	}
+
+	_streamStats.mu.Lock()
+	for streamID := range _streamStats.stats {
+		if !alive[streamID] && !streamStalling(streamID) {
+			delete(_streamStats.stats, streamID)
+		}
+	}
+	_streamStats.mu.Unlock()
+
+	for _, play := range stalls {
+		streamStalled(play)
+	}
+}
+
+// 记录采样，返回流是否刚进入卡死状态
+func streamStatsAdd(play *Streams, sample StreamStat) bool {
+	_streamStats.mu.Lock()
+	defer _streamStats.mu.Unlock()
+	stats, ok := _streamStats.stats[play.StreamID]
+	if !ok {
+		stats = &StreamStats{StreamID: play.StreamID, ChannelID: play.ChannelID, LastData: sample.Time}
+		_streamStats.stats[play.StreamID] = stats
+	}
+	stats.MediaServerID = play.MediaServerID
+	if n := len(stats.Samples); n > 0 && sample.Frames > 0 && sample.Frames >= stats.Samples[n-1].Frames {
+		sample.FrameDelta = sample.Frames - stats.Samples[n-1].Frames
+	}
+	stats.Samples = append(stats.Samples, sample)
+	if len(stats.Samples) > config.Stream.StatsWindow {
+		stats.Samples = stats.Samples[len(stats.Samples)-config.Stream.StatsWindow:]
+	}
+	if sample.BytesSpeed > 0 || sample.FrameDelta > 0 {
+		stats.LastData = sample.Time
+		stats.Stalled = false
+		stats.Retry = 0
+		return false
+	}
+	if stats.Stalled || sample.Time-stats.LastData < int64(config.Stream.StallTimeout) {
+		return false
+	}
+	stats.Stalled = true
+	return true
+}
+
+// key=streamid 正在重新点播的流，重新点播期间保留统计
+var _streamStalling sync.Map
+
+func streamStalling(streamID string) bool {
+	_, ok := _streamStalling.Load(streamID)
+	return ok
+}
+
+// 直播流卡死，关闭后重新向设备点播或重建拉流代理，超过重试次数或非直播流时关闭流
+func streamStalled(play *Streams) {
+	_streamStats.mu.Lock()
+	stats, ok := _streamStats.stats[play.StreamID]
+	if !ok {
+		// 采样后流已关闭，统计已清理
+		_streamStats.mu.Unlock()
+		return
+	}
+	stats.Retry++
+	retry := stats.Retry
+	_streamStats.mu.Unlock()
+
+	reinvite := play.T == 0 && retry <= config.Stream.StallRetry
+	go notify(notifyStreamStalled(play, retry, reinvite))
+	if !reinvite {
+		logrus.Warnln("closeStream stream stalled", play.StreamID, "retry:", retry)
+		SipStopPlay(play.StreamID)
+		return
+	}
+	logrus.Warnln("stream stalled, reinvite", play.StreamID, play.ChannelID, "retry:", retry)
+	_streamStalling.Store(play.StreamID, true)
+	go streamReinvite(play)
+}
+
+// 关闭卡死流的对话和收流端口后重新点播，保留观看会话
+func streamReinvite(play *Streams) {
+	defer _streamStalling.Delete(play.StreamID)
+	mediaServer := streamMedia(play)
+	if play.StreamType == m.StreamTypePull {
+		if err := sipPullStop(play); err != nil {
+			logrus.Warnln("streamReinvite del proxy fail,", play.StreamID, err)
+		}
+	}
+	sipStreamBroken(play, "流卡死，重新点播")
+	if err := mediaServer.CloseStreams(context.Background(), "rtp", play.StreamID); err != nil {
+		logrus.Warnln("streamReinvite close stream fail,", play.StreamID, err)
+	}
+	if err := mediaServer.CloseRtpServer(context.Background(), play.StreamID); err != nil && !errors.Is(err, media.ErrNotFound) {
+		logrus.Warnln("streamReinvite close rtp server fail,", play.StreamID, err)
+	}
+	data := &Streams{ChannelID: play.ChannelID, Ttag: db.M{}, Ftag: db.M{}}
+	data.SetTransport(play.transport)
+	if _, err := SipPlay(data); err != nil {
+		logrus.Warnln("streamReinvite fail,", play.StreamID, play.ChannelID, err)
+		viewerStreamClosed(play.StreamID)
+		return
+	}
+	// 新的流收到数据前重新计时
+	_streamStats.mu.Lock()
+	if stats, ok := _streamStats.stats[play.StreamID]; ok {
+		stats.Stalled = false
+		stats.LastData = time.Now().Unix()
+	}
+	_streamStats.mu.Unlock()
+	logrus.Infoln("streamReinvite succ,", play.StreamID, play.ChannelID)
+}
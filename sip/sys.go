@@ -2,10 +2,7 @@ package sipapi
 
 import (
 	"fmt"
-	"net"
 	"net/http"
-	"net/url"
-	"strconv"
 	"sync"
 
 	"github.com/panjjo/gosip/db"
@@ -22,8 +19,18 @@ func Start() {
 	db.DBClient.AutoMigrate(new(Streams))
 	db.DBClient.AutoMigrate(new(m.SysInfo))
 	db.DBClient.AutoMigrate(new(Files))
+	db.DBClient.AutoMigrate(new(Presets))
+	db.DBClient.AutoMigrate(new(ControlLogs))
+	db.DBClient.AutoMigrate(new(Snapshots))
+	db.DBClient.AutoMigrate(new(Firmwares))
+	db.DBClient.AutoMigrate(new(UpgradeTasks))
+	db.DBClient.AutoMigrate(new(Upgrades))
+	db.DBClient.AutoMigrate(new(PlayRevokes))
 
 	LoadSYSInfo()
+	loadSSRCPool(_sysinfo.Region)
+	loadPlayRevokes()
+	resetUpgrades()
 
 	srv = sip.NewServer()
 	srv.RegistHandler(sip.OPTIONS, handlerOptions)
@@ -31,6 +38,8 @@ func Start() {
 	srv.RegistHandler(sip.REGISTER, handlerRegister)
 	srv.RegistHandler(sip.NOTIFY, handlerNotify)
 	srv.RegistHandler(sip.BYE, handlerBye)
+	srv.RegistHandler(sip.INVITE, handlerInvite)
+	srv.RegistHandler(sip.ACK, handlerAck)
 	go srv.ListenTCPServer(config.TCP)
 	go srv.ListenUDPServer(config.UDP)
 }
@@ -62,9 +71,9 @@ func LoadSYSInfo() {
 	config = m.MConfig
 	_activeDevices = ActiveDevices{sync.Map{}}
 
-	StreamList = streamsList{&sync.Map{}, &sync.Map{}, 0}
-	ssrcLock = &sync.Mutex{}
+	StreamList = streamsList{&sync.Map{}, &sync.Map{}}
 	_recordList = &sync.Map{}
+	_messageWaits = &sync.Map{}
 	RecordList = apiRecordList{items: map[string]*apiRecordItem{}, l: sync.RWMutex{}}
 
 	// init sysinfo
@@ -95,16 +104,7 @@ func LoadSYSInfo() {
 	}
 
 	// init media
-	url, err := url.Parse(config.Media.RTP)
-	if err != nil {
-		logrus.Fatalf("media rtp url error,url:%s,err:%v", config.Media.RTP, err)
-	}
-	ipaddr, err := net.ResolveIPAddr("ip", url.Hostname())
-	if err != nil {
-		logrus.Fatalf("media rtp url error,url:%s,err:%v", config.Media.RTP, err)
-	}
-	_sysinfo.MediaServerRtpIP = ipaddr.IP
-	_sysinfo.MediaServerRtpPort, _ = strconv.Atoi(url.Port())
+	loadMediaNodes()
 }
 
 // 新增函数：基于 deviceId 和 channelId 生成 StreamID
@@ -112,15 +112,6 @@ func generateStreamID(deviceID, channelID string) string {
 	return fmt.Sprintf("%s_%s", deviceID, channelID)
 }
 
-// zlm接收到的ssrc为16进制。发起请求的ssrc为10进制
-func ssrc2stream(ssrc string) string {
-	if ssrc[0:1] == "0" {
-		ssrc = ssrc[1:]
-	}
-	num, _ := strconv.Atoi(ssrc)
-	return fmt.Sprintf("%08X", num)
-}
-
 func sipResponse(tx *sip.Transaction) (*sip.Response, error) {
 	response := tx.GetResponse()
 	if response == nil {
@@ -0,0 +1,145 @@
+package sipapi
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/panjjo/gorm"
+	"github.com/panjjo/gosip/db"
+	"github.com/panjjo/gosip/m"
+	"github.com/panjjo/gosip/media"
+)
+
+// 测试用数据库驱动，写入直接成功，查询按表名返回 testTables 中的数据
+type testDriver struct{}
+
+func (testDriver) Open(string) (driver.Conn, error) { return testConn{}, nil }
+
+type testConn struct{}
+
+func (testConn) Prepare(query string) (driver.Stmt, error) { return testStmt{query}, nil }
+func (testConn) Close() error                              { return nil }
+func (testConn) Begin() (driver.Tx, error)                 { return testTx{}, nil }
+
+type testTx struct{}
+
+func (testTx) Commit() error   { return nil }
+func (testTx) Rollback() error { return nil }
+
+type testStmt struct{ query string }
+
+func (testStmt) Close() error  { return nil }
+func (testStmt) NumInput() int { return -1 }
+func (testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return testResult{}, nil
+}
+func (s testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	testTables.mu.Lock()
+	defer testTables.mu.Unlock()
+	for table, rows := range testTables.rows {
+		if strings.Contains(s.query, "FROM `"+table+"`") || strings.Contains(s.query, `FROM "`+table+`"`) {
+			return &testRows{rows: rows}, nil
+		}
+	}
+	return &testRows{}, nil
+}
+
+type testResult struct{}
+
+func (testResult) LastInsertId() (int64, error) { return 1, nil }
+func (testResult) RowsAffected() (int64, error) { return 1, nil }
+
+type testRows struct {
+	rows []map[string]driver.Value
+	i    int
+}
+
+func (r *testRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return []string{"id"}
+	}
+	cols := []string{}
+	for k := range r.rows[0] {
+		cols = append(cols, k)
+	}
+	sort.Strings(cols)
+	return cols
+}
+func (r *testRows) Close() error { return nil }
+func (r *testRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.rows) {
+		return io.EOF
+	}
+	row := r.rows[r.i]
+	for i, col := range r.Columns() {
+		dest[i] = row[col]
+	}
+	r.i++
+	return nil
+}
+
+var testTables = struct {
+	mu   sync.Mutex
+	rows map[string][]map[string]driver.Value
+}{rows: map[string][]map[string]driver.Value{}}
+
+func init() {
+	sql.Register("gosiptest", testDriver{})
+}
+
+// 初始化测试环境，媒体服务器使用 media.Fake，返回默认节点的fake
+func testSetup(t *testing.T) *media.Fake {
+	t.Helper()
+	sqlDB, err := sql.Open("gosiptest", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.DBClient, err = gorm.Open("mysql", sqlDB); err != nil {
+		t.Fatal(err)
+	}
+	testTables.mu.Lock()
+	testTables.rows = map[string][]map[string]driver.Value{}
+	testTables.mu.Unlock()
+
+	config = &m.Config{}
+	config.Media.ID = m.DefaultMediaNodeID
+	config.Stream.HLS = true
+	config.Stream.RTMP = true
+	config.Stream.Transport = m.TransportTCPPassive
+
+	fake := media.NewFake(30000)
+	_mediaNodes = &mediaNodeList{nodes: map[string]*MediaNode{
+		config.Media.ID: {ID: config.Media.ID, HTTP: "http://127.0.0.1:8080", Online: true, rtpIP: net.IPv4(127, 0, 0, 1)},
+	}}
+	SetMediaServer(fake)
+	StreamList = streamsList{Response: &sync.Map{}, Succ: &sync.Map{}}
+	_activeDevices = ActiveDevices{sync.Map{}}
+	loadSSRCPool("3402000000")
+	_playRevokes.Range(func(key, _ any) bool {
+		_playRevokes.Delete(key)
+		return true
+	})
+	playPush = sipPlayPush
+	t.Cleanup(func() { playPush = sipPlayPush })
+	return fake
+}
+
+// 添加推流通道及在线设备
+func testChannel(t *testing.T, channelID, deviceID string) {
+	t.Helper()
+	testTables.mu.Lock()
+	testTables.rows["channels"] = append(testTables.rows["channels"], map[string]driver.Value{
+		"id":         int64(1),
+		"channelid":  channelID,
+		"deviceid":   deviceID,
+		"streamtype": m.StreamTypePush,
+	})
+	testTables.mu.Unlock()
+	_activeDevices.Store(deviceID, Devices{DeviceID: deviceID})
+}
@@ -1,240 +1,484 @@
 package sipapi
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	sdp "github.com/panjjo/gosdp"
-	"github.com/panjjo/gosip/db"
-	"github.com/panjjo/gosip/m"
+	"github.com/panjjo/gosip/media"
 	sip "github.com/panjjo/gosip/sip/s"
 	"github.com/panjjo/gosip/utils"
 	"github.com/sirupsen/logrus"
 )
 
-// sip 请求对讲
-func SipTalk(data *Streams) (*Streams, error) {
+// TalkApp 对讲音频源在媒体服务器中的app，浏览器麦克风音频推到该app下
+const TalkApp = "talk"
 
-	channel := Channels{ChannelID: data.ChannelID}
-	if err := db.Get(db.DBClient, &channel); err != nil {
-		if db.RecordNotFound(err) {
-			return nil, errors.New("通道不存在")
-		}
+// 对讲会话空闲检查间隔
+const talkCheckInterval = 5 * time.Second
+
+// 对讲模式
+const (
+	// TalkModeBroadcast 语音广播，设备收到广播通知后发起INVITE，只向设备发送音频
+	TalkModeBroadcast = "broadcast"
+	// TalkModeTalk 语音对讲，服务端向设备发起INVITE，同时接收设备音频
+	TalkModeTalk = "talk"
+)
+
+// 对讲状态
+const (
+	// TalkStatusWaiting 等待浏览器推送音频
+	TalkStatusWaiting = "waiting"
+	// TalkStatusConnecting 正在与设备建立会话
+	TalkStatusConnecting = "connecting"
+	// TalkStatusTalking 对讲中
+	TalkStatusTalking = "talking"
+	// TalkStatusClosed 对讲已结束
+	TalkStatusClosed = "closed"
+)
+
+// TalkSession 语音对讲会话
+// 浏览器通过 PushURL 推送麦克风音频，音频到达媒体服务器后再与设备建立会话并转发
+type TalkSession struct {
+	// ID 会话id，同时为音频源在 TalkApp 下的stream
+	ID string `json:"id"`
+	// ChannelID 通道编码
+	ChannelID string `json:"channelid"`
+	// DeviceID 设备编号
+	DeviceID string `json:"deviceid"`
+	// Mode 对讲模式 broadcast/talk
+	Mode string `json:"mode"`
+	// Codec 音频编码，浏览器推流后以实际推送的音频编码为准
+	Codec string `json:"codec"`
+	// PushURL 浏览器麦克风音频 WebRTC 推流地址
+	PushURL string `json:"pushurl"`
+	// PushURLS 加密的推流地址，媒体节点未配置 https 时为空
+	PushURLS string `json:"pushurls"`
+	// PlayURL 设备音频 WebRTC 播放地址，仅对讲模式有效
+	PlayURL string `json:"playurl"`
+	// PlayURLS 加密的播放地址，媒体节点未配置 https 时为空
+	PlayURLS string `json:"playurls"`
+	// Status 对讲状态 waiting/connecting/talking/closed
+	Status string `json:"status"`
+	// Msg 结束原因
+	Msg string `json:"msg"`
+	// Start 开始时间
+	Start int64 `json:"start"`
+
+	mu         sync.Mutex
+	lastActive time.Time
+	closed     chan struct{}
+	broadcast  *Broadcast
+	// 对讲模式下服务端发起的INVITE信息
+	ssrc       string
+	recvStream string
+	callID     string
+	resp       *sip.Response
+}
+
+// 对讲会话 key=id value=*TalkSession
+var _talkSessions sync.Map
+
+// SipStartTalk 创建对讲会话，返回浏览器推流地址，浏览器推流后自动与设备建立会话
+func SipStartTalk(channelID, mode, codec string) (*TalkSession, error) {
+	if mode == "" {
+		mode = TalkModeTalk
+	}
+	if mode != TalkModeTalk && mode != TalkModeBroadcast {
+		return nil, errors.New("对讲模式错误")
+	}
+	if codec == "" {
+		codec = config.Talk.Codec
+	}
+	if !CheckAudioCodec(codec) {
+		return nil, errors.New("音频编码错误")
+	}
+	channel, _, err := channelDevice(channelID)
+	if err != nil {
 		return nil, err
 	}
-
-	// 若通道的播放类型为空，默认按 push 处理
-	if channel.StreamType == "" {
-		channel.StreamType = m.StreamTypePush
+	exists := false
+	_talkSessions.Range(func(_, v any) bool {
+		exists = v.(*TalkSession).ChannelID == channel.ChannelID
+		return !exists
+	})
+	if exists {
+		return nil, errors.New("通道正在对讲")
+	}
+	s := &TalkSession{
+		ID:         utils.RandString(16),
+		ChannelID:  channel.ChannelID,
+		DeviceID:   channel.DeviceID,
+		Mode:       mode,
+		Codec:      codec,
+		Status:     TalkStatusWaiting,
+		Start:      time.Now().Unix(),
+		lastActive: time.Now(),
+		closed:     make(chan struct{}),
 	}
+	// 对讲音频经过默认节点，地址使用默认节点的地址前缀
+	s.PushURL, s.PushURLS = getMediaNode(config.Media.ID).webrtcURLs(TalkApp, s.ID, "push")
+	_talkSessions.Store(s.ID, s)
+	go s.watch()
+	return s, nil
+}
 
-	data.DeviceID = channel.DeviceID
-	data.StreamType = channel.StreamType
-	// 使用通道的播放模式进行处理
-	switch channel.StreamType {
-	default:
-		user, ok := _activeDevices.Get(channel.DeviceID)
-		if !ok {
-			return nil, errors.New("设备已离线")
-		}
-		// GB28181推流
-		ssrcLock.Lock()
-		data.ssrc = getSSRC(data.T)
-		data.StreamID = ssrc2stream(data.ssrc)
-
-		// 在 ZLM 中开启 RTP 服务器，指定自定义 streamId
-		rtpReq := zlmStartSendRtpPassivReq{
-			Vhost:  "__defaultVhost__", // 默认虚拟主机
-			App:    "rtp",              // App 名称
-			Stream: "audio",            // 流 ID
-			Ssrc:   "0",                // ssrc
-		}
+// SipStopTalk 结束对讲会话
+func SipStopTalk(id string) error {
+	v, ok := _talkSessions.Load(id)
+	if !ok {
+		return errors.New("对讲会话不存在")
+	}
+	v.(*TalkSession).close(true, "")
+	return nil
+}
 
-		rtpResp, err := zlmStartSendRtpPassive(rtpReq)
-		logrus.Infoln("开启 ZLM RTP 服务器", rtpReq, rtpResp)
-		if err != nil {
-			ssrcLock.Unlock()
-			return nil, fmt.Errorf("开启 ZLM RTP 服务器失败: %v", err)
-		}
+// GetTalkSessions 获取当前所有对讲会话
+func GetTalkSessions() []*TalkSession {
+	list := []*TalkSession{}
+	_talkSessions.Range(func(_, v any) bool {
+		list = append(list, v.(*TalkSession))
+		return true
+	})
+	return list
+}
 
-		// 更新媒体服务器端口（如果 ZLM 返回了新端口）
-		if rtpResp.Port > 0 {
-			_sysinfo.MediaServerRtpPort = rtpResp.Port
-		}
+// IsTalkStream 判断是否为对讲使用的流，包括浏览器推送的音频源和对讲模式下接收的设备音频
+func IsTalkStream(app, stream string) bool {
+	if app == TalkApp {
+		return true
+	}
+	found := false
+	_talkSessions.Range(func(_, v any) bool {
+		s := v.(*TalkSession)
+		s.mu.Lock()
+		found = s.recvStream == stream
+		s.mu.Unlock()
+		return !found
+	})
+	return found
+}
 
-		// 成功后保存
-		db.Create(db.DBClient, data)
-		ssrcLock.Unlock()
+// TalkSourceRegisted 浏览器音频源注册到媒体服务器后与设备建立会话
+func TalkSourceRegisted(stream string) {
+	v, ok := _talkSessions.Load(stream)
+	if !ok {
+		logrus.Infoln("TalkSourceRegisted session not found,", stream)
+		return
+	}
+	s := v.(*TalkSession)
+	s.mu.Lock()
+	if s.Status != TalkStatusWaiting {
+		s.mu.Unlock()
+		return
+	}
+	s.Status = TalkStatusConnecting
+	s.lastActive = time.Now()
+	s.mu.Unlock()
+	go s.connect()
+}
 
-		data, err = sipTalkPush(data, channel, user)
-		if err != nil {
-			return nil, fmt.Errorf("获取视频失败:%v", err)
+// 与设备建立会话，音频编码以浏览器实际推送的编码为准，媒体服务器只转发不转码
+func (s *TalkSession) connect() {
+	codec := ""
+	medias, err := _media.GetMediaList(context.Background(), media.MediaListReq{App: TalkApp, Stream: s.ID})
+	if err != nil {
+		logrus.Warnln("talk get media fail,", s.ID, err)
+	}
+	for _, info := range medias {
+		for _, track := range info.Tracks {
+			if track.Type == 1 {
+				codec = zlmAudioCodecs[track.CodecID]
+			}
 		}
 	}
+	if codec == "" {
+		s.close(false, "浏览器推送的音频编码不支持，需推送G711A/G711U/AAC音频")
+		return
+	}
+	s.mu.Lock()
+	s.Codec = codec
+	s.mu.Unlock()
 
-	data.HTTP = fmt.Sprintf("%s/rtp/%s/hls.m3u8", config.Media.HTTP, data.StreamID)
-	data.RTMP = fmt.Sprintf("%s/rtp/%s", config.Media.RTMP, data.StreamID)
-	data.RTSP = fmt.Sprintf("%s/rtp/%s", config.Media.RTSP, data.StreamID)
-	data.WSFLV = fmt.Sprintf("%s/rtp/%s.live.flv", config.Media.WS, data.StreamID)
-
-	data.Ext = time.Now().Unix() + 2*60 // 2分钟等待时间
-	StreamList.Response.Store(data.StreamID, data)
-	if data.T == 0 {
-		StreamList.Succ.Store(data.ChannelID, data)
+	if s.Mode == TalkModeBroadcast {
+		b := sipBroadcast(s.ChannelID, TalkApp, s.ID, []string{codec})
+		if b.Status == BroadcastStatusFailed {
+			err = errors.New(b.Msg)
+		}
+		s.mu.Lock()
+		s.broadcast = b
+		s.mu.Unlock()
+	} else {
+		err = s.invite(codec)
+	}
+	if err != nil {
+		logrus.Warnln("talk connect fail,", s.ID, s.ChannelID, err)
+		s.close(true, err.Error())
+		return
+	}
+	s.mu.Lock()
+	closed := s.Status == TalkStatusClosed
+	if s.Status == TalkStatusConnecting {
+		s.Status = TalkStatusTalking
+	}
+	s.mu.Unlock()
+	if closed {
+		// 建立会话期间对讲已结束
+		s.release(true)
 	}
-	db.Save(db.DBClient, data)
-	return data, nil
 }
 
-func sipTalkPush(data *Streams, channel Channels, device Devices) (*Streams, error) {
-	var (
-		s sdp.Session
-		b []byte
-	)
-	name := "Talk"
-	protocal := "TCP/RTP/AVP"
+// 对讲模式向设备发起INVITE，媒体服务器被动等待设备连接，同一连接上收发音频
+func (s *TalkSession) invite(codec string) error {
+	channel, device, err := channelDevice(s.ChannelID)
+	if err != nil {
+		return err
+	}
+	ssrc, err := _ssrcPool.alloc(false)
+	if err != nil {
+		return err
+	}
+	recvStream := s.ID + "_recv"
+	format := audioCodecs[codec]
+
+	port, err := _media.StartSendRtpPassive(context.Background(), media.SendRtpReq{
+		App:          TalkApp,
+		Stream:       s.ID,
+		SSRC:         ssrc,
+		PT:           format.PT,
+		OnlyAudio:    true,
+		RecvStreamID: recvStream,
+	})
+	if err != nil {
+		_ssrcPool.release(ssrc)
+		return err
+	}
+	s.mu.Lock()
+	s.ssrc = ssrc
+	s.recvStream = recvStream
+	s.PlayURL, s.PlayURLS = getMediaNode(config.Media.ID).webrtcURLs("rtp", recvStream, "play")
+	if config.PlayAuth.Enable {
+		// 设备音频同样经过 on_play 鉴权，以会话id作为签名用户
+		params := playAuthParams("rtp", recvStream, s.ID, "")
+		s.PlayURL, s.PlayURLS = appendURLParams(s.PlayURL, params), appendURLParams(s.PlayURLS, params)
+	}
+	s.mu.Unlock()
 
-	// 视频媒体描述
 	audio := sdp.Media{
 		Description: sdp.MediaDescription{
-			Type:    "audio",
-			Port:    _sysinfo.MediaServerRtpPort,
-			Formats: []string{"8"},
-			// Formats:  []string{"8 104"},
-			Protocol: protocal,
+			Type:     "audio",
+			Port:     port,
+			Formats:  []string{format.PT},
+			Protocol: "TCP/RTP/AVP",
 		},
 	}
-	audio.AddAttribute("sendonly")
-	audio.AddAttribute("rtpmap", "8", "PCMA/8000")
-	// audio.AddAttribute("rtpmap", "104", "mpeg4-generic/32000")
-
-	// defining message
+	audio.AddAttribute("sendrecv")
+	audio.AddAttribute("rtpmap", format.PT, format.RtpMap)
+	audio.AddAttribute("setup", "passive")
+	audio.AddAttribute("connection", "new")
 	msg := &sdp.Message{
 		Origin: sdp.Origin{
-			Username: _serverDevices.DeviceID,            // 媒体服务器id
-			Address:  _sysinfo.MediaServerRtpIP.String(), // TODO: 此处可以扩展成内外网收流地址
+			Username: _serverDevices.DeviceID,
+			Address:  _sysinfo.MediaServerRtpIP.String(),
 		},
-		Name: name,
+		Name: "Talk",
 		Connection: sdp.ConnectionData{
-			IP:  _sysinfo.MediaServerRtpIP, // TODO: 此处可以扩展成内外网收流地址
+			IP:  _sysinfo.MediaServerRtpIP,
 			TTL: 0,
 		},
-		Timing: []sdp.Timing{
-			{
-				Start: data.S,
-				End:   data.E,
-			},
-		},
-		Medias: []sdp.Media{audio}, // 同时包含视频和音频
-		SSRC:   data.ssrc,
-	}
-
-	// appending message to session
-	s = msg.Append(s)
-	// appending session to byte buffer
-	b = s.AppendTo(b)
-	uri, _ := sip.ParseURI(channel.URIStr)
-	channel.addr = &sip.Address{URI: uri}
-	_serverDevices.addr.Params.Add("tag", sip.String{Str: utils.RandString(20)})
-	hb := sip.NewHeaderBuilder().SetTo(channel.addr).SetFrom(_serverDevices.addr).AddVia(&sip.ViaHop{
+		Timing: []sdp.Timing{{}},
+		Medias: []sdp.Media{audio},
+		SSRC:   ssrc,
+	}
+	var session sdp.Session
+	session = msg.Append(session)
+	body := session.AppendTo(nil)
+
+	to := channelAddress(channel)
+	hb := sip.NewHeaderBuilder().SetTo(to).SetFrom(_serverDevices.addr).AddVia(&sip.ViaHop{
 		Params: sip.NewParams().Add("branch", sip.String{Str: sip.GenerateBranch()}),
 	}).SetContentType(&sip.ContentTypeSDP).SetMethod(sip.INVITE).SetContact(_serverDevices.addr)
-	req := sip.NewRequest("", sip.INVITE, channel.addr.URI, sip.DefaultSipVersion, hb.Build(), b)
+	req := sip.NewRequest("", sip.INVITE, to.URI, sip.DefaultSipVersion, hb.Build(), body)
 	req.SetDestination(device.source)
-	req.AppendHeader(&sip.GenericHeader{HeaderName: "Subject", Contents: fmt.Sprintf("%s:%s,%s:%s", channel.ChannelID, data.StreamID, _serverDevices.DeviceID, data.StreamID)})
-	req.SetRecipient(channel.addr.URI)
+	req.AppendHeader(&sip.GenericHeader{HeaderName: "Subject", Contents: fmt.Sprintf("%s:%s,%s:%s", channel.ChannelID, ssrc, _serverDevices.DeviceID, ssrc)})
+	req.SetRecipient(to.URI)
 	// 根据设备的传输方式发送请求
 	var tx *sip.Transaction
-	var err error
 	if strings.ToLower(device.TransPort) == "tcp" {
 		tx, err = srv.RequestWithProtocol(req, "tcp")
 	} else {
 		tx, err = srv.Request(req) // 默认UDP
 	}
 	if err != nil {
-		logrus.Warningln("sipTalkPush fail.id:", device.DeviceID, channel.ChannelID, "err:", err)
-		return data, err
+		return err
 	}
-	// response
 	response, err := sipResponse(tx)
 	if err != nil {
-		logrus.Warningln("sipTalkPush response fail.id:", device.DeviceID, channel.ChannelID, "err:", err)
-		return data, err
+		return err
 	}
-	data.Resp = response
-	// ACK
 	tx.Request(sip.NewRequestFromResponse(sip.ACK, response))
-
-	callid, _ := response.CallID()
-	data.CallID = string(*callid)
-
-	cseq, _ := response.CSeq()
-	if cseq != nil {
-		data.CseqNo = cseq.SeqNo
+	s.mu.Lock()
+	s.resp = response
+	if callID, ok := response.CallID(); ok {
+		s.callID = string(*callID)
 	}
+	s.mu.Unlock()
 
-	from, _ := response.From()
-	to, _ := response.To()
-	for k, v := range to.Params.Items() {
-		data.Ttag[k] = v.String()
+	// 设备应答的音频编码必须与浏览器推送的一致
+	answer, err := sdp.Decode(response.Body())
+	if err != nil {
+		return fmt.Errorf("设备sdp解析失败:%v", err)
 	}
-	for k, v := range from.Params.Items() {
-		data.Ftag[k] = v.String()
+	for i := range answer.Medias {
+		if answer.Medias[i].Description.Type == "audio" {
+			if pt, _, _ := sdpAudioFormat(&answer.Medias[i], []string{codec}); pt != "" {
+				return nil
+			}
+		}
 	}
-	data.Status = 0
-
-	return data, err
+	return fmt.Errorf("设备不支持音频编码%s", codec)
 }
 
-// sip 停止对讲
-func SipStopTalk(ssrc string) {
-	zlmCloseStream(ssrc)
-	// 关闭 ZLM RTP 服务器
-	if err := zlmCloseRtpServer(ssrc); err != nil {
-		logrus.Errorln("关闭 ZLM RTP 服务器失败:", err)
+// 定时检查音频源，超过空闲时间没有音频数据时关闭会话
+func (s *TalkSession) watch() {
+	idle := time.Duration(config.Talk.IdleTimeout) * time.Second
+	tick := time.NewTicker(talkCheckInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-s.closed:
+			return
+		case <-tick.C:
+		}
+		medias, _ := _media.GetMediaList(context.Background(), media.MediaListReq{App: TalkApp, Stream: s.ID})
+		for _, info := range medias {
+			if info.BytesSpeed > 0 {
+				s.mu.Lock()
+				s.lastActive = time.Now()
+				s.mu.Unlock()
+			}
+		}
+		s.mu.Lock()
+		lastActive := s.lastActive
+		b := s.broadcast
+		s.mu.Unlock()
+		if b != nil {
+			b.mu.Lock()
+			stopped := b.Status == BroadcastStatusStopped
+			b.mu.Unlock()
+			if stopped {
+				s.close(false, "设备结束广播")
+				return
+			}
+		}
+		if time.Since(lastActive) > idle {
+			s.close(true, "对讲空闲超时")
+			return
+		}
 	}
+}
 
-	data, ok := StreamList.Response.Load(ssrc)
-	if !ok {
+// 结束对讲，bye 为 true 时通知设备结束会话，设备主动BYE时不需要再发送
+func (s *TalkSession) close(bye bool, msg string) {
+	s.mu.Lock()
+	if s.Status == TalkStatusClosed {
+		s.mu.Unlock()
 		return
 	}
-	talk := data.(*Streams)
-	logrus.Infoln("SipStopTalk", talk.StreamType, m.StreamTypePush)
-	if talk.StreamType == m.StreamTypePush {
-		// 推流，需要发送关闭请求
-		resp := talk.Resp
-		u, ok := _activeDevices.Load(talk.DeviceID)
-		if !ok {
-			return
-		}
-		user := u.(Devices)
-		req := sip.NewRequestFromResponse(sip.BYE, resp)
-		req.SetDestination(user.source)
-		// 根据设备的传输方式发送请求
-		var tx *sip.Transaction
-		var err error
-		if strings.ToLower(user.TransPort) == "tcp" {
-			tx, err = srv.RequestWithProtocol(req, "tcp")
-		} else {
-			tx, err = srv.Request(req) // 默认UDP
-		}
-		if err != nil {
-			logrus.Warningln("sipStopPlay bye fail.id:", talk.DeviceID, talk.ChannelID, "err:", err)
+	s.Status = TalkStatusClosed
+	s.Msg = msg
+	close(s.closed)
+	s.mu.Unlock()
+	_talkSessions.Delete(s.ID)
+	s.release(bye)
+	logrus.Infoln("talk closed,", s.ID, s.ChannelID, msg)
+}
+
+// 释放对讲占用的设备会话和媒体服务器资源
+func (s *TalkSession) release(bye bool) {
+	s.mu.Lock()
+	b, resp, ssrc, recvStream := s.broadcast, s.resp, s.ssrc, s.recvStream
+	s.mu.Unlock()
+	if b != nil {
+		b.close(bye)
+	}
+	if resp != nil && bye {
+		if err := s.bye(resp); err != nil {
+			logrus.Warnln("talk bye fail,", s.ID, s.ChannelID, err)
 		}
-		_, err = sipResponse(tx)
-		if err != nil {
-			logrus.Warnln("sipStopPlay response fail", err)
-			talk.Msg = err.Error()
-		} else {
-			talk.Status = 1
-			talk.Stop = true
+	}
+	if ssrc != "" {
+		if err := _media.StopSendRtp(context.Background(), TalkApp, s.ID, ssrc); err != nil {
+			logrus.Warnln("talk stopSendRtp fail,", s.ID, err)
 		}
-		// db.Save(db.DBClient, play)
+		_ssrcPool.release(ssrc)
+	}
+	if recvStream != "" {
+		_media.CloseStreams(context.Background(), "rtp", recvStream)
+	}
+	// 断开浏览器推流
+	_media.CloseStreams(context.Background(), TalkApp, s.ID)
+}
+
+func (s *TalkSession) bye(resp *sip.Response) error {
+	device, ok := _activeDevices.Get(s.DeviceID)
+	if !ok {
+		return errors.New("设备已离线")
+	}
+	req := sip.NewRequestFromResponse(sip.BYE, resp)
+	req.SetDestination(device.source)
+	// 根据设备的传输方式发送请求
+	var tx *sip.Transaction
+	var err error
+	if strings.ToLower(device.TransPort) == "tcp" {
+		tx, err = srv.RequestWithProtocol(req, "tcp")
+	} else {
+		tx, err = srv.Request(req) // 默认UDP
+	}
+	if err != nil {
+		return err
+	}
+	_, err = sipResponse(tx)
+	return err
+}
+
+// 对讲模式下向设备发送音频中断，结束对讲会话
+func talkSendStopped(app, stream, ssrc, reason string) bool {
+	if app != TalkApp {
+		return false
 	}
-	// StreamList.Response.Delete(ssrc)
-	// if play.T == 0 {
-	// 	StreamList.Succ.Delete(play.ChannelID)
-	// }
+	v, ok := _talkSessions.Load(stream)
+	if !ok {
+		return false
+	}
+	s := v.(*TalkSession)
+	s.mu.Lock()
+	match := s.ssrc != "" && (ssrc == "" || s.ssrc == ssrc)
+	s.mu.Unlock()
+	if !match {
+		return false
+	}
+	go s.close(true, reason)
+	return true
+}
+
+// 根据CallID查找对讲模式的会话
+func talkByCallID(callID string) *TalkSession {
+	var found *TalkSession
+	_talkSessions.Range(func(_, v any) bool {
+		s := v.(*TalkSession)
+		s.mu.Lock()
+		match := s.callID == callID
+		s.mu.Unlock()
+		if match {
+			found = s
+			return false
+		}
+		return true
+	})
+	return found
 }
@@ -0,0 +1,326 @@
+package sipapi
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/panjjo/gosip/db"
+	sip "github.com/panjjo/gosip/sip/s"
+	"github.com/panjjo/gosip/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// 升级状态
+const (
+	UpgradeStatusPending   = "pending"
+	UpgradeStatusUpgrading = "upgrading"
+	UpgradeStatusSuccess   = "success"
+	UpgradeStatusFailed    = "failed"
+	UpgradeStatusCanceled  = "canceled"
+	UpgradeStatusRunning   = "running"
+	UpgradeStatusFinished  = "finished"
+)
+
+// Firmwares 设备固件
+type Firmwares struct {
+	db.DBModel
+	// Name 固件名称
+	Name string `json:"name" gorm:"column:name"`
+	// Firmware 固件版本
+	Firmware string `json:"firmware" gorm:"column:firmware"`
+	// Manufacturer 适用的设备厂商，为空时不限制
+	Manufacturer string `json:"manufacturer" gorm:"column:manufacturer"`
+	// File 固件文件名
+	File string `json:"file" gorm:"column:file"`
+	// Size 文件大小
+	Size int64 `json:"size" gorm:"column:size"`
+	// MD5 文件md5
+	MD5 string `json:"md5" gorm:"column:md5"`
+}
+
+// UpgradeTasks 设备升级任务
+type UpgradeTasks struct {
+	db.DBModel
+	// FirmwareID 固件id
+	FirmwareID uint `json:"firmwareid" gorm:"column:firmwareid"`
+	// Firmware 固件版本
+	Firmware string `json:"firmware" gorm:"column:firmware"`
+	// Filters 设备筛选条件
+	Filters string `json:"filters" gorm:"column:filters"`
+	// Concurrency 同时升级的设备数
+	Concurrency int `json:"concurrency" gorm:"column:concurrency"`
+	// Total 设备总数
+	Total int `json:"total" gorm:"column:total"`
+	// Success 升级成功数
+	Success int `json:"success" gorm:"column:success"`
+	// Failed 升级失败数
+	Failed int `json:"failed" gorm:"column:failed"`
+	// Status 任务状态 running/finished/canceled
+	Status string `json:"status" gorm:"column:status"`
+}
+
+// Upgrades 单台设备升级记录
+type Upgrades struct {
+	db.DBModel
+	// TaskID 升级任务id
+	TaskID uint `json:"taskid" gorm:"column:taskid"`
+	// DeviceID 设备编号
+	DeviceID string `json:"deviceid" gorm:"column:deviceid"`
+	// SessionID 升级会话id
+	SessionID string `json:"sessionid" gorm:"column:sessionid"`
+	// Firmware 升级的固件版本
+	Firmware string `json:"firmware" gorm:"column:firmware"`
+	// Status 升级状态 pending/upgrading/success/failed/canceled
+	Status string `json:"status" gorm:"column:status"`
+	// Reason 失败原因
+	Reason string `json:"reason" gorm:"column:reason"`
+	// StartAt 开始升级时间
+	StartAt int64 `json:"start" gorm:"column:start"`
+	// EndAt 结束升级时间
+	EndAt int64 `json:"end" gorm:"column:end"`
+}
+
+// MessageDeviceUpgradeResult 设备升级结果通知
+type MessageDeviceUpgradeResult struct {
+	CmdType   string `xml:"CmdType"`
+	SN        int    `xml:"SN"`
+	DeviceID  string `xml:"DeviceID"`
+	SessionID string `xml:"SessionID"`
+	Firmware  string `xml:"Firmware"`
+	// UpgradeResult 升级结果 OK/ERROR
+	UpgradeResult string `xml:"UpgradeResult"`
+	// UpgradeFailedReason 升级失败原因
+	UpgradeFailedReason string `xml:"UpgradeFailedReason"`
+}
+
+// 等待设备升级结果 key=sessionid value=chan *MessageDeviceUpgradeResult
+var _upgradeWaits sync.Map
+
+// 运行中的升级任务 key=taskid value=*upgradeRunner
+var _upgradeRunners sync.Map
+
+type upgradeRunner struct {
+	sync.Mutex
+	task     *UpgradeTasks
+	firmware Firmwares
+	cancel   chan struct{}
+	once     sync.Once
+}
+
+// SaveFirmware 保存上传的固件文件
+func SaveFirmware(fw *Firmwares, name string, data io.Reader) error {
+	fw.File = utils.RandString(16) + filepath.Ext(name)
+	if fw.Name == "" {
+		fw.Name = filepath.Base(name)
+	}
+	filename := filepath.Join(config.Upgrade.FilePath, fw.File)
+	if err := os.MkdirAll(config.Upgrade.FilePath, 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	hash := md5.New()
+	size, err := io.Copy(io.MultiWriter(f, hash), data)
+	if err != nil {
+		os.Remove(filename)
+		return err
+	}
+	fw.Size = size
+	fw.MD5 = hex.EncodeToString(hash.Sum(nil))
+	return db.Create(db.DBClient, fw)
+}
+
+// StartUpgradeTask 创建升级任务，按筛选条件选择设备并按并发数依次升级
+func StartUpgradeTask(firmwareID uint, filters string, concurrency int) (*UpgradeTasks, error) {
+	if config.Upgrade.FileURL == "" {
+		return nil, errors.New("未配置固件下载地址")
+	}
+	fw := Firmwares{}
+	if err := db.GetQ(db.DBClient, &fw, db.M{"id=?": firmwareID}); err != nil {
+		if db.RecordNotFound(err) {
+			return nil, errors.New("固件不存在")
+		}
+		return nil, err
+	}
+	if concurrency <= 0 {
+		concurrency = config.Upgrade.Concurrency
+	}
+	devices := []Devices{}
+	if _, err := db.FindWithJson(db.DBClient, new(Devices), &devices, filters, "", 0, -1, false); err != nil {
+		return nil, err
+	}
+	task := &UpgradeTasks{
+		FirmwareID:  fw.ID,
+		Firmware:    fw.Firmware,
+		Filters:     filters,
+		Concurrency: concurrency,
+		Status:      UpgradeStatusRunning,
+	}
+	upgrades := []*Upgrades{}
+	for _, device := range devices {
+		if fw.Manufacturer != "" && !strings.EqualFold(device.Manufacturer, fw.Manufacturer) {
+			// 厂商不匹配的设备不升级
+			continue
+		}
+		upgrades = append(upgrades, &Upgrades{DeviceID: device.DeviceID, Firmware: fw.Firmware, Status: UpgradeStatusPending})
+	}
+	if len(upgrades) == 0 {
+		return nil, errors.New("没有符合条件的设备")
+	}
+	task.Total = len(upgrades)
+	if err := db.Create(db.DBClient, task); err != nil {
+		return nil, err
+	}
+	for _, u := range upgrades {
+		u.TaskID = task.ID
+		if err := db.Create(db.DBClient, u); err != nil {
+			return nil, err
+		}
+	}
+	runner := &upgradeRunner{task: task, firmware: fw, cancel: make(chan struct{})}
+	_upgradeRunners.Store(task.ID, runner)
+	go runner.run(upgrades)
+	return task, nil
+}
+
+// CancelUpgradeTask 取消升级任务，未开始的设备不再升级，正在升级的设备继续等待结果
+func CancelUpgradeTask(taskID uint) error {
+	v, ok := _upgradeRunners.Load(taskID)
+	if !ok {
+		return errors.New("升级任务不存在或已结束")
+	}
+	runner := v.(*upgradeRunner)
+	runner.once.Do(func() { close(runner.cancel) })
+	return nil
+}
+
+func (r *upgradeRunner) run(upgrades []*Upgrades) {
+	defer _upgradeRunners.Delete(r.task.ID)
+	sem := make(chan struct{}, r.task.Concurrency)
+	wg := sync.WaitGroup{}
+	canceled := false
+	for _, u := range upgrades {
+		if !canceled {
+			select {
+			case <-r.cancel:
+				canceled = true
+			case sem <- struct{}{}:
+			}
+		}
+		if canceled {
+			u.Status = UpgradeStatusCanceled
+			u.Reason = "任务已取消"
+			db.Save(db.DBClient, u)
+			continue
+		}
+		wg.Add(1)
+		go func(u *Upgrades) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			r.upgrade(u)
+		}(u)
+	}
+	wg.Wait()
+	r.Lock()
+	r.task.Status = UpgradeStatusFinished
+	if canceled {
+		r.task.Status = UpgradeStatusCanceled
+	}
+	db.Save(db.DBClient, r.task)
+	r.Unlock()
+	logrus.Infoln("upgrade task finished,", r.task.ID, r.task.Status, "success:", r.task.Success, "failed:", r.task.Failed)
+}
+
+// 升级单台设备并等待升级结果
+func (r *upgradeRunner) upgrade(u *Upgrades) {
+	u.StartAt = time.Now().Unix()
+	u.Status = UpgradeStatusUpgrading
+	u.SessionID = utils.RandString(32)
+	db.Save(db.DBClient, u)
+
+	result := make(chan *MessageDeviceUpgradeResult, 1)
+	_upgradeWaits.Store(u.SessionID, result)
+	defer _upgradeWaits.Delete(u.SessionID)
+
+	err := r.sendUpgrade(u)
+	if err == nil {
+		timeout := time.NewTimer(time.Duration(config.Upgrade.Timeout) * time.Minute)
+		defer timeout.Stop()
+		select {
+		case res := <-result:
+			if res.UpgradeResult != "OK" {
+				err = fmt.Errorf("升级失败:%s", res.UpgradeFailedReason)
+			}
+		case <-timeout.C:
+			err = errors.New("等待升级结果超时")
+		}
+	}
+	u.EndAt = time.Now().Unix()
+	r.Lock()
+	if err != nil {
+		u.Status = UpgradeStatusFailed
+		u.Reason = err.Error()
+		r.task.Failed++
+	} else {
+		u.Status = UpgradeStatusSuccess
+		r.task.Success++
+	}
+	db.Save(db.DBClient, r.task)
+	r.Unlock()
+	db.Save(db.DBClient, u)
+	go notify(notifyDevicesUpgrade(*u))
+}
+
+// 向设备下发升级指令
+func (r *upgradeRunner) sendUpgrade(u *Upgrades) error {
+	device, ok := _activeDevices.Get(u.DeviceID)
+	if !ok {
+		return errors.New("设备已离线")
+	}
+	fileURL := fmt.Sprintf("%s/firmwares/files/%s", strings.TrimRight(config.Upgrade.FileURL, "/"), r.firmware.File)
+	sn := utils.RandInt(100000, 999999)
+	body, err := sipMessageQuery(device, nil, "DeviceControl", device.DeviceID, sn,
+		sip.GetDeviceUpgradeXML(device.DeviceID, sn, r.firmware.Firmware, fileURL, r.firmware.Manufacturer, u.SessionID))
+	if err != nil {
+		return err
+	}
+	_, err = parseMessageResponse(body)
+	return err
+}
+
+// sipMessageDeviceUpgradeResult 设备升级结果通知
+func sipMessageDeviceUpgradeResult(_ Devices, body []byte) error {
+	message := &MessageDeviceUpgradeResult{}
+	if err := utils.XMLDecode(body, message); err != nil {
+		logrus.Errorln("sipMessageDeviceUpgradeResult Unmarshal xml err:", err, "body:", string(body))
+		return err
+	}
+	if v, ok := _upgradeWaits.Load(message.SessionID); ok {
+		select {
+		case v.(chan *MessageDeviceUpgradeResult) <- message:
+		default:
+		}
+		return nil
+	}
+	logrus.Infoln("sipMessageDeviceUpgradeResult not found wait,", message.DeviceID, message.SessionID, message.UpgradeResult)
+	return nil
+}
+
+// 服务重启后未完成的升级无法继续等待结果，标记为失败
+func resetUpgrades() {
+	db.UpdateAll(db.DBClient, new(Upgrades), db.M{"status in (?)": []string{UpgradeStatusPending, UpgradeStatusUpgrading}}, db.M{"status": UpgradeStatusFailed, "reason": "服务重启"})
+	db.UpdateAll(db.DBClient, new(UpgradeTasks), db.M{"status=?": UpgradeStatusRunning}, db.M{"status": UpgradeStatusCanceled})
+}
@@ -0,0 +1,174 @@
+package sipapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/panjjo/gosip/media"
+	"github.com/panjjo/gosip/utils"
+	"github.com/sirupsen/logrus"
+)
+
+var errViewerNotFound = errors.New("观看会话不存在或已过期")
+
+// ViewerSession 观看会话，存在会话时流保持打开
+type ViewerSession struct {
+	Token     string `json:"token"`
+	StreamID  string `json:"streamid"`
+	ChannelID string `json:"channelid"`
+	// 创建时间
+	Created int64 `json:"created"`
+	// 最后心跳时间
+	Active int64 `json:"active"`
+}
+
+type viewerList struct {
+	mu sync.Mutex
+	// key=token
+	sessions map[string]*ViewerSession
+	// key=streamid value=观看会话token，由观看会话管理生命周期的流
+	streams map[string]map[string]bool
+	// key=streamid value=最后一个会话结束的时间
+	idle map[string]int64
+}
+
+var _viewers = &viewerList{
+	sessions: map[string]*ViewerSession{},
+	streams:  map[string]map[string]bool{},
+	idle:     map[string]int64{},
+}
+
+// ViewerOpen 为流创建观看会话
+func ViewerOpen(data *Streams) ViewerSession {
+	now := time.Now().Unix()
+	session := &ViewerSession{
+		Token:     utils.RandString(32),
+		StreamID:  data.StreamID,
+		ChannelID: data.ChannelID,
+		Created:   now,
+		Active:    now,
+	}
+	_viewers.mu.Lock()
+	defer _viewers.mu.Unlock()
+	_viewers.sessions[session.Token] = session
+	if _, ok := _viewers.streams[data.StreamID]; !ok {
+		_viewers.streams[data.StreamID] = map[string]bool{}
+	}
+	_viewers.streams[data.StreamID][session.Token] = true
+	delete(_viewers.idle, data.StreamID)
+	return *session
+}
+
+// ViewerHeartbeat 观看会话心跳
+func ViewerHeartbeat(token string) (ViewerSession, error) {
+	_viewers.mu.Lock()
+	defer _viewers.mu.Unlock()
+	session, ok := _viewers.sessions[token]
+	if !ok {
+		return ViewerSession{}, errViewerNotFound
+	}
+	session.Active = time.Now().Unix()
+	return *session, nil
+}
+
+// ViewerRelease 结束观看会话，流在最后一个会话结束并超过保留时间后关闭
+func ViewerRelease(token string) error {
+	_viewers.mu.Lock()
+	defer _viewers.mu.Unlock()
+	session, ok := _viewers.sessions[token]
+	if !ok {
+		return errViewerNotFound
+	}
+	viewerRemove(session, time.Now().Unix())
+	return nil
+}
+
+// 移除会话，需持有锁
+func viewerRemove(session *ViewerSession, now int64) {
+	delete(_viewers.sessions, session.Token)
+	if tokens, ok := _viewers.streams[session.StreamID]; ok {
+		delete(tokens, session.Token)
+		if len(tokens) == 0 {
+			_viewers.idle[session.StreamID] = now
+		}
+	}
+}
+
+// StreamViewers 流的观看会话数量
+func StreamViewers(streamID string) int {
+	_viewers.mu.Lock()
+	defer _viewers.mu.Unlock()
+	return len(_viewers.streams[streamID])
+}
+
+// IsViewerStream 流是否由观看会话管理，此类流无人观看时不由媒体服务器关闭
+func IsViewerStream(streamID string) bool {
+	_viewers.mu.Lock()
+	defer _viewers.mu.Unlock()
+	_, ok := _viewers.streams[streamID]
+	return ok
+}
+
+// 流已关闭，移除流上的所有会话
+func viewerStreamClosed(streamID string) {
+	_viewers.mu.Lock()
+	defer _viewers.mu.Unlock()
+	for token := range _viewers.streams[streamID] {
+		delete(_viewers.sessions, token)
+	}
+	delete(_viewers.streams, streamID)
+	delete(_viewers.idle, streamID)
+}
+
+// CheckViewers 定时清理超时的观看会话，关闭没有会话且超过保留时间的流
+func CheckViewers() {
+	now := time.Now().Unix()
+	closes := []string{}
+	_viewers.mu.Lock()
+	for _, session := range _viewers.sessions {
+		if now-session.Active > int64(config.Stream.ViewerTimeout) {
+			logrus.Infoln("viewer session timeout,", session.StreamID, session.Token)
+			viewerRemove(session, now)
+		}
+	}
+	for streamID, since := range _viewers.idle {
+		if now-since >= int64(config.Stream.Linger) {
+			closes = append(closes, streamID)
+		}
+	}
+	_viewers.mu.Unlock()
+	for _, streamID := range closes {
+		if StreamViewers(streamID) > 0 {
+			// 期间有新的会话
+			continue
+		}
+		logrus.Infoln("closeStream viewer linger timeout", streamID)
+		SipStopPlay(streamID)
+	}
+}
+
+// LiveStream 通道当前可用的直播流，已失效的流关闭后返回false
+func LiveStream(channelID string) (*Streams, bool) {
+	v, ok := StreamList.Succ.Load(channelID)
+	if !ok {
+		return nil, false
+	}
+	data := v.(*Streams)
+	healthy := true
+	if !data.Stream {
+		// 尚未收到流，等待超时后视为失效
+		healthy = time.Now().Unix() <= data.Ext
+	} else {
+		medias, err := streamMedia(data).GetMediaList(context.Background(), media.MediaListReq{App: "rtp", Stream: data.StreamID})
+		// 媒体服务器异常时无法判断，由节点故障转移处理
+		healthy = err != nil || len(medias) > 0
+	}
+	if !healthy {
+		logrus.Infoln("closeStream live stream unhealthy", data.StreamID)
+		SipStopPlay(data.StreamID)
+		return nil, false
+	}
+	return data, true
+}
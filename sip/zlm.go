@@ -1,65 +1,69 @@
 package sipapi
 
 import (
-	"fmt"
-	"net/url"
+	"context"
+	"errors"
 
-	"github.com/panjjo/gosip/utils"
+	"github.com/panjjo/gosip/db"
+	"github.com/panjjo/gosip/media"
 	"github.com/sirupsen/logrus"
 )
 
-type zlmGetMediaListReq struct {
-	vhost    string
-	schema   string
-	streamID string
-	app      string
-}
-type zlmGetMediaListResp struct {
-	Code int                       `json:"code"`
-	Data []zlmGetMediaListDataResp `json:"data"`
-}
-type zlmGetMediaListDataResp struct {
-	App        string                  `json:"app"`
-	Stream     string                  `json:"stream"`
-	Schema     string                  `json:"schema"`
-	OriginType int                     `json:"originType"`
-	Tracks     []zlmGetMediaListTracks `json:"tracks"`
-}
-type zlmGetMediaListTracks struct {
-	Type    int `json:"codec_type"`
-	CodecID int `json:"codec_id"`
-	Height  int `json:"height"`
-	Width   int `json:"width"`
-	FPS     int `json:"fps"`
-}
-
-// zlm 获取流列表信息
-func zlmGetMediaList(req zlmGetMediaListReq) zlmGetMediaListResp {
-	res := zlmGetMediaListResp{}
-	reqStr := "/index/api/getMediaList?secret=" + config.Media.Secret
-	if req.streamID != "" {
-		reqStr += "&stream=" + req.streamID
-	}
-	if req.app != "" {
-		reqStr += "&app=" + req.app
-	}
-	if req.schema != "" {
-		reqStr += "&schema=" + req.schema
-	}
-	if req.vhost != "" {
-		reqStr += "&vhost=" + req.vhost
-	}
-	body, err := utils.GetRequest(config.Media.RESTFUL + reqStr)
-	if err != nil {
-		logrus.Errorln("get stream mediaList fail,", err)
-		return res
-	}
-	if err = utils.JSONDecode(body, &res); err != nil {
-		logrus.Errorln("get stream mediaList fail,", err)
-		return res
-	}
-	logrus.Traceln("zlmGetMediaList ", string(body), req.streamID)
-	return res
+// 默认节点的媒体服务器，对讲、广播、录制等使用
+var _media media.MediaServer
+
+// SetMediaServer 替换默认节点的媒体服务器驱动，测试时可使用 media.Fake
+func SetMediaServer(s media.MediaServer) {
+	_media = s
+	_mediaNodes.mu.Lock()
+	if node, ok := _mediaNodes.nodes[config.Media.ID]; ok {
+		node.driver = s
+	}
+	_mediaNodes.mu.Unlock()
+}
+
+// RtpServerTimeout 媒体服务器开启的rtp端口超时未收到设备的流，关闭端口和流并通知设备结束会话
+func RtpServerTimeout(mediaServerID, streamID string) {
+	reason := "设备未发送媒体流，收流端口超时"
+	v, ok := StreamList.Response.Load(streamID)
+	if !ok {
+		// 流已关闭，只释放端口，未知节点的通知不处理
+		if node, ok := findMediaNode(mediaServerID); ok {
+			if err := node.driver.CloseRtpServer(context.Background(), streamID); err != nil && !errors.Is(err, media.ErrNotFound) {
+				logrus.Warnln("rtp server timeout close fail,", mediaServerID, streamID, err)
+			}
+		} else {
+			logrus.Warnln("rtp server timeout, media node not found,", mediaServerID, streamID)
+		}
+		db.UpdateAll(db.DBClient, new(Streams), db.M{"streamid=?": streamID, "stop=?": false}, db.M{"status": 1, "stop": true, "msg": reason})
+		return
+	}
+	play := v.(*Streams)
+	logrus.Infoln("closeStream rtp server timeout", streamID, play.ChannelID)
+	SipStopPlay(streamID)
+	// 以超时原因记录关闭状态，覆盖BYE失败等信息
+	play.Status = 1
+	play.Stop = true
+	play.Msg = reason
+	db.Save(db.DBClient, play)
+	go notify(notifyStreamClosed(play, reason))
+}
+
+// SendRtpStopped 媒体服务器停止向设备发送rtp，结束对应的对讲或广播会话
+func SendRtpStopped(app, stream, ssrc, msg string) {
+	reason := "媒体服务器停止发送rtp"
+	if msg != "" {
+		reason = reason + ":" + msg
+	}
+	found := talkSendStopped(app, stream, ssrc, reason)
+	if broadcastSendStopped(app, stream, ssrc, reason) {
+		found = true
+	}
+	if !found {
+		logrus.Infoln("send rtp stopped, session not found,", app, stream, ssrc)
+		return
+	}
+	go notify(notifySendRtpStopped(app, stream, ssrc, reason))
 }
 
 var zlmDeviceVFMap = map[int]string{
@@ -76,169 +80,3 @@ func transZLMDeviceVF(t int) string {
 	}
 	return "undefind"
 }
-
-type rtpInfo struct {
-	Code  int  `json:"code"`
-	Exist bool `json:"exist"`
-}
-
-// 获取流在zlm上的信息
-func zlmGetMediaInfo(ssrc string) rtpInfo {
-	res := rtpInfo{}
-	body, err := utils.GetRequest(config.Media.RESTFUL + "/index/api/getRtpInfo?secret=" + config.Media.Secret + "&stream_id=" + ssrc)
-	if err != nil {
-		logrus.Errorln("get stream rtpInfo fail,", err)
-		return res
-	}
-	if err = utils.JSONDecode(body, &res); err != nil {
-		logrus.Errorln("get stream rtpInfo fail,", err)
-		return res
-	}
-	return res
-}
-
-// zlm 关闭流
-func zlmCloseStream(ssrc string) {
-	utils.GetRequest(config.Media.RESTFUL + "/index/api/close_streams?secret=" + config.Media.Secret + "&stream=" + ssrc)
-}
-
-// zlm 开始录制视频流
-func zlmStartRecord(values url.Values) error {
-	body, err := utils.GetRequest(config.Media.RESTFUL + "/index/api/startRecord?" + values.Encode())
-	if err != nil {
-		return err
-	}
-	tmp := map[string]interface{}{}
-	err = utils.JSONDecode(body, &tmp)
-	if err != nil {
-		return err
-	}
-	if code, ok := tmp["code"]; !ok || fmt.Sprint(code) != "0" {
-		return utils.NewError(nil, tmp)
-	}
-	return nil
-}
-
-// zlm 停止录制
-func zlmStopRecord(values url.Values) error {
-	body, err := utils.GetRequest(config.Media.RESTFUL + "/index/api/stopRecord?" + values.Encode())
-	if err != nil {
-		return err
-	}
-	tmp := map[string]interface{}{}
-	err = utils.JSONDecode(body, &tmp)
-	if err != nil {
-		return err
-	}
-	if code, ok := tmp["code"]; !ok || fmt.Sprint(code) != "0" {
-		return utils.NewError(nil, tmp)
-	}
-	return nil
-}
-
-// ZLM openRtpServer 请求结构
-type zlmOpenRtpServerReq struct {
-	Port      string `json:"port"`
-	StreamID  string `json:"stream_id"`
-	EnableTCP string `json:"enable_tcp"`
-}
-
-// ZLM openRtpServer 响应结构
-type zlmOpenRtpServerResp struct {
-	Code int `json:"code"`
-	Port int `json:"port"`
-}
-
-// zlm 开启 RTP 服务器，指定 streamId
-func zlmOpenRtpServer(req zlmOpenRtpServerReq) (zlmOpenRtpServerResp, error) {
-	res := zlmOpenRtpServerResp{}
-
-	params := url.Values{}
-	params.Set("secret", config.Media.Secret)
-	params.Set("stream_id", req.StreamID)
-	params.Set("enable_tcp", req.EnableTCP)
-	params.Set("port", req.Port)
-	if req.StreamID != "" {
-		params.Set("stream_id", req.StreamID)
-	}
-
-	body, err := utils.GetRequest(config.Media.RESTFUL + "/index/api/openRtpServer?" + params.Encode())
-	if err != nil {
-		logrus.Errorln("zlm openRtpServer fail,", err)
-		return res, err
-	}
-
-	if err = utils.JSONDecode(body, &res); err != nil {
-		logrus.Errorln("zlm openRtpServer decode fail,", err)
-		return res, err
-	}
-
-	logrus.Traceln("zlmOpenRtpServer success", string(body), req.StreamID)
-	return res, nil
-}
-
-// zlm 关闭 RTP 服务器
-func zlmCloseRtpServer(streamID string) error {
-	params := url.Values{}
-	params.Set("secret", config.Media.Secret)
-	params.Set("stream_id", streamID)
-
-	body, err := utils.GetRequest(config.Media.RESTFUL + "/index/api/closeRtpServer?" + params.Encode())
-	if err != nil {
-		logrus.Errorln("zlm closeRtpServer fail,", err)
-		return err
-	}
-
-	tmp := map[string]interface{}{}
-	if err = utils.JSONDecode(body, &tmp); err != nil {
-		logrus.Errorln("zlm closeRtpServer decode fail,", err)
-		return err
-	}
-
-	if code, ok := tmp["code"]; !ok || fmt.Sprint(code) != "0" {
-		return utils.NewError(nil, tmp)
-	}
-
-	logrus.Traceln("zlmCloseRtpServer success", streamID)
-	return nil
-}
-
-// ZLM startSendRtpPassive 请求结构
-type zlmStartSendRtpPassivReq struct {
-	Vhost  string `json:"vhost"`
-	App    string `json:"app"`
-	Stream string `json:"stream"`
-	Ssrc   string `json:"ssrc"`
-}
-
-// ZLM startSendRtpPassive 响应结构
-type zlmStartSendRtpPassivResp struct {
-	Code int `json:"code"`
-	Port int `json:"local_port"`
-}
-
-// zlm 开启 RTP 服务器，指定 streamId
-func zlmStartSendRtpPassive(req zlmStartSendRtpPassivReq) (zlmStartSendRtpPassivResp, error) {
-	res := zlmStartSendRtpPassivResp{}
-
-	params := url.Values{}
-	params.Set("secret", config.Media.Secret)
-	params.Set("vhost", req.Vhost)
-	params.Set("app", req.App)
-	params.Set("stream", req.Stream)
-	params.Set("ssrc", req.Ssrc)
-
-	body, err := utils.GetRequest(config.Media.RESTFUL + "/index/api/startSendRtpPassive?" + params.Encode())
-	if err != nil {
-		logrus.Errorln("zlm startSendRtpPassive fail,", err)
-		return res, err
-	}
-
-	if err = utils.JSONDecode(body, &res); err != nil {
-		logrus.Errorln("zlm startSendRtpPassive decode fail,", err)
-		return res, err
-	}
-
-	logrus.Traceln("startSendRtpPassive success", string(body), req.Stream)
-	return res, nil
-}